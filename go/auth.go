@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenRefreshMargin is how long before its expiry a token is refreshed.
+	tokenRefreshMargin = time.Minute
+	// tokenRefreshTimeout bounds a call of TokenSource.Token.
+	tokenRefreshTimeout = 30 * time.Second
+)
+
+// TokenSource provides the bearer tokens of a client; see Config.TokenSource.
+type TokenSource interface {
+	// Token returns a token and its expiry. A zero expiry means that the
+	// token does not expire.
+	Token(ctx context.Context) (token string, expiry time.Time, err error)
+}
+
+// tokenCache caches the token of a TokenSource until shortly before its
+// expiry. Concurrent requests share one call of the source.
+type tokenCache struct {
+	source TokenSource
+	now    func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	// refresh is the call of the source in flight, if any.
+	refresh *tokenRefresh
+}
+
+type tokenRefresh struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+func newTokenCache(config *Config) *tokenCache {
+	if config == nil || config.TokenSource == nil {
+		return nil
+	}
+	return &tokenCache{source: config.TokenSource, now: time.Now}
+}
+
+// get returns the cached token, refreshing it if it expires within
+// tokenRefreshMargin. Errors of the source wrap ErrAuth.
+//
+// A token that has not expired yet is returned at once while it is refreshed
+// in the background; otherwise, get waits for the refresh until ctx is done.
+func (c *tokenCache) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	now := c.now()
+	valid := c.token != "" && (c.expiry.IsZero() || now.Before(c.expiry))
+	if valid && (c.expiry.IsZero() || now.Before(c.expiry.Add(-tokenRefreshMargin))) {
+		token := c.token
+		c.mu.Unlock()
+		return token, nil
+	}
+	r := c.refresh
+	if r == nil {
+		r = &tokenRefresh{done: make(chan struct{})}
+		c.refresh = r
+		go c.doRefresh(context.WithoutCancel(ctx), r)
+	}
+	token := c.token
+	c.mu.Unlock()
+
+	if valid {
+		return token, nil
+	}
+	select {
+	case <-r.done:
+		if r.err != nil {
+			return "", r.err
+		}
+		return r.token, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (c *tokenCache) doRefresh(ctx context.Context, r *tokenRefresh) {
+	ctx, cancel := context.WithTimeout(ctx, tokenRefreshTimeout)
+	defer cancel()
+	token, expiry, err := c.source.Token(ctx)
+	if err == nil && token == "" {
+		err = errors.New("empty token")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		r.err = fmt.Errorf("%w: token source: %w", ErrAuth, err)
+	} else {
+		r.token = token
+		c.token, c.expiry = token, expiry
+	}
+	c.refresh = nil
+	close(r.done)
+}
+
+// invalidate drops token if it is still the cached one, e.g., after ScopeDB
+// rejected it, so that the next get refreshes it.
+func (c *tokenCache) invalidate(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
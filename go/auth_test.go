@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenSource struct {
+	calls   atomic.Int32
+	release chan struct{}
+	token   func(n int32) (string, time.Time, error)
+}
+
+func (s *fakeTokenSource) Token(context.Context) (string, time.Time, error) {
+	n := s.calls.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return s.token(n)
+}
+
+func TestTokenSource(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if r.Header.Get("Authorization") == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			_, err := decodeCompressedRequestBody(r)
+			require.NoError(t, err)
+		}
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	start := time.Now()
+	var elapsed atomic.Int64
+	source := &fakeTokenSource{token: func(n int32) (string, time.Time, error) {
+		switch n {
+		case 1:
+			return "t1", start.Add(time.Hour), nil
+		case 2:
+			return "revoked", start.Add(2 * time.Hour), nil
+		default:
+			return "t3", time.Time{}, nil
+		}
+	}}
+	c := NewClient(&Config{Endpoint: server.URL, TokenSource: source})
+	c.http.tokens.now = func() time.Time { return start.Add(time.Duration(elapsed.Load())) }
+	ctx := context.Background()
+	execute := func() {
+		_, err := c.Statement("VALUES (1)").Execute(ctx)
+		require.NoError(t, err)
+	}
+
+	// The token is cached until shortly before its expiry.
+	execute()
+	execute()
+	require.EqualValues(t, 1, source.calls.Load())
+
+	// Then it is refreshed in the background while still in use.
+	elapsed.Store(int64(time.Hour - time.Second))
+	execute()
+	require.Eventually(t, func() bool {
+		c.http.tokens.mu.Lock()
+		defer c.http.tokens.mu.Unlock()
+		return c.http.tokens.token == "revoked"
+	}, time.Second, time.Millisecond)
+
+	// A rejected token is refreshed and the request retried with its body.
+	execute()
+	require.EqualValues(t, 3, source.calls.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{
+		"Bearer t1", "Bearer t1",
+		"Bearer t1",
+		"Bearer revoked", "Bearer t3",
+	}, auths)
+}
+
+func TestTokenSourceSingleFlight(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(writeEmptyResponse))
+	t.Cleanup(server.Close)
+	source := &fakeTokenSource{
+		release: make(chan struct{}),
+		token: func(int32) (string, time.Time, error) {
+			return "t", time.Now().Add(time.Hour), nil
+		},
+	}
+	c := NewClient(&Config{Endpoint: server.URL, TokenSource: source})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Statement("VALUES (1)").Execute(context.Background())
+			require.NoError(t, err)
+		}()
+	}
+	require.Eventually(t, func() bool { return source.calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(source.release)
+	wg.Wait()
+	require.EqualValues(t, 1, source.calls.Load())
+}
+
+func TestTokenSourceError(t *testing.T) {
+	t.Parallel()
+
+	source := &fakeTokenSource{token: func(int32) (string, time.Time, error) {
+		return "", time.Time{}, errors.New("identity provider unavailable")
+	}}
+	c := NewClient(&Config{Endpoint: "http://scopedb.test", TokenSource: source})
+	_, err := c.Statement("VALUES (1)").Execute(context.Background())
+	require.ErrorIs(t, err, ErrAuth)
+	require.EqualError(t, err, "submit statement: authentication failed: token source: identity provider unavailable")
+	require.Zero(t, StatusCodeOf(err))
+
+	err = (&Config{APIKey: "key", TokenSource: source}).Validate()
+	require.EqualError(t, err, "api key and token source are mutually exclusive")
+}
+
+func TestBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var users []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		mu.Lock()
+		users = append(users, user)
+		mu.Unlock()
+		if !ok || password != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"invalid credentials"}`))
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	var logs syncBuffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := context.Background()
+	c := NewClient(&Config{Endpoint: server.URL, Username: "scopedb", Password: "hunter2", DebugLogger: logger})
+	_, err := c.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+
+	c = NewClient(&Config{Endpoint: server.URL, Username: "scopedb", Password: "wrong-s3cret", DebugLogger: logger})
+	_, err = c.Statement("VALUES (1)").Execute(ctx)
+	require.Equal(t, http.StatusUnauthorized, StatusCodeOf(err))
+
+	// Neither the credentials nor their encoding leak into logs or errors.
+	for _, secret := range []string{"hunter2", "wrong-s3cret", "c2NvcGVkYjpo", "c2NvcGVkYjp3"} {
+		require.NotContains(t, logs.buf.String(), secret)
+		require.NotContains(t, err.Error(), secret)
+	}
+	require.Contains(t, logs.buf.String(), `"Authorization":["REDACTED"]`)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"scopedb", "scopedb"}, users)
+}
+
+func TestBasicAuthValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, (&Config{Username: "scopedb"}).Validate())
+	require.EqualError(t, (&Config{Username: "scopedb", APIKey: "key"}).Validate(),
+		"basic authentication is exclusive with api key and token source")
+	require.EqualError(t, (&Config{Username: "scopedb", TokenSource: &fakeTokenSource{}}).Validate(),
+		"basic authentication is exclusive with api key and token source")
+	require.EqualError(t, (&Config{Password: "hunter2"}).Validate(), "password requires a username")
+}
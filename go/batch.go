@@ -0,0 +1,218 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBatchRetries is the default number of times SubmitBatch resubmits
+	// a statement that ScopeDB rejected with 429 Too Many Requests.
+	defaultBatchRetries = 5
+	// batchBackoffInitial and batchBackoffMax bound the pause of SubmitBatch
+	// after a 429 Too Many Requests response.
+	batchBackoffInitial = 100 * time.Millisecond
+	batchBackoffMax     = 5 * time.Second
+)
+
+// BatchOption configures Client.SubmitBatch.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	retries     int
+	failFast    bool
+}
+
+// BatchConcurrency sets the maximum number of submissions in flight at once.
+// The default is 8.
+func BatchConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// BatchRetries sets the number of times a statement rejected with 429 Too
+// Many Requests is resubmitted. The default is 5.
+func BatchRetries(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.retries = max(n, 0)
+	}
+}
+
+// BatchFailFast makes the first failure abort SubmitBatch: statements that
+// are not submitted yet are skipped, and those already submitted are
+// cancelled on the server.
+func BatchFailFast() BatchOption {
+	return func(o *batchOptions) {
+		o.failFast = true
+	}
+}
+
+// SubmitBatch submits many statements to ScopeDB and returns their handles in
+// the order of stmts.
+//
+// ScopeDB has no batch endpoint, so the statements are submitted over a pool
+// of concurrent requests; see BatchConcurrency. The order in which ScopeDB
+// receives them is unspecified, so statements that depend on each other must
+// not be in the same batch. When ScopeDB responds with 429 Too Many Requests,
+// all submissions pause with an exponential backoff, and the rejected
+// statement is resubmitted; see BatchRetries.
+//
+// If statements fail to submit, the returned slice holds the handles of those
+// that were submitted, nil for the others, along with an *ExecuteAllError
+// whose StatementError.Index identifies the failed statements. By default,
+// a failure does not affect the other statements; see BatchFailFast.
+func (c *Client) SubmitBatch(ctx context.Context, stmts []string, opts ...BatchOption) ([]*StatementHandle, error) {
+	o := batchOptions{concurrency: defaultMaxInFlight, retries: defaultBatchRetries}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	runCtx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	handles := make([]*StatementHandle, len(stmts))
+	errs := make([]error, len(stmts))
+	backoff := &batchBackoff{}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(o.concurrency, len(stmts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				handles[i], errs[i] = c.submitBatched(runCtx, stmts[i], backoff, o.retries)
+				if errs[i] != nil && o.failFast {
+					abort()
+				}
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(stmts); next++ {
+		select {
+		case jobs <- next:
+		case <-runCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	for i := next; i < len(stmts); i++ {
+		errs[i] = runCtx.Err()
+	}
+
+	aborted := runCtx.Err() != nil
+	if aborted {
+		cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), abortCancelTimeout)
+		defer cancel()
+		for _, h := range handles {
+			if h != nil {
+				_, _ = h.Cancel(cancelCtx)
+			}
+		}
+	}
+
+	var failures []*StatementError
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		// Skip the statements that were interrupted by the abort itself.
+		if aborted && ctx.Err() == nil && errors.Is(err, context.Canceled) {
+			continue
+		}
+		failures = append(failures, &StatementError{Index: i, Err: err})
+	}
+	if len(failures) > 0 {
+		return handles, &ExecuteAllError{Errors: failures}
+	}
+	return handles, nil
+}
+
+// submitBatched submits stmt, resubmitting it up to retries times while
+// ScopeDB responds with 429 Too Many Requests.
+func (c *Client) submitBatched(ctx context.Context, stmt string, backoff *batchBackoff, retries int) (*StatementHandle, error) {
+	for attempt := 0; ; attempt++ {
+		if err := backoff.wait(ctx); err != nil {
+			return nil, err
+		}
+		h, err := c.Statement(stmt).Submit(ctx)
+		if err == nil {
+			backoff.reset()
+			return h, nil
+		}
+		if StatusCodeOf(err) != http.StatusTooManyRequests || attempt >= retries {
+			return nil, err
+		}
+		backoff.throttle()
+	}
+}
+
+// batchBackoff is the backoff shared by the submissions of a batch, so that
+// one 429 Too Many Requests response pauses all of them.
+type batchBackoff struct {
+	mu       sync.Mutex
+	until    time.Time
+	interval time.Duration
+}
+
+// wait blocks until the backoff is over or ctx is done.
+func (b *batchBackoff) wait(ctx context.Context) error {
+	b.mu.Lock()
+	d := time.Until(b.until)
+	b.mu.Unlock()
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttle doubles the backoff and pauses the submissions for its duration.
+func (b *batchBackoff) throttle() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.interval = min(max(2*b.interval, batchBackoffInitial), batchBackoffMax)
+	if until := time.Now().Add(b.interval); until.After(b.until) {
+		b.until = until
+	}
+}
+
+// reset restores the initial backoff after a successful submission.
+func (b *batchBackoff) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.interval = 0
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type batchTestServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	throttle  int
+	throttled int
+	stmts     map[uuid.UUID]string
+	cancelled []string
+}
+
+// newBatchTestServer rejects the first throttle submissions with 429 and
+// statements named FAIL with 400.
+func newBatchTestServer(t *testing.T, throttle int) *batchTestServer {
+	t.Helper()
+
+	s := &batchTestServer{throttle: throttle, stmts: map[uuid.UUID]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/statements", func(w http.ResponseWriter, r *http.Request) {
+		body, err := decodeCompressedRequestBody(r)
+		require.NoError(t, err)
+		var req statementRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch {
+		case s.throttled < s.throttle:
+			s.throttled++
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = fmt.Fprint(w, `{"message":"too many requests"}`)
+			return
+		case req.Statement == "FAIL":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"message":"syntax error"}`)
+			return
+		}
+		id := uuid.New()
+		s.stmts[id] = req.Statement
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"statement_id": id,
+			"status":       StatementStatusRunning,
+			"created_at":   "2026-01-01T00:00:00Z",
+			"progress":     map[string]any{},
+		})
+	})
+	mux.HandleFunc("POST /v1/statements/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.cancelled = append(s.cancelled, s.stmts[uuid.MustParse(r.PathValue("id"))])
+		s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status":     StatementStatusCancelled,
+			"message":    "",
+			"created_at": "2026-01-01T00:00:00Z",
+		})
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSubmitBatch(t *testing.T) {
+	t.Parallel()
+
+	server := newBatchTestServer(t, 3)
+	c := NewClient(&Config{Endpoint: server.URL})
+	ctx := context.Background()
+
+	stmts := []string{"S0", "S1", "FAIL", "S3", "S4", "S5"}
+	handles, err := c.SubmitBatch(ctx, stmts, BatchConcurrency(3))
+	var batchErr *ExecuteAllError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Errors, 1)
+	require.Equal(t, 2, batchErr.Errors[0].Index)
+	require.ErrorContains(t, err, "statement 2 failed: submit statement: status 400: syntax error")
+
+	require.Len(t, handles, len(stmts))
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Equal(t, 3, server.throttled)
+	for i, h := range handles {
+		if i == 2 {
+			require.Nil(t, h)
+			continue
+		}
+		require.Equal(t, stmts[i], server.stmts[h.ID()])
+	}
+	require.Empty(t, server.cancelled)
+}
+
+func TestSubmitBatchFailFast(t *testing.T) {
+	t.Parallel()
+
+	server := newBatchTestServer(t, 0)
+	c := NewClient(&Config{Endpoint: server.URL})
+	ctx := context.Background()
+
+	handles, err := c.SubmitBatch(ctx, []string{"S0", "FAIL", "S2"}, BatchConcurrency(1), BatchFailFast())
+	var batchErr *ExecuteAllError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Errors, 1)
+	require.Equal(t, 1, batchErr.Errors[0].Index)
+	require.NotNil(t, handles[0])
+	require.Nil(t, handles[2])
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Len(t, server.stmts, 1)
+	require.Equal(t, []string{"S0"}, server.cancelled)
+}
+
+func TestSubmitBatchRetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	server := newBatchTestServer(t, 2)
+	c := NewClient(&Config{Endpoint: server.URL})
+
+	_, err := c.SubmitBatch(context.Background(), []string{"S0"}, BatchRetries(1))
+	require.Equal(t, http.StatusTooManyRequests, StatusCodeOf(err))
+}
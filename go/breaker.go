@@ -0,0 +1,245 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerCoolDown         = 30 * time.Second
+	defaultBreakerHalfOpenProbes   = 1
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breaker of the client; see
+// Config.CircuitBreaker.
+//
+// Each endpoint host has its own breaker. After FailureThreshold consecutive
+// requests fail to get a response, e.g., because the connection is refused or
+// times out, the breaker opens and requests fail with ErrCircuitOpen without
+// being sent. After CoolDown, the breaker half-opens and lets HalfOpenProbes
+// requests through at once: it closes if a probe gets a response, and opens
+// again if a probe fails. Responses with an error status count as successes,
+// since the endpoint is reachable.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive connection failures that
+	// opens the breaker. The default is 5.
+	FailureThreshold int
+	// CoolDown is how long the breaker stays open. The default is 30 seconds.
+	CoolDown time.Duration
+	// HalfOpenProbes is the number of requests let through at once while the
+	// breaker is half-open. The default is 1.
+	HalfOpenProbes int
+	// OnStateChange, if set, is called after the breaker of endpoint changes
+	// its state. It must not block.
+	OnStateChange func(endpoint string, from, to CircuitState)
+}
+
+// circuitBreakers are the circuit breakers of the endpoints of a client.
+type circuitBreakers struct {
+	config CircuitBreakerConfig
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	endpoints map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// requestOutcome is the result of a request for a circuit breaker.
+type requestOutcome int
+
+const (
+	// requestSucceeded means that the request got a response.
+	requestSucceeded requestOutcome = iota
+	// requestFailed means that the request failed to get a response.
+	requestFailed
+	// requestAbandoned means that the caller gave up on the request, which
+	// says nothing about the endpoint.
+	requestAbandoned
+)
+
+// circuitTransition is a state change to report once the lock is released.
+type circuitTransition struct {
+	endpoint string
+	from, to CircuitState
+}
+
+func newCircuitBreakers(config *Config) *circuitBreakers {
+	if config == nil || config.CircuitBreaker == nil {
+		return nil
+	}
+	b := &circuitBreakers{
+		config:    *config.CircuitBreaker,
+		logger:    config.DebugLogger,
+		now:       time.Now,
+		endpoints: map[string]*circuitBreaker{},
+	}
+	if b.config.FailureThreshold <= 0 {
+		b.config.FailureThreshold = defaultBreakerFailureThreshold
+	}
+	if b.config.CoolDown <= 0 {
+		b.config.CoolDown = defaultBreakerCoolDown
+	}
+	if b.config.HalfOpenProbes <= 0 {
+		b.config.HalfOpenProbes = defaultBreakerHalfOpenProbes
+	}
+	return b
+}
+
+// allow returns ErrCircuitOpen if a request to endpoint must fail fast.
+// Otherwise, the returned function must be called with the outcome of the
+// request.
+func (b *circuitBreakers) allow(ctx context.Context, endpoint string) (func(requestOutcome), error) {
+	b.mu.Lock()
+	cb, ok := b.endpoints[endpoint]
+	if !ok {
+		cb = &circuitBreaker{}
+		b.endpoints[endpoint] = cb
+	}
+
+	var transition *circuitTransition
+	if cb.state == CircuitOpen && b.now().Sub(cb.openedAt) >= b.config.CoolDown {
+		transition = cb.transit(endpoint, CircuitHalfOpen)
+		cb.probes = 0
+	}
+	probe := false
+	switch cb.state {
+	case CircuitOpen:
+		b.mu.Unlock()
+		return nil, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if cb.probes >= b.config.HalfOpenProbes {
+			b.mu.Unlock()
+			b.report(ctx, transition)
+			return nil, ErrCircuitOpen
+		}
+		cb.probes++
+		probe = true
+	}
+	b.mu.Unlock()
+	b.report(ctx, transition)
+
+	return func(outcome requestOutcome) {
+		b.mu.Lock()
+		var transition *circuitTransition
+		if probe {
+			cb.probes--
+		}
+		switch {
+		case outcome == requestAbandoned:
+		case outcome == requestSucceeded:
+			cb.failures = 0
+			if probe && cb.state == CircuitHalfOpen {
+				transition = cb.transit(endpoint, CircuitClosed)
+			}
+		case probe && cb.state == CircuitHalfOpen:
+			transition = cb.transit(endpoint, CircuitOpen)
+			cb.openedAt = b.now()
+		case cb.state == CircuitClosed:
+			cb.failures++
+			if cb.failures >= b.config.FailureThreshold {
+				transition = cb.transit(endpoint, CircuitOpen)
+				cb.openedAt = b.now()
+			}
+		}
+		b.mu.Unlock()
+		b.report(ctx, transition)
+	}, nil
+}
+
+// transit changes the state of the breaker. The caller must hold the lock.
+func (cb *circuitBreaker) transit(endpoint string, to CircuitState) *circuitTransition {
+	from := cb.state
+	cb.state = to
+	cb.failures = 0
+	return &circuitTransition{endpoint: endpoint, from: from, to: to}
+}
+
+// report logs the transition, if any, and calls OnStateChange.
+func (b *circuitBreakers) report(ctx context.Context, t *circuitTransition) {
+	if t == nil {
+		return
+	}
+	if b.logger != nil {
+		b.logger.LogAttrs(context.WithoutCancel(ctx), slog.LevelDebug, "scopedb circuit breaker state changed",
+			slog.String("endpoint", t.endpoint),
+			slog.String("from", t.from.String()),
+			slog.String("to", t.to.String()))
+	}
+	if b.config.OnStateChange != nil {
+		b.config.OnStateChange(t.endpoint, t.from, t.to)
+	}
+}
+
+// CircuitState returns the state of the circuit breaker of the configured
+// endpoint, or CircuitClosed if Config.CircuitBreaker is not set.
+func (c *Client) CircuitState() CircuitState {
+	b := c.http.breakers
+	if b == nil {
+		return CircuitClosed
+	}
+	if c.endpointErr != nil {
+		return CircuitClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cb, ok := b.endpoints[c.endpoint.Host]
+	if !ok {
+		return CircuitClosed
+	}
+	if cb.state == CircuitOpen && b.now().Sub(cb.openedAt) >= b.config.CoolDown {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
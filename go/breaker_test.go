@@ -0,0 +1,149 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var down atomic.Bool
+	var sent atomic.Int32
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		sent.Add(1)
+		if down.Load() {
+			return nil, errors.New("connection refused")
+		}
+		rec := httptest.NewRecorder()
+		writeEmptyResponse(rec, req)
+		return rec.Result(), nil
+	})
+
+	var mu sync.Mutex
+	var transitions []string
+	c := NewClient(&Config{
+		Endpoint:  "http://scopedb.test",
+		Transport: transport,
+		CircuitBreaker: &CircuitBreakerConfig{
+			FailureThreshold: 2,
+			CoolDown:         time.Minute,
+			OnStateChange: func(endpoint string, from, to CircuitState) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, endpoint+": "+from.String()+" -> "+to.String())
+			},
+		},
+	})
+	now := time.Now()
+	c.http.breakers.now = func() time.Time { return now }
+	ctx := context.Background()
+	execute := func() error {
+		_, err := c.Statement("VALUES (1)").Execute(ctx)
+		return err
+	}
+
+	down.Store(true)
+	require.Error(t, execute())
+	require.Equal(t, CircuitClosed, c.CircuitState())
+	require.Error(t, execute())
+	require.Equal(t, CircuitOpen, c.CircuitState())
+	err := execute()
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.ErrorContains(t, err, "submit statement: circuit breaker open")
+	require.EqualValues(t, 2, sent.Load())
+
+	// A failed probe opens the breaker again.
+	now = now.Add(time.Minute)
+	require.Equal(t, CircuitHalfOpen, c.CircuitState())
+	require.Error(t, execute())
+	require.EqualValues(t, 3, sent.Load())
+	require.ErrorIs(t, execute(), ErrCircuitOpen)
+
+	// A successful probe closes it.
+	now = now.Add(time.Minute)
+	down.Store(false)
+	require.NoError(t, execute())
+	require.Equal(t, CircuitClosed, c.CircuitState())
+
+	// Requests abandoned by the caller do not count.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	for range 3 {
+		_, err := c.Statement("VALUES (1)").Execute(cancelled)
+		require.ErrorIs(t, err, context.Canceled)
+	}
+	require.Equal(t, CircuitClosed, c.CircuitState())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{
+		"scopedb.test: closed -> open",
+		"scopedb.test: open -> half-open",
+		"scopedb.test: half-open -> open",
+		"scopedb.test: open -> half-open",
+		"scopedb.test: half-open -> closed",
+	}, transitions)
+}
+
+func TestCircuitBreakerPerEndpoint(t *testing.T) {
+	t.Parallel()
+
+	b := newCircuitBreakers(&Config{CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 1, HalfOpenProbes: 1}})
+	ctx := context.Background()
+
+	done, err := b.allow(ctx, "a.test")
+	require.NoError(t, err)
+	done(requestFailed)
+	_, err = b.allow(ctx, "a.test")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	done, err = b.allow(ctx, "b.test")
+	require.NoError(t, err)
+	done(requestSucceeded)
+
+	// Only HalfOpenProbes requests are let through at once.
+	b.now = func() time.Time { return time.Now().Add(defaultBreakerCoolDown) }
+	probe, err := b.allow(ctx, "a.test")
+	require.NoError(t, err)
+	_, err = b.allow(ctx, "a.test")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	probe(requestAbandoned)
+	probe, err = b.allow(ctx, "a.test")
+	require.NoError(t, err)
+	probe(requestSucceeded)
+	_, err = b.allow(ctx, "a.test")
+	require.NoError(t, err)
+}
@@ -20,13 +20,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"math"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	defaultBatchSize     = 16 * 1024 * 1024 // default to 16 MiB
-	defaultBatchInterval = time.Second      // default to 1 second
+	defaultBatchSize          = 16 * 1024 * 1024 // default to 16 MiB
+	defaultBatchInterval      = time.Second      // default to 1 second
+	defaultDrainGracePeriod   = 5 * time.Second  // default to 5 seconds
+	defaultUnhealthyBufferAge = 30 * time.Second // default to 30 seconds if BatchInterval is zero
 )
 
 // DataCable is a cable for sending any records as raw data to ScopeDB.
@@ -43,20 +50,243 @@ type DataCable struct {
 
 	transforms  string
 	currentSize uint64
-	sendBatches []*dataSendRecord
-	sendBatchCh chan *dataSendRecord
+	sendBatches []dataSendRecord
+	sendBatchCh chan dataSendRecord
+	// sendFlush is the Flush of sendBatches, or nil if no record asked for it.
+	sendFlush *Flush
+	// batchKeys maps the DedupeKeyFunc keys of sendBatches to their indexes.
+	batchKeys map[string]int
+	// deduped is the number of superseded records.
+	deduped atomic.Uint64
+
+	closeOnce sync.Once
+	// closeCh is closed by Close.
+	closeCh chan struct{}
+	// stopped is closed once the cable stops accepting records.
+	stopped chan struct{}
+	// inflight tracks the running ingests.
+	inflight sync.WaitGroup
+	// done is closed once the cable is closed and all batches are flushed.
+	done chan struct{}
+	// closing is set once Close is called.
+	closing atomic.Bool
+	// drainErrsMu guards drainErrs.
+	drainErrsMu sync.Mutex
+	// drainErrs are the errors of the flushes that finished after Close.
+	drainErrs []error
+	// started is set by Start.
+	started atomic.Bool
+	// abandonCtx is canceled by CloseContext to cancel the ingests in flight.
+	abandonCtx context.Context
+	abandon    context.CancelFunc
+	// dropped is the number of records of the batches that failed after
+	// abandonCtx was canceled.
+	dropped atomic.Int64
+	// stopping is set once the cable stops accepting records.
+	stopping atomic.Bool
+	// drainedRecords, spilledRecords, and undrainedRecords count the records
+	// of the batches settled after stopping is set, for the final FlushResult.
+	drainedRecords   atomic.Int64
+	spilledRecords   atomic.Int64
+	undrainedRecords atomic.Int64
+	// ingestingBytes is the size of the batches being ingested.
+	ingestingBytes atomic.Uint64
+	// spill is the spill log, opened by Start if Spill is set.
+	spill *spillLog
+	// oldestBuffered is the time in Unix nanoseconds the oldest buffered
+	// record was received, or zero if no records are buffered.
+	oldestBuffered atomic.Int64
+	// flushMu guards lastFlush and failedFlushes.
+	flushMu   sync.Mutex
+	lastFlush FlushInfo
+	// failedFlushes is the number of consecutive failed flushes.
+	failedFlushes int
+	// pending is the number of records sent and not yet flushed, including
+	// those of the batches in flight.
+	pending atomic.Int64
+	// tick, if set, replaces the BatchInterval ticker, e.g., with the shared
+	// scheduler of a PartitionedCable.
+	tick chan time.Time
 
 	// AutoCommit indicates whether the cable should automatically commit the batches
 	AutoCommit bool
-	// BatchSize is the maximum size in bytes of the batches to be sent.
+	// BatchSize is the maximum size in bytes of the batches to be sent,
+	// counting the newlines between records. A batch holding a single record
+	// larger than BatchSize is sent on its own. Batches are also capped so
+	// that their ingest requests stay within Config.MaxRequestBytes.
 	BatchSize uint64
+	// MaxRecordBytes is the maximum size in bytes of a single JSON-encoded
+	// record. Larger records are rejected by Send with a *RecordTooLargeError.
+	// Zero means no limit.
+	MaxRecordBytes uint64
 	// BatchInterval is the maximum time to wait before sending the batches.
 	BatchInterval time.Duration
+	// DrainGracePeriod is how long the cable drains once the context passed
+	// to Start is done: new records are rejected with ErrCableStopped, and the
+	// buffered records and the batches in flight are flushed until it expires.
+	// Ingests still running then are canceled, and their records are spilled
+	// if Spill is set, or dropped otherwise. Zero or negative cancels them
+	// right away.
+	DrainGracePeriod time.Duration
+	// OnFlush, if set, is called from a background goroutine with the result
+	// of each flush, and once more with a final FlushResult when the cable has
+	// stopped and settled all of its batches.
+	OnFlush func(result FlushResult)
+	// NodeGroup is the node group to run the ingest statements on, e.g., "default".
+	//
+	// It is passed through to ScopeDB as is. Empty means the server default.
+	NodeGroup string
+	// Priority is the scheduling priority of the ingest statements. Empty
+	// means the server default.
+	//
+	// If ScopeDB rejects the priority, batches are ingested without it.
+	Priority Priority
+	// Spill, if set, enables buffering batches on disk while ScopeDB is
+	// unavailable. See SpillOptions.
+	Spill *SpillOptions
+	// OnError, if set, is called from a background goroutine with the error of
+	// each batch that fails to be ingested, including the batches of records
+	// sent with Send. It is the only way to observe the ingest errors of
+	// records sent with SendNoWait.
+	OnError func(err error, batch BatchInfo)
+	// UnhealthyFlushFailures is the number of consecutive failed flushes
+	// after which Healthy reports an error. Zero means 3.
+	UnhealthyFlushFailures int
+	// UnhealthyBufferAge is the age of the oldest buffered record after which
+	// Healthy reports an error. Zero means ten times BatchInterval, or 30
+	// seconds if BatchInterval is zero.
+	UnhealthyBufferAge time.Duration
+	// DedupeKeyFunc, if set, returns the key of a record, or false if the
+	// record has none. A record replaces the buffered record with the same key
+	// in its batch, if any, so that only the latest of them is ingested; the
+	// replaced record is acknowledged with the batch.
+	//
+	// Deduplication is within a batch only: a record does not replace one
+	// that is already flushed. DedupeKeyFunc is called by Send, SendNoWait,
+	// and SendFlush in the caller goroutine.
+	DedupeKeyFunc func(record any) (string, bool)
+	// ValidateOnStart makes Start check the transforms with ScopeDB by
+	// ingesting zero rows through them, so that a broken transform fails Start
+	// instead of the first flush.
+	ValidateOnStart bool
+	// FlushContext, if set, derives the context of each ingest request from
+	// the context passed to Start, e.g., to authenticate with
+	// WithCredentials. Otherwise, ingests use the Start context detached from
+	// its cancellation, which keeps its values.
+	FlushContext func(ctx context.Context) context.Context
 }
 
 type dataSendRecord struct {
 	payload string
-	err     chan error
+	// err is nil for records sent with SendNoWait.
+	err chan error
+	// flush, if set, receives the Flush of the batch the record is added to.
+	flush chan *Flush
+	// key is the DedupeKeyFunc key of the record, if keyed is set.
+	key   string
+	keyed bool
+	// superseded is set once a later record with the same key replaces the
+	// record in its batch. The record is not ingested, but it is acknowledged
+	// with the batch.
+	superseded bool
+}
+
+// CableStats are the counters of a DataCable.
+type CableStats struct {
+	// Deduped is the number of records replaced by a later record with the
+	// same DedupeKeyFunc key in the same batch.
+	Deduped uint64
+}
+
+// Flush is the future of the flush of a batch of a DataCable, shared by all
+// the records of the batch. See DataCable.SendFlush.
+type Flush struct {
+	done   chan struct{}
+	result *IngestResponse
+	err    error
+}
+
+// Done returns a channel that is closed once the batch is ingested or fails.
+func (f *Flush) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns the error of the batch once Done is closed, or nil if the batch
+// was ingested or spilled, or is not done yet.
+func (f *Flush) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Result returns the result of ingesting the batch once Done is closed. It is
+// nil if the batch failed, was spilled, or is not done yet.
+func (f *Flush) Result() *IngestResponse {
+	select {
+	case <-f.done:
+		return f.result
+	default:
+		return nil
+	}
+}
+
+// resolve completes f, if not nil, with result and err.
+func (f *Flush) resolve(result *IngestResponse, err error) {
+	if f == nil {
+		return
+	}
+	f.result, f.err = result, err
+	close(f.done)
+}
+
+// FlushInfo describes the most recent flush attempt of a DataCable.
+type FlushInfo struct {
+	// Time is when the flush started, or zero if there was none yet.
+	Time time.Time
+	// Duration is how long the ingest request took.
+	Duration time.Duration
+	// Records is the number of records in the batch.
+	Records int
+	// Bytes is the size in bytes of the batch sent to ScopeDB.
+	Bytes int
+	// Err is the error of the flush, or nil if it succeeded.
+	Err error
+}
+
+// FlushResult is passed to the OnFlush callback of a cable.
+//
+// It describes a single flush, unless Final is set: the final result
+// summarizes the drain of the cable once it stops, whether by Close or
+// because the context passed to Start is done.
+type FlushResult struct {
+	// FlushInfo describes the flush. It is zero for the final result.
+	FlushInfo
+	// Final is set for the last result, reported once the cable has stopped
+	// and settled all of its batches.
+	Final bool
+	// Drained is the number of records of the batches ingested after the
+	// cable stopped, including those already in flight. It is only set for
+	// the final result.
+	Drained int
+	// Spilled is the number of records of the batches spilled after the cable
+	// stopped, e.g., because DrainGracePeriod expired while Spill is set. It
+	// is only set for the final result.
+	Spilled int
+	// Dropped is the number of records of the batches that failed and were
+	// not spilled after the cable stopped, e.g., because DrainGracePeriod
+	// expired. It is only set for the final result.
+	Dropped int
+}
+
+// BatchInfo describes a batch of records ingested by a DataCable.
+type BatchInfo struct {
+	// Records is the number of records in the batch.
+	Records int
+	// Bytes is the size in bytes of the batch sent to ScopeDB.
+	Bytes int
 }
 
 // DataCable creates a new DataCable with the specified transforms.
@@ -70,70 +300,181 @@ type dataSendRecord struct {
 //	SELECT $0["col1"]::int, $0["col2"]::string, $0
 //	INSERT INTO my_table (col1, col2, v)
 func (c *Client) DataCable(transforms string) *DataCable {
+	abandonCtx, abandon := context.WithCancel(context.Background())
 	cable := &DataCable{
 		c:             c,
 		transforms:    transforms,
 		currentSize:   0,
 		sendBatches:   nil,
-		sendBatchCh:   make(chan *dataSendRecord),
+		sendBatchCh:   make(chan dataSendRecord),
+		closeCh:       make(chan struct{}),
+		stopped:       make(chan struct{}),
+		done:          make(chan struct{}),
+		abandonCtx:    abandonCtx,
+		abandon:       abandon,
 		AutoCommit:    false,
 		BatchSize:     defaultBatchSize,
 		BatchInterval: defaultBatchInterval,
+
+		DrainGracePeriod: defaultDrainGracePeriod,
 	}
 
 	return cable
 }
 
+// CableTableOption configures Client.DataCableForTable.
+type CableTableOption func(*cableTableOptions)
+
+type cableTableOptions struct {
+	// keys maps column names to the record keys they are read from.
+	keys map[string]string
+	// exprs maps column names to the expressions that compute them.
+	exprs map[string]string
+}
+
+// CableColumnKey reads column from the record key instead of the key of the
+// same name.
+func CableColumnKey(column, key string) CableTableOption {
+	return func(o *cableTableOptions) {
+		o.keys[column] = key
+	}
+}
+
+// CableColumnExpr computes column with the ScopeQL expression expr over the
+// record $0, e.g., `PARSE_JSON($0["payload"])`, instead of a cast of its key.
+func CableColumnExpr(column, expr string) CableTableOption {
+	return func(o *cableTableOptions) {
+		o.exprs[column] = expr
+	}
+}
+
+// DataCableForTable creates a new DataCable that inserts records into tbl,
+// with transforms generated from the table schema as by Table.CableTransform.
+//
+// This method issues a meta query to ScopeDB for the table schema. It fails if
+// the table has no columns, a column of a type that cannot be mapped, or if an
+// option names a column that the table does not have. The generated
+// transforms are returned by DataCable.Transforms.
+func (c *Client) DataCableForTable(ctx context.Context, tbl *Table, opts ...CableTableOption) (*DataCable, error) {
+	o := &cableTableOptions{keys: map[string]string{}, exprs: map[string]string{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	schema, err := tbl.TableSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, columns := range []map[string]string{o.keys, o.exprs} {
+		for column := range columns {
+			if !slices.ContainsFunc(schema, func(fs *FieldSchema) bool { return fs.Name == column }) {
+				return nil, fmt.Errorf("table %s has no column %q", tbl.Identifier(), column)
+			}
+		}
+	}
+
+	transforms, err := tbl.cableTransform(schema, o)
+	if err != nil {
+		return nil, err
+	}
+	return c.DataCable(transforms), nil
+}
+
+// Transforms returns the transforms of the cable.
+func (c *DataCable) Transforms() string {
+	return c.transforms
+}
+
 // Start starts the DataCable background task.
 //
 // It will receive batches that users Send, package them based on the BatchSize and BatchInterval,
 // and send them to ScopeDB.
-func (c *DataCable) Start(ctx context.Context) {
-	ticker := time.Tick(c.BatchInterval)
+//
+// The cable is tracked by its Client until it is closed and drained, so that
+// Client.Shutdown can flush it.
+//
+// When ctx is done, the cable drains: records sent afterwards fail with
+// ErrCableStopped, and the buffered records and the batches in flight are
+// flushed on a best-effort basis until DrainGracePeriod expires. Ingests use
+// a context detached from the cancellation of ctx, so that canceling ctx does
+// not abort them before the grace period.
+//
+// Ingest requests use ctx, so they carry its values, e.g., the credentials of
+// WithCredentials; see FlushContext to override them.
+//
+// Start returns an error if ValidateOnStart is set and the transforms fail
+// validation; the cable is then stopped, and records sent to it fail with
+// ErrCableStopped. A cable can be started only once; later calls return
+// ErrCableStarted.
+func (c *DataCable) Start(ctx context.Context) error {
+	if !c.started.CompareAndSwap(false, true) {
+		return ErrCableStarted
+	}
+	if c.ValidateOnStart {
+		if err := c.validate(ctx); err != nil {
+			close(c.stopped)
+			close(c.done)
+			return err
+		}
+	}
+
+	c.c.trackCable(c)
+	c.run(ctx)
+	return nil
+}
+
+// run starts the background task of a started cable.
+func (c *DataCable) run(ctx context.Context) {
+	var ticker <-chan time.Time = c.tick
+	if ticker == nil {
+		ticker = time.Tick(c.BatchInterval)
+	}
 
 	batchSize := c.BatchSize
+	if limit := c.c.http.maxIngestRows(c.transforms); limit > 0 && limit < batchSize {
+		batchSize = limit
+	}
 	ingestType := writeTypeBuffered
 	if c.AutoCommit {
 		ingestType = writeTypeCommitted
 	}
 
+	// flushCtx outlives ctx for the drain, bounded by DrainGracePeriod.
+	flushCtx, cancelFlushes := context.WithCancel(context.WithoutCancel(ctx))
+	if c.Spill != nil {
+		c.spill = openSpillLog(c.Spill.Dir, c.Spill.MaxBytes)
+		c.inflight.Add(1)
+		go func() {
+			defer c.inflight.Done()
+			c.replaySpilled(flushCtx, ingestType)
+		}()
+	}
 	go func() {
+		var flushTimer *time.Timer
+		defer func() {
+			close(c.stopped)
+			c.inflight.Wait()
+			if flushTimer != nil {
+				flushTimer.Stop()
+			}
+			cancelFlushes()
+			if c.OnFlush != nil {
+				c.OnFlush(FlushResult{
+					Final:   true,
+					Drained: int(c.drainedRecords.Load()),
+					Spilled: int(c.spilledRecords.Load()),
+					Dropped: int(c.undrainedRecords.Load()),
+				})
+			}
+			c.c.untrackCable(c)
+			close(c.done)
+		}()
+
 		stop, tick := false, false
 		for {
-			if tick || c.currentSize > batchSize {
-				sendBatches := c.sendBatches
-				go func() {
-					rows := ""
-					for _, sendBatch := range sendBatches {
-						if rows != "" {
-							rows += "\n"
-						}
-						rows += sendBatch.payload
-					}
-
-					if _, err := c.c.ingest(ctx, &ingestRequest{
-						Data: ingestData{
-							Format: writeFormatJSON,
-							Rows:   rows,
-						},
-						Type:      ingestType,
-						Statement: c.transforms,
-					}); err != nil {
-						for _, sendBatch := range sendBatches {
-							sendBatch.err <- err
-							close(sendBatch.err)
-						}
-						return
-					}
-
-					for _, sendBatch := range sendBatches {
-						close(sendBatch.err)
-					}
-				}()
-
+			if len(c.sendBatches) > 0 && (tick || stop || c.currentSize >= batchSize) {
+				c.flush(flushCtx, ingestType)
 				tick = false
-				c.currentSize = 0
-				c.sendBatches = nil
 			}
 
 			if stop {
@@ -145,53 +486,419 @@ func (c *DataCable) Start(ctx context.Context) {
 				if len(c.sendBatches) > 0 {
 					tick = true
 				}
-			case sendBatch, more := <-c.sendBatchCh:
-				if !more {
-					stop = true
-					continue
+			case <-c.closeCh:
+				stop = true
+				c.stopping.Store(true)
+			case <-ctx.Done():
+				stop = true
+				c.stopping.Store(true)
+				flushTimer = time.AfterFunc(c.DrainGracePeriod, cancelFlushes)
+			case sendBatch := <-c.sendBatchCh:
+				if !c.replaceDuplicate(sendBatch) {
+					// Account the bytes actually sent: records are joined by newlines.
+					// currentSize stays below batchSize between iterations, so that
+					// batchSize-c.currentSize cannot underflow.
+					size := uint64(len(sendBatch.payload))
+					if len(c.sendBatches) > 0 {
+						size++
+						if size > batchSize-c.currentSize {
+							c.flush(flushCtx, ingestType)
+							size--
+						}
+					}
+					if len(c.sendBatches) == 0 {
+						c.oldestBuffered.Store(time.Now().UnixNano())
+					}
+					c.currentSize += size
+					c.sendBatches = append(c.sendBatches, sendBatch)
+					if sendBatch.keyed {
+						if c.batchKeys == nil {
+							c.batchKeys = make(map[string]int)
+						}
+						c.batchKeys[sendBatch.key] = len(c.sendBatches) - 1
+					}
+				}
+				if sendBatch.flush != nil {
+					if c.sendFlush == nil {
+						c.sendFlush = &Flush{done: make(chan struct{})}
+					}
+					sendBatch.flush <- c.sendFlush
 				}
+			}
+		}
+	}()
+}
 
-				size := uint64(len(sendBatch.payload))
-				if size > math.MaxUint64-c.currentSize {
-					c.currentSize = math.MaxUint64
-				} else {
-					c.currentSize += size
+// validate ingests zero rows through the transforms.
+func (c *DataCable) validate(ctx context.Context) error {
+	if c.c.configErr != nil {
+		return c.c.configErr
+	}
+	if _, err := c.ingestRows(ctx, writeTypeCommitted, ""); err != nil {
+		return fmt.Errorf("validate transforms: %w", err)
+	}
+	return nil
+}
+
+// flush ingests the buffered records in the background and resets the buffer.
+func (c *DataCable) flush(ctx context.Context, ingestType writeType) {
+	sendBatches, size, sendFlush := c.sendBatches, c.currentSize, c.sendFlush
+	c.currentSize = 0
+	c.sendBatches = nil
+	c.sendFlush = nil
+	clear(c.batchKeys)
+	c.oldestBuffered.Store(0)
+
+	if c.spill != nil && ((c.Spill.Ordered && !c.spill.empty()) ||
+		(c.Spill.Threshold > 0 && c.ingestingBytes.Load()+size > c.Spill.Threshold)) {
+		if err := c.spillBatch(sendBatches, sendFlush); err != nil {
+			c.failBatch(sendBatches, sendFlush, err, int(size))
+		}
+		c.pending.Add(-int64(len(sendBatches)))
+		return
+	}
+
+	c.ingestingBytes.Add(size)
+	c.inflight.Add(1)
+	go func() {
+		defer c.inflight.Done()
+		defer c.ingestingBytes.Add(-size)
+		defer c.pending.Add(-int64(len(sendBatches)))
+
+		var rows strings.Builder
+		rows.Grow(int(size))
+		for i, payload := range payloads(sendBatches) {
+			if i > 0 {
+				rows.WriteByte('\n')
+			}
+			rows.WriteString(payload)
+		}
+
+		start := time.Now()
+		resp, err := c.ingestRows(ctx, ingestType, rows.String())
+		c.recordFlush(FlushInfo{
+			Time:     start,
+			Duration: time.Since(start),
+			Records:  len(payloads(sendBatches)),
+			Bytes:    rows.Len(),
+			Err:      err,
+		})
+		if err != nil {
+			// Spill even if the drain or CloseContext canceled the ingest, so
+			// that the records outlive the cable.
+			if c.spill != nil {
+				spillErr := c.spillBatch(sendBatches, sendFlush)
+				if spillErr == nil {
+					return
 				}
-				c.sendBatches = append(c.sendBatches, sendBatch)
+				err = errors.Join(err, spillErr)
+			}
+			c.failBatch(sendBatches, sendFlush, err, rows.Len())
+			return
+		}
+
+		c.settled(sendBatches, &c.drainedRecords)
+		sendFlush.resolve(&IngestResponse{RowsInserted: resp.NumRowsInserted}, nil)
+		for _, sendBatch := range sendBatches {
+			if sendBatch.err != nil {
+				close(sendBatch.err)
 			}
 		}
 	}()
 }
 
+// replaceDuplicate replaces the buffered record with the key of sendBatch, if
+// any, with sendBatch, and reports whether it did.
+func (c *DataCable) replaceDuplicate(sendBatch dataSendRecord) bool {
+	if !sendBatch.keyed {
+		return false
+	}
+	i, ok := c.batchKeys[sendBatch.key]
+	if !ok {
+		return false
+	}
+	replaced := c.sendBatches[i]
+	c.currentSize = c.currentSize - uint64(len(replaced.payload)) + uint64(len(sendBatch.payload))
+	c.sendBatches[i] = sendBatch
+	replaced.payload, replaced.superseded = "", true
+	c.sendBatches = append(c.sendBatches, replaced)
+	c.deduped.Add(1)
+	return true
+}
+
+// settled adds the records of a batch to counter, one of drainedRecords,
+// spilledRecords, and undrainedRecords, once the cable is stopping.
+func (c *DataCable) settled(sendBatches []dataSendRecord, counter *atomic.Int64) {
+	if c.stopping.Load() {
+		counter.Add(int64(len(payloads(sendBatches))))
+	}
+}
+
+// payloads returns the payloads of the records of sendBatches that are not
+// superseded.
+func payloads(sendBatches []dataSendRecord) []string {
+	payloads := make([]string, 0, len(sendBatches))
+	for _, sendBatch := range sendBatches {
+		if !sendBatch.superseded {
+			payloads = append(payloads, sendBatch.payload)
+		}
+	}
+	return payloads
+}
+
+// Stats returns the counters of the cable.
+func (c *DataCable) Stats() CableStats {
+	return CableStats{Deduped: c.deduped.Load()}
+}
+
+// ingestRows ingests the newline-delimited JSON rows through the transforms.
+func (c *DataCable) ingestRows(ctx context.Context, ingestType writeType, rows string) (*ingestResponse, error) {
+	if c.FlushContext != nil {
+		ctx = c.FlushContext(ctx)
+	}
+	ctx, cancel := abandonable(ctx, c.abandonCtx)
+	defer cancel()
+	return c.c.ingest(ctx, &ingestRequest{
+		Data: ingestData{
+			Format: writeFormatJSON,
+			Rows:   rows,
+		},
+		Type:      ingestType,
+		Statement: c.transforms,
+		NodeGroup: c.NodeGroup,
+		Priority:  c.Priority,
+	})
+}
+
+// recordFlush records info as the most recent flush and reports it to OnFlush.
+func (c *DataCable) recordFlush(info FlushInfo) {
+	c.flushMu.Lock()
+	c.lastFlush = info
+	if info.Err != nil {
+		c.failedFlushes++
+	} else {
+		c.failedFlushes = 0
+	}
+	c.flushMu.Unlock()
+
+	if c.OnFlush != nil {
+		c.OnFlush(FlushResult{FlushInfo: info})
+	}
+}
+
+// LastFlush returns the most recent flush attempt of the cable. Its Time is
+// zero if the cable has not flushed yet.
+func (c *DataCable) LastFlush() FlushInfo {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+	return c.lastFlush
+}
+
+// Healthy returns an error if the cable is stopped, if its last
+// UnhealthyFlushFailures flushes failed, or if it buffers a record received
+// longer than UnhealthyBufferAge ago, e.g., because ingestion stalled.
+// Otherwise, it returns nil. It is intended for readiness probes.
+func (c *DataCable) Healthy() error {
+	select {
+	case <-c.stopped:
+		return ErrCableStopped
+	default:
+	}
+
+	maxFailures := c.UnhealthyFlushFailures
+	if maxFailures <= 0 {
+		maxFailures = 3
+	}
+	c.flushMu.Lock()
+	failures, lastErr := c.failedFlushes, c.lastFlush.Err
+	c.flushMu.Unlock()
+	if failures >= maxFailures {
+		return fmt.Errorf("last %d flushes failed: %w", failures, lastErr)
+	}
+
+	maxAge := c.UnhealthyBufferAge
+	if maxAge <= 0 {
+		maxAge = 10 * c.BatchInterval
+	}
+	if maxAge <= 0 {
+		maxAge = defaultUnhealthyBufferAge
+	}
+	if oldest := c.oldestBuffered.Load(); oldest != 0 {
+		if age := time.Since(time.Unix(0, oldest)); age > maxAge {
+			return fmt.Errorf("records buffered for %s without a flush", age.Round(time.Millisecond))
+		}
+	}
+	return nil
+}
+
+// failBatch reports err for the records of a batch of the given size.
+func (c *DataCable) failBatch(sendBatches []dataSendRecord, sendFlush *Flush, err error, size int) {
+	c.recordDrainError(err)
+	if c.abandonCtx.Err() != nil {
+		c.dropped.Add(int64(len(payloads(sendBatches))))
+	}
+	c.settled(sendBatches, &c.undrainedRecords)
+	sendFlush.resolve(nil, err)
+	if c.OnError != nil {
+		c.OnError(err, BatchInfo{Records: len(payloads(sendBatches)), Bytes: size})
+	}
+	for _, sendBatch := range sendBatches {
+		if sendBatch.err != nil {
+			sendBatch.err <- err
+			close(sendBatch.err)
+		}
+	}
+}
+
+// recordDrainError records err if the cable is closing.
+func (c *DataCable) recordDrainError(err error) {
+	if !c.closing.Load() {
+		return
+	}
+	c.drainErrsMu.Lock()
+	defer c.drainErrsMu.Unlock()
+	c.drainErrs = append(c.drainErrs, err)
+}
+
 // Send sends a record to the cable. The record should be JSON-serializable.
 //
 // Returns a channel that will be closed when the record is sent to ScopeDB, or an error occurs.
 func (c *DataCable) Send(record any) <-chan error {
 	errCh := make(chan error, 1)
+	if err := c.enqueue(record, errCh); err != nil {
+		errCh <- err
+		close(errCh)
+	}
+	return errCh
+}
+
+// SendNoWait sends a record to the DataCable without waiting for it to be
+// ingested. It returns an error only if the record cannot be enqueued; the
+// ingest errors are reported to OnError.
+//
+// Unlike Send, SendNoWait does not allocate a channel per record, which suits
+// high-throughput streams that do not need per-record acknowledgment.
+func (c *DataCable) SendNoWait(record any) error {
+	return c.enqueue(record, nil)
+}
+
+// SendFlush sends a record to the cable, and returns the Flush of the batch
+// the record is added to. The record should be JSON-serializable.
+//
+// The records of a batch share its Flush, so that a producer can wait for a
+// few distinct flushes instead of every record, e.g., to commit the offsets of
+// the records once all of their flushes are done. A batch whose ingest fails
+// and that is spilled completes its Flush once spilled, as Send does.
+//
+// It returns an error only if the record cannot be enqueued.
+func (c *DataCable) SendFlush(record any) (*Flush, error) {
+	flush := make(chan *Flush, 1)
+	if err := c.enqueueRecord(record, dataSendRecord{flush: flush}); err != nil {
+		return nil, err
+	}
+	return <-flush, nil
+}
+
+// enqueue encodes record and hands it to the background task.
+func (c *DataCable) enqueue(record any, errCh chan error) error {
+	return c.enqueueRecord(record, dataSendRecord{err: errCh})
+}
 
+// enqueueRecord encodes record into the payload of sendBatch and hands it to
+// the background task.
+func (c *DataCable) enqueueRecord(record any, sendBatch dataSendRecord) error {
 	bs, err := json.Marshal(record)
 	if err != nil {
-		errCh <- err
-		close(errCh)
-		return errCh
+		return err
 	}
 
 	var buf bytes.Buffer
 	if err := json.Compact(&buf, bs); err != nil {
-		errCh <- err
-		close(errCh)
-		return errCh
+		return err
+	}
+
+	if c.MaxRecordBytes > 0 && uint64(buf.Len()) > c.MaxRecordBytes {
+		return &RecordTooLargeError{Size: uint64(buf.Len()), Limit: c.MaxRecordBytes}
 	}
 
-	sendBatch := &dataSendRecord{
-		payload: buf.String(),
-		err:     errCh,
+	sendBatch.payload = buf.String()
+	if c.DedupeKeyFunc != nil {
+		sendBatch.key, sendBatch.keyed = c.DedupeKeyFunc(record)
+	}
+	c.pending.Add(1)
+	select {
+	case c.sendBatchCh <- sendBatch:
+		return nil
+	case <-c.stopped:
+		c.pending.Add(-1)
+		return ErrCableStopped
 	}
-	c.sendBatchCh <- sendBatch
-	return sendBatch.err
 }
 
 // Close closes the DataCable and stops sending batches.
+//
+// The records sent before Close are flushed in the background, and records
+// sent after Close fail with ErrCableStopped. Close may be called more than
+// once. Use CloseContext to wait for the flushes with a deadline.
 func (c *DataCable) Close() {
-	close(c.sendBatchCh)
+	c.closeOnce.Do(func() {
+		c.closing.Store(true)
+		close(c.closeCh)
+	})
+}
+
+// CloseContext closes the cable as Close does, and waits until the records
+// sent before are flushed or ctx is done.
+//
+// When ctx is done, the ingests in flight are canceled and their records are
+// dropped, unless they are spilled. CloseContext returns the number of records
+// dropped and the error of the last flush that failed after Close, joined with
+// the error of ctx if the drain was abandoned.
+func (c *DataCable) CloseContext(ctx context.Context) (dropped int, err error) {
+	c.Close()
+	var ctxErr error
+	if c.started.Load() {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			c.abandon()
+			<-c.done
+		}
+	}
+	c.drainErrsMu.Lock()
+	defer c.drainErrsMu.Unlock()
+	return int(c.dropped.Load()), errors.Join(ctxErr, lastError(c.drainErrs))
+}
+
+// abandonable returns a context derived from ctx that is also canceled once
+// abandon is done.
+func abandonable(ctx, abandon context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(abandon, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// lastError returns the last of errs, or nil if errs is empty.
+func lastError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[len(errs)-1]
+}
+
+// wait waits until the cable is closed and all batches are flushed, and
+// returns the errors of the flushes that finished after Close.
+func (c *DataCable) wait(ctx context.Context) error {
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	c.drainErrsMu.Lock()
+	defer c.drainErrsMu.Unlock()
+	return errors.Join(c.drainErrs...)
 }
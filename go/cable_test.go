@@ -0,0 +1,737 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// newIdleCable returns a started cable that only flushes when closed.
+func newIdleCable(ctx context.Context, t *testing.T, c *Client) *DataCable {
+	t.Helper()
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 1 << 30
+	cable.BatchInterval = time.Hour
+	require.NoError(t, cable.Start(ctx))
+	return cable
+}
+
+func TestClientShutdownDrainsCables(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+
+	ctx := context.Background()
+	first, second := newIdleCable(ctx, t, c), newIdleCable(ctx, t, c)
+	var acks []<-chan error
+	for i := range 3 {
+		acks = append(acks, first.Send(i))
+	}
+	acks = append(acks, second.Send("x"))
+
+	require.NoError(t, c.Shutdown(ctx))
+	for _, ack := range acks {
+		require.NoError(t, <-ack)
+	}
+
+	recorded := requests()
+	require.Len(t, recorded, 2)
+	var rows []string
+	for _, r := range recorded {
+		rows = append(rows, r.Body["data"].(map[string]any)["rows"].(string))
+	}
+	require.ElementsMatch(t, []string{"0\n1\n2", `"x"`}, rows)
+	require.Empty(t, c.cables)
+	server.Close()
+}
+
+func TestClientShutdownDeadline(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message":"ingest failed"}`))
+	}))
+	c := NewClient(&Config{Endpoint: server.URL})
+
+	cable := newIdleCable(context.Background(), t, c)
+	ack := cable.Send(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, c.Shutdown(ctx), context.DeadlineExceeded)
+
+	close(release)
+	err := <-ack
+	require.Equal(t, &Error{Message: "ingest failed"}, errors.Unwrap(err))
+	require.EqualError(t, cable.wait(context.Background()), err.Error())
+	c.Close()
+	server.Close()
+}
+
+func TestCableStopsWhenContextDone(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cable := newIdleCable(ctx, t, c)
+	ack := cable.Send(1)
+	cancel()
+
+	// The buffered record is flushed even though ctx is done.
+	require.NoError(t, <-ack)
+	require.NoError(t, cable.wait(context.Background()))
+	require.ErrorIs(t, <-cable.Send(2), ErrCableStopped)
+	cable.Close()
+	require.ErrorIs(t, <-cable.Send(3), ErrCableStopped)
+
+	recorded := requests()
+	require.Len(t, recorded, 1)
+	require.Equal(t, "1", recorded[0].Body["data"].(map[string]any)["rows"])
+	require.Empty(t, c.cables)
+	server.Close()
+}
+
+func TestCableDrainGracePeriod(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	c := NewClient(&Config{Endpoint: server.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchInterval = time.Hour
+	cable.DrainGracePeriod = 10 * time.Millisecond
+	require.NoError(t, cable.Start(ctx))
+	ack := cable.Send(1)
+	cancel()
+
+	require.ErrorIs(t, <-ack, context.Canceled)
+	require.NoError(t, cable.wait(context.Background()))
+	close(release)
+	server.Close()
+}
+
+func TestCableMaxRecordBytes(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := newIdleCable(context.Background(), t, c)
+	cable.MaxRecordBytes = 5
+	atLimit := cable.Send("abc") // `"abc"` is exactly 5 bytes
+	err := <-cable.Send("abcd")
+	var tooLarge *RecordTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	require.Equal(t, &RecordTooLargeError{Size: 6, Limit: 5}, tooLarge)
+	require.EqualError(t, err, "record of 6 bytes exceeds the limit of 5 bytes")
+
+	cable.Close()
+	require.NoError(t, <-atLimit)
+	require.NoError(t, cable.wait(context.Background()))
+	recorded := requests()
+	require.Len(t, recorded, 1)
+	require.Equal(t, `"abc"`, recorded[0].Body["data"].(map[string]any)["rows"])
+}
+
+func TestCableBatchSizeAccounting(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		batchSize uint64
+		expected  []string
+	}{
+		// "1\n2" is exactly 3 bytes.
+		{name: "at the limit", batchSize: 3, expected: []string{"1\n2", "3"}},
+		// "1\n2" is one byte over.
+		{name: "one byte over", batchSize: 2, expected: []string{"1", "2", "3"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, requests := newRecordingTestServer(t)
+			c := NewClient(&Config{Endpoint: server.URL})
+			defer c.Close()
+
+			cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+			cable.BatchSize = tc.batchSize
+			cable.BatchInterval = time.Hour
+			require.NoError(t, cable.Start(context.Background()))
+			var acks []<-chan error
+			for i := 1; i <= 3; i++ {
+				acks = append(acks, cable.Send(i))
+			}
+			cable.Close()
+			for _, ack := range acks {
+				require.NoError(t, <-ack)
+			}
+			require.NoError(t, cable.wait(context.Background()))
+
+			var rows []string
+			for _, r := range requests() {
+				rows = append(rows, r.Body["data"].(map[string]any)["rows"].(string))
+			}
+			require.ElementsMatch(t, tc.expected, rows)
+		})
+	}
+}
+
+func TestCableIntegerPrecision(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 0
+	require.NoError(t, cable.Start(context.Background()))
+	defer cable.Close()
+
+	require.NoError(t, <-cable.Send(map[string]any{"u": uint64(math.MaxUint64), "i": int64(math.MinInt64)}))
+	require.NoError(t, <-cable.Send(map[string]any{"n": json.Number("18446744073709551615")}))
+
+	var rows []string
+	for _, r := range requests() {
+		rows = append(rows, r.Body["data"].(map[string]any)["rows"].(string))
+	}
+	require.Equal(t, []string{
+		`{"i":-9223372036854775808,"u":18446744073709551615}`,
+		`{"n":18446744073709551615}`,
+	}, rows)
+}
+
+func TestCableSendNoWait(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message":"table not found"}`))
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	type failure struct {
+		err   error
+		batch BatchInfo
+	}
+	failures := make(chan failure, 1)
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchInterval = time.Hour
+	cable.MaxRecordBytes = 8
+	cable.OnError = func(err error, batch BatchInfo) {
+		failures <- failure{err, batch}
+	}
+	require.NoError(t, cable.Start(context.Background()))
+
+	require.NoError(t, cable.SendNoWait(1))
+	ack := cable.Send(22)
+	var tooLarge *RecordTooLargeError
+	require.ErrorAs(t, cable.SendNoWait("too large"), &tooLarge)
+	cable.Close()
+
+	f := <-failures
+	require.Equal(t, &Error{Message: "table not found"}, errors.Unwrap(f.err))
+	require.NotEmpty(t, RequestIDOf(f.err))
+	require.Equal(t, BatchInfo{Records: 2, Bytes: len("1\n22")}, f.batch)
+	require.Equal(t, f.err, <-ack)
+	require.ErrorIs(t, cable.SendNoWait(3), ErrCableStopped)
+}
+
+func benchmarkCableSend(b *testing.B, send func(cable *DataCable, record any)) {
+	server := httptest.NewServer(http.HandlerFunc(writeEmptyResponse))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	require.NoError(b, cable.Start(context.Background()))
+	record := map[string]any{"ts": 1700000000000000, "name": "scopedb"}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		send(cable, record)
+	}
+	cable.Close()
+	_ = cable.wait(context.Background())
+}
+
+func BenchmarkCableSend(b *testing.B) {
+	benchmarkCableSend(b, func(cable *DataCable, record any) {
+		_ = cable.Send(record)
+	})
+}
+
+func BenchmarkCableSendNoWait(b *testing.B) {
+	benchmarkCableSend(b, func(cable *DataCable, record any) {
+		_ = cable.SendNoWait(record)
+	})
+}
+
+func TestDataCableForTable(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newResultTestServer(t, []resultSetField{
+		{Name: "column_name", DataType: "string"},
+		{Name: "data_type", DataType: "string"},
+	}, [][]*string{
+		{ptr("ts"), ptr("timestamp")},
+		{ptr("msg"), ptr("string")},
+		{ptr("payload"), ptr("any")},
+		{ptr("v"), ptr("any")},
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table("events")
+	cable, err := c.DataCableForTable(ctx, tbl,
+		CableColumnKey("msg", "message"),
+		CableColumnExpr("v", "$0"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, `SELECT $0["ts"]::timestamp, $0["message"]::string, $0["payload"], $0
+INSERT INTO `+"`events` (`ts`, `msg`, `payload`, `v`)", cable.Transforms())
+
+	_, err = c.DataCableForTable(ctx, tbl, CableColumnKey("missing", "m"))
+	require.EqualError(t, err, "table `events` has no column \"missing\"")
+}
+
+func TestDataCableForTableUnmappedType(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newResultTestServer(t, []resultSetField{
+		{Name: "column_name", DataType: "string"},
+		{Name: "data_type", DataType: "string"},
+	}, [][]*string{
+		{ptr("geo"), ptr("geometry")},
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	_, err := c.DataCableForTable(context.Background(), c.Table("events"))
+	require.EqualError(t, err, `column "geo": unknown data type: "geometry"`)
+}
+
+func TestCableValidateOnStart(t *testing.T) {
+	t.Parallel()
+
+	var statements []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := decodeCompressedRequestBody(r)
+		require.NoError(t, err)
+		var req ingestRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Empty(t, req.Data.Rows)
+		require.Equal(t, writeTypeCommitted, req.Type)
+		statements = append(statements, req.Statement)
+
+		if req.Statement != "SELECT $0 INSERT INTO t (v)" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{"message": "syntax error at 1:1"})
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.ValidateOnStart = true
+	require.NoError(t, cable.Start(ctx))
+	cable.Close()
+	require.NoError(t, cable.wait(ctx))
+
+	cable = c.DataCable("SELEC $0 INSERT INTO t (v)")
+	cable.ValidateOnStart = true
+	err := cable.Start(ctx)
+	require.ErrorIs(t, err, ErrSyntax)
+	require.ErrorContains(t, err, "validate transforms: ingest: status 400: syntax error at 1:1")
+	require.ErrorIs(t, <-cable.Send("x"), ErrCableStopped)
+	require.Len(t, statements, 2)
+
+	// A cable that failed validation stays stopped.
+	require.ErrorIs(t, cable.Start(ctx), ErrCableStarted)
+	require.Len(t, statements, 2)
+}
+
+func TestCableStartTwice(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(writeEmptyResponse))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	require.NoError(t, cable.Start(ctx))
+	require.ErrorIs(t, cable.Start(ctx), ErrCableStarted)
+	require.NoError(t, <-cable.Send("x"))
+	cable.Close()
+	require.NoError(t, cable.wait(ctx))
+}
+
+func TestCableLastFlushAndHealthy(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 1
+	cable.BatchInterval = time.Hour
+	cable.UnhealthyFlushFailures = 2
+	require.NoError(t, cable.Start(context.Background()))
+	defer cable.Close()
+
+	require.Zero(t, cable.LastFlush().Time)
+	require.NoError(t, cable.Healthy())
+
+	require.NoError(t, <-cable.Send("a"))
+	flush := cable.LastFlush()
+	require.NotZero(t, flush.Time)
+	require.Equal(t, 1, flush.Records)
+	require.Equal(t, 3, flush.Bytes)
+	require.NoError(t, flush.Err)
+
+	failing.Store(true)
+	require.Error(t, <-cable.Send("b"))
+	require.Error(t, cable.LastFlush().Err)
+	require.NoError(t, cable.Healthy())
+	require.Error(t, <-cable.Send("c"))
+	require.ErrorContains(t, cable.Healthy(), "last 2 flushes failed: ingest: status 503")
+
+	failing.Store(false)
+	require.NoError(t, <-cable.Send("d"))
+	require.NoError(t, cable.Healthy())
+
+	cable.Close()
+	require.NoError(t, cable.wait(context.Background()))
+	require.ErrorIs(t, cable.Healthy(), ErrCableStopped)
+}
+
+func TestCableUnhealthyBufferAge(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := newIdleCable(context.Background(), t, c)
+	defer cable.Close()
+	cable.UnhealthyBufferAge = 10 * time.Millisecond
+
+	require.NoError(t, cable.SendNoWait("x"))
+	require.Eventually(t, func() bool {
+		err := cable.Healthy()
+		return err != nil && strings.Contains(err.Error(), "without a flush")
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCableUnhealthyBufferAgeDefault(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(&Config{Endpoint: "http://localhost"})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchInterval = 0
+
+	cable.oldestBuffered.Store(time.Now().Add(-time.Second).UnixNano())
+	require.NoError(t, cable.Healthy())
+	cable.oldestBuffered.Store(time.Now().Add(-defaultUnhealthyBufferAge - time.Second).UnixNano())
+	require.ErrorContains(t, cable.Healthy(), "without a flush")
+}
+
+func TestCableSendFlush(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 7
+	cable.BatchInterval = time.Hour
+	require.NoError(t, cable.Start(context.Background()))
+
+	var flushes []*Flush
+	for _, record := range []string{"a", "b", "c"} {
+		flush, err := cable.SendFlush(record)
+		require.NoError(t, err)
+		flushes = append(flushes, flush)
+	}
+	require.Same(t, flushes[0], flushes[1])
+	require.NotSame(t, flushes[1], flushes[2])
+
+	<-flushes[0].Done()
+	require.NoError(t, flushes[0].Err())
+	require.Equal(t, &IngestResponse{RowsInserted: 1}, flushes[0].Result())
+
+	cable.Close()
+	<-flushes[2].Done()
+	require.NoError(t, flushes[2].Err())
+	require.NoError(t, cable.wait(context.Background()))
+	require.Len(t, requests(), 2)
+
+	_, err := cable.SendFlush("d")
+	require.ErrorIs(t, err, ErrCableStopped)
+}
+
+func TestCableSendFlushError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 1
+	require.NoError(t, cable.Start(context.Background()))
+	defer cable.Close()
+
+	flush, err := cable.SendFlush("a")
+	require.NoError(t, err)
+	<-flush.Done()
+	require.Equal(t, http.StatusServiceUnavailable, StatusCodeOf(flush.Err()))
+	require.Nil(t, flush.Result())
+}
+
+func TestCableDedupeKeyFunc(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	type event struct {
+		ID string `json:"id"`
+		N  int    `json:"n"`
+	}
+	cable := newIdleCable(context.Background(), t, c)
+	cable.DedupeKeyFunc = func(record any) (string, bool) {
+		e, ok := record.(event)
+		return e.ID, ok
+	}
+
+	acks := []<-chan error{
+		cable.Send(event{ID: "a", N: 1}),
+		cable.Send(event{ID: "b", N: 1}),
+		cable.Send(event{ID: "a", N: 2}),
+		cable.Send("unkeyed"),
+		cable.Send(event{ID: "a", N: 3}),
+	}
+	cable.Close()
+	require.NoError(t, cable.wait(context.Background()))
+	require.Equal(t, CableStats{Deduped: 2}, cable.Stats())
+	for _, ack := range acks {
+		require.NoError(t, <-ack)
+	}
+	reqs := requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, `{"id":"a","n":3}
+{"id":"b","n":1}
+"unkeyed"`, reqs[0].Body["data"].(map[string]any)["rows"])
+}
+
+func TestCableCloseContext(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := newIdleCable(context.Background(), t, c)
+	ack := cable.Send("a")
+	dropped, err := cable.CloseContext(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, dropped)
+	require.NoError(t, <-ack)
+
+	dropped, err = c.DataCable("SELECT $0 INSERT INTO t (v)").CloseContext(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, dropped)
+}
+
+func TestCableCloseContextAbandons(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// The server notices the client going away only once the body is read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := newIdleCable(context.Background(), t, c)
+	acks := []<-chan error{cable.Send("a"), cable.Send("b"), cable.Send("c")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	dropped, err := cable.CloseContext(ctx)
+	require.Equal(t, 3, dropped)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, err, context.Canceled)
+	for _, ack := range acks {
+		require.ErrorIs(t, <-ack, context.Canceled)
+	}
+}
+
+func TestCableDrainsWhenContextDone(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		select {
+		case <-release:
+			writeEmptyResponse(w, r)
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	for _, tc := range []struct {
+		name        string
+		gracePeriod time.Duration
+		want        FlushResult
+	}{
+		{name: "dropped", gracePeriod: 100 * time.Millisecond, want: FlushResult{Final: true, Dropped: 3}},
+		{name: "drained", gracePeriod: 5 * time.Second, want: FlushResult{Final: true, Drained: 3}},
+	} {
+		received.Store(0)
+		var mu sync.Mutex
+		var flushed []FlushResult
+		final := make(chan FlushResult, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+		cable.BatchSize = 3
+		cable.BatchInterval = time.Hour
+		cable.DrainGracePeriod = tc.gracePeriod
+		cable.OnFlush = func(result FlushResult) {
+			if result.Final {
+				final <- result
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			flushed = append(flushed, result)
+		}
+		require.NoError(t, cable.Start(ctx))
+
+		// The first batch is in flight and the last record is buffered when
+		// ctx is canceled.
+		acks := []<-chan error{cable.Send(1), cable.Send(2)}
+		require.Eventually(t, func() bool { return received.Load() == 1 }, time.Second, time.Millisecond)
+		acks = append(acks, cable.Send(3))
+		cancel()
+		require.Eventually(t, func() bool { return received.Load() == 2 }, time.Second, time.Millisecond)
+		require.ErrorIs(t, <-cable.Send(4), ErrCableStopped, tc.name)
+
+		if tc.want.Dropped == 0 {
+			close(release)
+		}
+		require.Equal(t, tc.want, <-final, tc.name)
+		mu.Lock()
+		require.Len(t, flushed, 2, tc.name)
+		for _, result := range flushed {
+			require.Equal(t, tc.want.Dropped > 0, result.Err != nil, tc.name)
+		}
+		mu.Unlock()
+		for _, ack := range acks {
+			if tc.want.Dropped == 0 {
+				require.NoError(t, <-ack, tc.name)
+			} else {
+				require.ErrorIs(t, <-ack, context.Canceled, tc.name)
+			}
+		}
+	}
+}
+
+func TestCableBatchesWithinMaxRequestBytes(t *testing.T) {
+	t.Parallel()
+
+	const transforms = "SELECT $0 INSERT INTO t (v)"
+	server, requests := newRecordingTestServer(t)
+	// Leave room for 10 bytes of rows, i.e., two records of `"ab"`.
+	c := NewClient(&Config{Endpoint: server.URL, MaxRequestBytes: 1024 + 2*int64(len(transforms)) + 20})
+	defer c.Close()
+	require.Equal(t, uint64(10), c.http.maxIngestRows(transforms))
+
+	cable := newIdleCable(context.Background(), t, c)
+	acks := []<-chan error{cable.Send("ab"), cable.Send("ab"), cable.Send("ab")}
+	cable.Close()
+	require.NoError(t, cable.wait(context.Background()))
+	for _, ack := range acks {
+		require.NoError(t, <-ack)
+	}
+
+	var rows []any
+	for _, r := range requests() {
+		rows = append(rows, r.Body["data"].(map[string]any)["rows"])
+	}
+	require.ElementsMatch(t, []any{"\"ab\"\n\"ab\"", "\"ab\""}, rows)
+}
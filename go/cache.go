@@ -0,0 +1,297 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"container/list"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// ResultCacheConfig configures the client-side result cache; see Config.ResultCache.
+//
+// Statement.Execute caches the finished result sets of read-only statements
+// for TTL, keyed by the statement text with insignificant whitespace removed,
+// the result format, and the row limit. A statement is read-only unless it
+// contains a DML or DDL keyword, such as INSERT, DELETE, or CREATE, outside
+// of literals and comments. Statements with an explicit ID are never cached.
+//
+// Cached results may be stale by up to TTL; use Client.InvalidateCache after
+// writes that the cached statements should observe.
+type ResultCacheConfig struct {
+	// TTL is how long a result set is served from the cache. The cache is
+	// disabled if TTL is not positive.
+	TTL time.Duration
+	// MaxEntries is the maximum number of cached result sets. When it is
+	// exceeded, the least recently used result sets are evicted.
+	//
+	// Zero means unlimited.
+	MaxEntries int
+	// MaxBytes is the maximum total size of the rows of the cached result sets.
+	// When it is exceeded, the least recently used result sets are evicted.
+	// Result sets larger than MaxBytes are not cached.
+	//
+	// Zero means unlimited.
+	MaxBytes int64
+}
+
+// CacheStats are the counters of the client-side result cache.
+type CacheStats struct {
+	// Hits is the number of Execute calls served from the cache.
+	Hits uint64
+	// Misses is the number of cacheable Execute calls not served from the cache.
+	Misses uint64
+	// Entries is the number of cached result sets.
+	Entries int
+	// Bytes is the total size of the rows of the cached result sets.
+	Bytes int64
+}
+
+// CacheStats returns the counters of the result cache, or zero values if
+// Config.ResultCache is not enabled.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.stats()
+}
+
+// InvalidateCache removes all result sets from the result cache.
+func (c *Client) InvalidateCache() {
+	if c.cache != nil {
+		c.cache.clear()
+	}
+}
+
+type resultCache struct {
+	config ResultCacheConfig
+	now    func() time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+
+	mu sync.Mutex
+	// lru holds *cacheEntry values, most recently used first.
+	lru     *list.List
+	entries map[string]*list.Element
+	bytes   int64
+}
+
+type cacheEntry struct {
+	key     string
+	rs      *ResultSet
+	size    int64
+	expires time.Time
+}
+
+func newResultCache(config *Config) *resultCache {
+	if config == nil || config.ResultCache == nil || config.ResultCache.TTL <= 0 {
+		return nil
+	}
+	return &resultCache{
+		config:  *config.ResultCache,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// cacheKey returns the cache key of the statement, or false if the statement
+// must not be cached.
+func (s *Statement) cacheKey() (string, bool) {
+	if s.c.cache == nil || s.ID != nil {
+		return "", false
+	}
+	text, ok := normalizeStatement(s.stmt)
+	if !ok {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteString(string(s.ResultFormat))
+	b.WriteByte(0)
+	b.WriteString(strconv.FormatUint(s.MaxResultRows, 10))
+	b.WriteByte(0)
+	b.WriteString(strconv.FormatBool(s.TruncateResult))
+	b.WriteByte(0)
+	b.WriteString(text)
+	return b.String(), true
+}
+
+// get returns a copy of the cached result set for key, if not expired.
+func (c *resultCache) get(key string) (*ResultSet, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.hits.Add(1)
+	rs := *entry.rs
+	rs.Schema = cloneSchema(rs.Schema)
+	return &rs, true
+}
+
+// cloneSchema copies the schema so that callers cannot modify cached fields.
+func cloneSchema(schema Schema) Schema {
+	cloned := make(Schema, len(schema))
+	for i, field := range schema {
+		f := *field
+		cloned[i] = &f
+	}
+	return cloned
+}
+
+// put caches a copy of rs for key and evicts the least recently used result
+// sets beyond the limits.
+func (c *resultCache) put(key string, rs *ResultSet) {
+	size := int64(len(rs.rows))
+	if c.config.MaxBytes > 0 && size > c.config.MaxBytes {
+		return
+	}
+	copied := *rs
+	copied.Schema = cloneSchema(rs.Schema)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:     key,
+		rs:      &copied,
+		size:    size,
+		expires: c.now().Add(c.config.TTL),
+	})
+	c.bytes += size
+	for (c.config.MaxEntries > 0 && c.lru.Len() > c.config.MaxEntries) ||
+		(c.config.MaxBytes > 0 && c.bytes > c.config.MaxBytes) {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes elem from the cache. The caller must hold c.mu.
+func (c *resultCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	clear(c.entries)
+	c.bytes = 0
+}
+
+func (c *resultCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: c.lru.Len(),
+		Bytes:   c.bytes,
+	}
+}
+
+// mutatingKeywords are the keywords that make a statement uncacheable.
+var mutatingKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+	"CREATE": true, "DROP": true, "ALTER": true, "TRUNCATE": true,
+	"OPTIMIZE": true, "VACUUM": true, "GRANT": true, "REVOKE": true,
+	"COPY": true, "SET": true, "USE": true, "CANCEL": true, "KILL": true,
+}
+
+// normalizeStatement collapses whitespace and drops comments outside of
+// literals and quoted identifiers. It returns false if the statement contains
+// a keyword of mutatingKeywords.
+func normalizeStatement(stmt string) (string, bool) {
+	var b strings.Builder
+	space := false
+	write := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(stmt); {
+		ch := stmt[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			j := i + 1
+			for j < len(stmt) && stmt[j] != ch {
+				if stmt[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(stmt))
+			write(stmt[i:j])
+			i = j
+		case ch == '-' && strings.HasPrefix(stmt[i:], "--"):
+			j := strings.IndexByte(stmt[i:], '\n')
+			if j < 0 {
+				j = len(stmt) - i
+			}
+			i += j
+			space = true
+		case ch == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			j := strings.Index(stmt[i+2:], "*/")
+			if j < 0 {
+				i = len(stmt)
+			} else {
+				i += j + 4
+			}
+			space = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+			space = true
+		case ch == '_' || unicode.IsLetter(rune(ch)):
+			j := i + 1
+			for j < len(stmt) && (stmt[j] == '_' || unicode.IsLetter(rune(stmt[j])) || unicode.IsDigit(rune(stmt[j]))) {
+				j++
+			}
+			word := stmt[i:j]
+			if mutatingKeywords[strings.ToUpper(word)] {
+				return "", false
+			}
+			write(word)
+			i = j
+		default:
+			write(stmt[i : i+1])
+			i++
+		}
+	}
+	return b.String(), true
+}
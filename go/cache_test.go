@@ -0,0 +1,107 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCache(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRoutingTestServer(t, func(string) ([]resultSetField, [][]*string) {
+		return []resultSetField{{Name: "v", DataType: "int"}}, [][]*string{{ptr("1")}}
+	})
+	c := NewClient(&Config{
+		Endpoint:    server.URL,
+		ResultCache: &ResultCacheConfig{TTL: time.Minute, MaxEntries: 2},
+	})
+	now := time.Now()
+	c.cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	execute := func(stmt string) *ResultSet {
+		rs, err := c.Statement(stmt).Execute(ctx)
+		require.NoError(t, err)
+		return rs
+	}
+
+	first := execute("FROM t  SELECT v")
+	require.Len(t, *requests, 1)
+	second := execute("FROM t\n\tSELECT v -- cached")
+	require.Len(t, *requests, 1)
+	require.Equal(t, first, second)
+	require.NotSame(t, first, second)
+	second.Schema[0].Name = "changed"
+	require.Equal(t, "v", execute("FROM t SELECT v").Schema[0].Name)
+	require.Equal(t, CacheStats{Hits: 2, Misses: 1, Entries: 1, Bytes: int64(len(first.rows))}, c.CacheStats())
+
+	// Literals are kept verbatim.
+	execute("FROM t WHERE s = 'a  b' SELECT v")
+	execute("FROM t WHERE s = 'a b' SELECT v")
+	require.Len(t, *requests, 3)
+
+	// Writes, explicit IDs, and expired entries bypass the cache.
+	execute("INSERT INTO t VALUES (1)")
+	execute("insert into t values (1)")
+	require.Len(t, *requests, 5)
+	stmt := c.Statement("FROM t SELECT v")
+	id := uuid.New()
+	stmt.ID = &id
+	_, err := stmt.Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, *requests, 6)
+	now = now.Add(time.Minute)
+	execute("FROM t WHERE s = 'a b' SELECT v")
+	require.Len(t, *requests, 7)
+
+	// MaxEntries evicts the least recently used entry.
+	require.Equal(t, 2, c.CacheStats().Entries)
+	execute("FROM t SELECT v")
+	require.Len(t, *requests, 8)
+
+	c.InvalidateCache()
+	require.Equal(t, 0, c.CacheStats().Entries)
+	execute("FROM t WHERE s = 'a b' SELECT v")
+	require.Len(t, *requests, 9)
+}
+
+func TestNormalizeStatement(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		stmt string
+		want string
+		ok   bool
+	}{
+		{stmt: " FROM t\n SELECT  v ", want: "FROM t SELECT v", ok: true},
+		{stmt: "FROM t /* a\nb */ SELECT v", want: "FROM t SELECT v", ok: true},
+		{stmt: `FROM "delete" WHERE s = 'it\'s  INSERT' SELECT v`, want: `FROM "delete" WHERE s = 'it\'s  INSERT' SELECT v`, ok: true},
+		{stmt: "FROM t DELETE WHERE v > 1", ok: false},
+		{stmt: "create table t (v int)", ok: false},
+		{stmt: "FROM t SELECT updated_at", want: "FROM t SELECT updated_at", ok: true},
+	} {
+		got, ok := normalizeStatement(tc.stmt)
+		require.Equal(t, tc.ok, ok, tc.stmt)
+		require.Equal(t, tc.want, got, tc.stmt)
+	}
+}
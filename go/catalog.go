@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SchemaInfo describes a schema in ScopeDB.
+type SchemaInfo struct {
+	// Database is the name of the database that the schema belongs to.
+	Database string
+	// Name is the name of the schema.
+	Name string
+}
+
+// ListDatabases returns the names of all databases, ordered by name.
+//
+// This method issues a meta query to ScopeDB and blocks until the result is fetched.
+func (c *Client) ListDatabases(ctx context.Context) ([]string, error) {
+	var rows []struct {
+		Name string `json:"database_name"`
+	}
+	if err := c.queryStructs(ctx, `
+		FROM scopedb.system.databases
+		SELECT database_name
+		ORDER BY database_name
+	`, &rows); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		names[i] = row.Name
+	}
+	return names, nil
+}
+
+// ListSchemas returns the schemas in the given database, ordered by name.
+//
+// If the database does not exist, an error wrapping ErrDatabaseNotFound is returned.
+//
+// This method issues meta queries to ScopeDB and blocks until the result is fetched.
+func (c *Client) ListSchemas(ctx context.Context, database string) ([]*SchemaInfo, error) {
+	var rows []struct {
+		Database string `json:"database_name"`
+		Name     string `json:"schema_name"`
+	}
+	if err := c.queryStructs(ctx, fmt.Sprintf(`
+		FROM scopedb.system.schemas
+		WHERE database_name = %s
+		SELECT database_name, schema_name
+		ORDER BY schema_name
+	`, QuoteString(database)), &rows); err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		databases, err := c.ListDatabases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(databases, database) {
+			return nil, fmt.Errorf("%w: %s", ErrDatabaseNotFound, database)
+		}
+	}
+
+	schemas := make([]*SchemaInfo, len(rows))
+	for i, row := range rows {
+		schemas[i] = &SchemaInfo{Database: row.Database, Name: row.Name}
+	}
+	return schemas, nil
+}
+
+// ListTables returns the tables and views in the given schema, ordered by name.
+//
+// The Kind of each returned Table is set to TableKindTable or TableKindView.
+//
+// This method issues meta queries on scopedb.system.tables and
+// scopedb.system.views to ScopeDB and blocks until the results are fetched.
+func (c *Client) ListTables(ctx context.Context, database, schema string) ([]*Table, error) {
+	var tables []*Table
+	for _, source := range []struct {
+		systemTable string
+		kind        TableKind
+	}{
+		{"tables", TableKindTable},
+		{"views", TableKindView},
+	} {
+		var rows []struct {
+			Name string `json:"table_name"`
+		}
+		if err := c.queryStructs(ctx, fmt.Sprintf(`
+			FROM scopedb.system.%s
+			WHERE database_name = %s
+			  AND schema_name = %s
+			SELECT table_name
+		`, source.systemTable, QuoteString(database), QuoteString(schema)), &rows); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			tables = append(tables, &Table{
+				c:        c,
+				Database: database,
+				Schema:   schema,
+				Table:    row.Name,
+				Kind:     source.kind,
+			})
+		}
+	}
+
+	slices.SortStableFunc(tables, func(a, b *Table) int {
+		return strings.Compare(a.Table, b.Table)
+	})
+	return tables, nil
+}
+
+// queryStructs executes the statement and stores the rows into dest with ResultSet.ToStructs.
+func (c *Client) queryStructs(ctx context.Context, stmt string, dest any) error {
+	rs, err := c.Statement(stmt).Execute(ctx)
+	if err != nil {
+		return err
+	}
+	return rs.ToStructs(dest)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientListSchemas(t *testing.T) {
+	t.Parallel()
+
+	var statements []string
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		statements = append(statements, stmt)
+		if strings.Contains(stmt, "system.databases") {
+			return []resultSetField{{Name: "database_name", DataType: "string"}}, [][]*string{{ptr("scopedb")}}
+		}
+		if strings.Contains(stmt, "database_name = 'scopedb'") {
+			return []resultSetField{
+				{Name: "database_name", DataType: "string"},
+				{Name: "schema_name", DataType: "string"},
+			}, [][]*string{{ptr("scopedb"), ptr("public")}}
+		}
+		return []resultSetField{
+			{Name: "database_name", DataType: "string"},
+			{Name: "schema_name", DataType: "string"},
+		}, nil
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	databases, err := c.ListDatabases(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"scopedb"}, databases)
+
+	schemas, err := c.ListSchemas(context.Background(), "scopedb")
+	require.NoError(t, err)
+	require.Equal(t, []*SchemaInfo{{Database: "scopedb", Name: "public"}}, schemas)
+
+	_, err = c.ListSchemas(context.Background(), "missing'db")
+	require.ErrorIs(t, err, ErrDatabaseNotFound)
+	require.Contains(t, statements[len(statements)-2], `database_name = 'missing\'db'`)
+}
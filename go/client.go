@@ -21,10 +21,16 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,25 +41,110 @@ import (
 type Client struct {
 	config *Config
 	http   *httpClient
+	// endpoint is the parsed Config.Endpoint, or nil if endpointErr is set.
+	endpoint    *url.URL
+	endpointErr error
+	// configErr is the error of config.Validate, returned by submitted statements.
+	configErr error
+	// priorityUnsupported is set once the server has rejected a priority.
+	priorityUnsupported atomic.Bool
+	// cache is the result cache, or nil if Config.ResultCache is not enabled.
+	cache *resultCache
+
+	cablesMu sync.Mutex
+	// cables are the started cables that are not drained yet.
+	cables map[drainer]struct{}
+}
+
+// drainer is a cable that Shutdown closes and waits for.
+type drainer interface {
+	Close()
+	wait(ctx context.Context) error
 }
 
 // NewClient creates a new ScopeDB client with the given configuration.
 func NewClient(config *Config) *Client {
+	return newClient(config, nil)
+}
+
+// newClient creates a new client that sends requests with client, or with a
+// new HTTP client built from config if nil.
+func newClient(config *Config, client *http.Client) *Client {
+	endpoint, socket, endpointErr := parseEndpoint(config.Endpoint)
+	if client == nil {
+		client = newHTTPClient(config, socket)
+	}
 	return &Client{
-		config: config,
+		config:      config,
+		endpoint:    endpoint,
+		endpointErr: endpointErr,
 		http: &httpClient{
-			client:        http.DefaultClient,
-			authorization: bearerAuthorization(config),
-			compression:   requestCompression(config),
+			client:          client,
+			authorization:   authorization(config),
+			tokens:          newTokenCache(config),
+			compression:     requestCompression(config),
+			maxRequestBytes: maxRequestBytes(config),
+			limiter:         newRequestLimiter(config),
+			breakers:        newCircuitBreakers(config),
+			signRequest:     requestSigner(config),
+
+			logger:         debugLogger(config),
+			scrubStatement: statementScrubber(config),
 		},
+		configErr: config.Validate(),
+		cache:     newResultCache(config),
 	}
 }
 
+// Shutdown closes all cables started from the client, waits until their
+// pending batches are flushed, and then closes the client.
+//
+// If ctx is done before all cables are drained, Shutdown returns without
+// waiting for the rest. The returned error joins the errors of the flushes
+// that failed during the drain and of the cables that did not drain in time.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.cablesMu.Lock()
+	cables := make([]drainer, 0, len(c.cables))
+	for cable := range c.cables {
+		cables = append(cables, cable)
+	}
+	c.cablesMu.Unlock()
+
+	var errs []error
+	for _, cable := range cables {
+		cable.Close()
+	}
+	for _, cable := range cables {
+		if err := cable.wait(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("drain cable: %w", err))
+		}
+	}
+	c.Close()
+	return errors.Join(errs...)
+}
+
+func (c *Client) trackCable(cable drainer) {
+	c.cablesMu.Lock()
+	defer c.cablesMu.Unlock()
+	if c.cables == nil {
+		c.cables = make(map[drainer]struct{})
+	}
+	c.cables[cable] = struct{}{}
+}
+
+func (c *Client) untrackCable(cable drainer) {
+	c.cablesMu.Lock()
+	defer c.cablesMu.Unlock()
+	delete(c.cables, cable)
+}
+
 // Close closes the ScopeDB client and release all associated resources.
 //
 // You don't typically need to call this as the garbage collector will release
 // the resources when the connection is no longer referenced. However, it can be
 // useful to call this if you want to release the resources immediately.
+//
+// Close does not flush cables started from the client; use Shutdown for that.
 func (c *Client) Close() {
 	c.http.Close()
 }
@@ -62,7 +153,22 @@ func (c *Client) Close() {
 type httpClient struct {
 	client        *http.Client
 	authorization string
-	compression   Compression
+	// tokens, if set, provide the authorization instead. See Config.TokenSource.
+	tokens      *tokenCache
+	compression Compression
+	// maxRequestBytes is the limit of uncompressed request bodies, or zero
+	// if unlimited. See Config.MaxRequestBytes.
+	maxRequestBytes int64
+	// limiter bounds the requests in flight. See Config.MaxConcurrentRequests.
+	limiter *requestLimiter
+	// breakers, if set, fail requests fast. See Config.CircuitBreaker.
+	breakers *circuitBreakers
+	// signRequest, if set, signs every request. See Config.SignRequest.
+	signRequest func(req *http.Request, body []byte) error
+	// logger, if set, logs every request at the debug level. See Config.DebugLogger.
+	logger *slog.Logger
+	// scrubStatement rewrites statement text before it is logged.
+	scrubStatement func(string) string
 }
 
 // doGet sends a GET request to the ScopeDB server.
@@ -72,13 +178,18 @@ func (c *httpClient) doGet(ctx context.Context, u *url.URL) (*http.Response, err
 		return nil, err
 	}
 	c.applyAuthorization(req)
-	resp, err := c.client.Do(req)
-	return resp, err
+	return c.do(req, nil)
 }
 
 // doPost sends a POST request to the ScopeDB server.
 func (c *httpClient) doPost(ctx context.Context, u *url.URL, body []byte) (*http.Response, error) {
 	uncompressedContentLength := len(body)
+	if c.maxRequestBytes > 0 && int64(uncompressedContentLength) > c.maxRequestBytes {
+		return nil, &RequestError{
+			Operation: operationOf(&http.Request{URL: u}),
+			Err:       &RequestTooLargeError{Size: int64(uncompressedContentLength), Limit: c.maxRequestBytes},
+		}
+	}
 
 	compressedBody, compression, err := compressRequestBody(body, c.compression)
 	if err != nil {
@@ -93,8 +204,143 @@ func (c *httpClient) doPost(ctx context.Context, u *url.URL, body []byte) (*http
 	req.Header.Set("Content-Encoding", string(compression))
 	req.Header.Set("X-ScopeDB-Uncompressed-Content-Length", strconv.Itoa(uncompressedContentLength))
 	c.applyAuthorization(req)
-	resp, err := c.client.Do(req)
-	return resp, err
+	return c.do(req, body)
+}
+
+const (
+	// requestIDHeader is the header of the client-generated request ID.
+	requestIDHeader = "X-Request-ID"
+	// requestDeadlineHeader is the header of the milliseconds remaining until
+	// the deadline of the request context, after which the server may stop
+	// working on the request.
+	requestDeadlineHeader = "X-ScopeDB-Request-Deadline-Millis"
+)
+
+// setDeadlineHeader sets the requestDeadlineHeader of req if its context has a deadline.
+func setDeadlineHeader(req *http.Request) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return
+	}
+	remaining := max(time.Until(deadline).Milliseconds(), 0)
+	req.Header.Set(requestDeadlineHeader, strconv.FormatInt(remaining, 10))
+}
+
+// do sends req, whose uncompressed body is body, with the headers and
+// credentials of its context, see WithCredentials, or else with the token of
+// Config.TokenSource if set. If ScopeDB rejects the token, it is refreshed
+// and req is sent once more.
+func (c *httpClient) do(req *http.Request, body []byte) (*http.Response, error) {
+	if applyContext(req) || c.tokens == nil {
+		return c.send(req, body)
+	}
+
+	token, err := c.tokens.get(req.Context())
+	if err != nil {
+		return nil, &RequestError{Operation: operationOf(req), Err: err}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.send(req, body)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+
+	c.tokens.invalidate(token)
+	token, err = c.tokens.get(req.Context())
+	if err != nil {
+		sneakyBodyClose(resp.Body)
+		return nil, &RequestError{Operation: operationOf(req), Err: err}
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			sneakyBodyClose(resp.Body)
+			return nil, &RequestError{Operation: operationOf(req), Err: err}
+		}
+	}
+	sneakyBodyClose(resp.Body)
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return c.send(retry, body)
+}
+
+// send sends req, whose uncompressed body is body, and logs it if debug logging is enabled.
+//
+// Every request is tagged with a new request ID in the X-Request-ID header, and
+// errors of the request are returned as *RequestError. If the request context
+// has a deadline, the remaining time is sent in the deadline header.
+//
+// The request fails fast if the circuit breaker of its endpoint is open.
+// Otherwise, it waits for a slot of the limiter, and holds it until the
+// response body is closed.
+func (c *httpClient) send(req *http.Request, body []byte) (*http.Response, error) {
+	requestID := uuid.NewString()
+	req.Header.Set(requestIDHeader, requestID)
+
+	done := func(requestOutcome) {}
+	if c.breakers != nil {
+		var err error
+		done, err = c.breakers.allow(req.Context(), req.URL.Host)
+		if err != nil {
+			return nil, &RequestError{RequestID: requestID, Operation: operationOf(req), Err: err}
+		}
+	}
+
+	release, err := c.limiter.acquire(req.Context())
+	if err != nil {
+		done(requestAbandoned)
+		return nil, &RequestError{RequestID: requestID, Operation: operationOf(req), Err: err}
+	}
+	setDeadlineHeader(req)
+	if err := c.sign(req); err != nil {
+		done(requestAbandoned)
+		release()
+		return nil, &RequestError{RequestID: requestID, Operation: operationOf(req), Err: err}
+	}
+
+	var resp *http.Response
+	if c.logger == nil {
+		resp, err = c.client.Do(req)
+	} else {
+		start := time.Now()
+		resp, err = c.client.Do(req)
+		c.logRequest(req, body, resp, err, time.Since(start))
+	}
+	switch {
+	case err == nil:
+		done(requestSucceeded)
+	case req.Context().Err() != nil:
+		done(requestAbandoned)
+	default:
+		done(requestFailed)
+	}
+	if err != nil {
+		release()
+		return nil, &RequestError{RequestID: requestID, Operation: operationOf(req), Err: proxyError(err)}
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// sign calls the signRequest hook with the body of req as sent on the wire.
+func (c *httpClient) sign(req *http.Request) error {
+	if c.signRequest == nil {
+		return nil
+	}
+	var wire []byte
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		defer sneakyBodyClose(body)
+		if wire, err = io.ReadAll(body); err != nil {
+			return err
+		}
+	}
+	if err := c.signRequest(req, wire); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+	return nil
 }
 
 func (c *httpClient) applyAuthorization(req *http.Request) {
@@ -113,11 +359,102 @@ func (c *httpClient) Close() {
 	c.client.CloseIdleConnections()
 }
 
-func bearerAuthorization(config *Config) string {
-	if config == nil || config.APIKey == "" {
+// newHTTPClient returns the HTTP client of config. If socket is not empty,
+// requests are sent over that Unix domain socket.
+//
+// Config.Transport, if set, is used as is.
+func newHTTPClient(config *Config, socket string) *http.Client {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.Transport != nil {
+		return &http.Client{Transport: config.Transport}
+	}
+	proxy := proxyFunc(config)
+	if socket == "" && !config.ForceHTTP2 && proxy == nil {
+		return http.DefaultClient
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = proxy
+	}
+	if socket != "" {
+		dialUnixSocket(transport, socket)
+	}
+	if config.ForceHTTP2 {
+		// HTTP/2 over TLS for https endpoints, and h2c with prior knowledge
+		// for plain http endpoints.
+		var protocols http.Protocols
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = &protocols
+	}
+	return &http.Client{Transport: transport}
+}
+
+// proxyFunc returns the proxy of config, or nil to use the environment.
+func proxyFunc(config *Config) func(*http.Request) (*url.URL, error) {
+	if config.Proxy != nil {
+		return config.Proxy
+	}
+	if config.ProxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(config.ProxyURL)
+	if err != nil {
+		// Also reported by Config.Validate. The url.Error quotes the URL,
+		// which may contain credentials.
+		err = fmt.Errorf("invalid proxy url: %w", errors.Unwrap(err))
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(u)
+}
+
+// authorization returns the static Authorization header of config: bearer
+// for Config.APIKey, or basic for Config.Username and Config.Password.
+func authorization(config *Config) string {
+	if config == nil {
 		return ""
 	}
-	return "Bearer " + config.APIKey
+	return Credentials{APIKey: config.APIKey, Username: config.Username, Password: config.Password}.authorization()
+}
+
+// defaultMaxRequestBytes is the default of Config.MaxRequestBytes.
+const defaultMaxRequestBytes = 256 * 1024 * 1024 // default to 256 MiB
+
+func maxRequestBytes(config *Config) int64 {
+	switch {
+	case config == nil || config.MaxRequestBytes == 0:
+		return defaultMaxRequestBytes
+	case config.MaxRequestBytes < 0:
+		return 0
+	default:
+		return config.MaxRequestBytes
+	}
+}
+
+// maxIngestRows returns the maximum size in bytes of the rows of an ingest
+// request through statement that keeps the request within the limit of
+// uncompressed bodies, or zero if unlimited.
+//
+// The rows are JSON-encoded into the body as a string, which at most doubles
+// them: compact JSON has no raw control characters, so only quotes and
+// backslashes are escaped.
+func (c *httpClient) maxIngestRows(statement string) uint64 {
+	if c.maxRequestBytes <= 0 {
+		return 0
+	}
+	// ingestRequestOverhead bounds the other fields of an ingest request.
+	const ingestRequestOverhead = 1024
+	return uint64(max((c.maxRequestBytes-2*int64(len(statement))-ingestRequestOverhead)/2, 1))
+}
+
+func requestSigner(config *Config) func(*http.Request, []byte) error {
+	if config == nil {
+		return nil
+	}
+	return config.SignRequest
 }
 
 func requestCompression(config *Config) Compression {
@@ -163,9 +500,14 @@ type statementRequest struct {
 	Statement   string       `json:"statement"`
 	ExecTimeout string       `json:"exec_timeout,omitempty"`
 	Format      ResultFormat `json:"format"`
+	NodeGroup   string       `json:"nodegroup,omitempty"`
+	Priority    Priority     `json:"priority,omitempty"`
 }
 
 type statementResponse struct {
+	// requestID is the ID of the HTTP request that returned the response.
+	requestID string
+
 	ID       uuid.UUID         `json:"statement_id"`
 	Progress StatementProgress `json:"progress"`
 	Status   StatementStatus   `json:"status"`
@@ -191,28 +533,45 @@ type resultSetMetadata struct {
 
 type resultSetField struct {
 	Name     string `json:"name"`
-	DataType string `json:"data_Type"`
+	DataType string `json:"data_type"`
+	Nullable bool   `json:"nullable,omitempty"`
 }
 
 func (rs *resultSet) toResultSet() *ResultSet {
-	schema := make(Schema, len(rs.Metadata.Fields))
-	for i, field := range rs.Metadata.Fields {
-		schema[i] = &FieldSchema{
-			Name: field.Name,
-			Type: DataType(field.DataType),
-		}
+	// DDL statements may finish with a result set that has no metadata or rows.
+	metadata := rs.Metadata
+	if metadata == nil {
+		metadata = &resultSetMetadata{}
+	}
+	rows := rs.Rows
+	if len(rows) == 0 || string(rows) == "null" {
+		rows = json.RawMessage("[]")
+	}
+
+	schema := make(Schema, len(metadata.Fields))
+	for i, field := range metadata.Fields {
+		schema[i] = newFieldSchema(field.Name, field.DataType)
+		schema[i].Nullable = field.Nullable
 	}
 
 	return &ResultSet{
-		TotalRows: rs.Metadata.NumRows,
+		TotalRows: metadata.NumRows,
 		Schema:    schema,
 		Format:    rs.Format,
-		rows:      rs.Rows,
+		rows:      rows,
 	}
 }
 
 func (c *Client) submitStatement(ctx context.Context, request *statementRequest) (*statementResponse, error) {
-	req, err := url.Parse(c.config.Endpoint + "/v1/statements")
+	return withPriorityFallback(c, request.Priority, func(priority Priority) (*statementResponse, error) {
+		r := *request
+		r.Priority = priority
+		return c.doSubmitStatement(ctx, &r)
+	})
+}
+
+func (c *Client) doSubmitStatement(ctx context.Context, request *statementRequest) (*statementResponse, error) {
+	req, err := c.endpointURL("v1", "statements")
 	if err != nil {
 		return nil, err
 	}
@@ -230,14 +589,41 @@ func (c *Client) submitStatement(ctx context.Context, request *statementRequest)
 	return checkStatementResponse(resp)
 }
 
-func (c *Client) fetchStatementResult(ctx context.Context, id uuid.UUID, format ResultFormat) (*statementResponse, error) {
-	req, err := url.Parse(c.config.Endpoint + "/v1/statements/" + id.String())
+// endpointURL returns the URL of the API path elem under the endpoint,
+// preserving its path prefix and query parameters.
+func (c *Client) endpointURL(elem ...string) (*url.URL, error) {
+	if c.endpointErr != nil {
+		return nil, c.endpointErr
+	}
+	u := c.endpoint.JoinPath(elem...)
+	// JoinPath leaves the path relative for endpoints without a path.
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	return u, nil
+}
+
+// resultPage is a range of rows to fetch from a statement result.
+type resultPage struct {
+	Offset uint64
+	Limit  uint64
+}
+
+func (c *Client) fetchStatementResult(ctx context.Context, id uuid.UUID, format ResultFormat, page *resultPage, waitTimeout time.Duration) (*statementResponse, error) {
+	req, err := c.endpointURL("v1", "statements", id.String())
 	if err != nil {
 		return nil, err
 	}
 
 	q := req.Query()
 	q.Add("format", string(format))
+	if page != nil {
+		q.Add("offset", strconv.FormatUint(page.Offset, 10))
+		q.Add("limit", strconv.FormatUint(page.Limit, 10))
+	}
+	if waitTimeout > 0 {
+		q.Add("wait_timeout", formatTimeout(waitTimeout))
+	}
 	req.RawQuery = q.Encode()
 
 	resp, err := c.http.doGet(ctx, req)
@@ -245,16 +631,21 @@ func (c *Client) fetchStatementResult(ctx context.Context, id uuid.UUID, format
 		return nil, err
 	}
 	defer sneakyBodyClose(resp.Body)
-	return checkStatementResponse(resp)
+	stmtResp, err := checkStatementResponse(resp)
+	if err != nil {
+		return nil, statementGone(id, err)
+	}
+	return stmtResp, nil
 }
 
 type statementCancelResponse struct {
 	Status  StatementStatus `json:"status"`
 	Message string          `json:"message"`
+	Created time.Time       `json:"created_at"`
 }
 
 func (c *Client) cancelStatement(ctx context.Context, statementID uuid.UUID) (*statementCancelResponse, error) {
-	req, err := url.Parse(c.config.Endpoint + "/v1/statements/" + statementID.String() + "/cancel")
+	req, err := c.endpointURL("v1", "statements", statementID.String(), "cancel")
 	if err != nil {
 		return nil, err
 	}
@@ -283,6 +674,8 @@ type ingestRequest struct {
 	Data      ingestData `json:"data"`
 	Type      writeType  `json:"type"`
 	Statement string     `json:"statement"`
+	NodeGroup string     `json:"nodegroup,omitempty"`
+	Priority  Priority   `json:"priority,omitempty"`
 }
 
 type ingestData struct {
@@ -297,7 +690,15 @@ type ingestResponse struct {
 }
 
 func (c *Client) ingest(ctx context.Context, request *ingestRequest) (*ingestResponse, error) {
-	req, err := url.Parse(c.config.Endpoint + "/v1/ingest")
+	return withPriorityFallback(c, request.Priority, func(priority Priority) (*ingestResponse, error) {
+		r := *request
+		r.Priority = priority
+		return c.doIngest(ctx, &r)
+	})
+}
+
+func (c *Client) doIngest(ctx context.Context, request *ingestRequest) (*ingestResponse, error) {
+	req, err := c.endpointURL("v1", "ingest")
 	if err != nil {
 		return nil, err
 	}
@@ -20,13 +20,20 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
 )
@@ -100,6 +107,113 @@ func TestHTTPClientDoPostRejectsUnsupportedCompression(t *testing.T) {
 	require.ErrorContains(t, err, `unsupported compression: "brotli"`)
 }
 
+func TestHTTPClientDoPostRejectsTooLargeRequest(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL, MaxRequestBytes: 64})
+	defer c.Close()
+
+	ctx := context.Background()
+	err := c.Ingest(ctx, "SELECT $0 INSERT INTO t (v)", strings.Repeat("x", 64))
+	var tooLarge *RequestTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	require.Equal(t, int64(64), tooLarge.Limit)
+	require.Greater(t, tooLarge.Size, int64(64))
+	require.Equal(t, "ingest", err.(*RequestError).Operation)
+	require.Empty(t, requests())
+
+	_, err = c.Execute(ctx, "SELECT 1")
+	require.NoError(t, err)
+
+	require.Equal(t, int64(defaultMaxRequestBytes), NewClient(&Config{}).http.maxRequestBytes)
+	require.Zero(t, NewClient(&Config{MaxRequestBytes: -1}).http.maxRequestBytes)
+}
+
+// recordedRequest is a POST request recorded by newRecordingTestServer.
+type recordedRequest struct {
+	Path string
+	Body map[string]any
+}
+
+// newRecordingTestServer records the decoded JSON bodies of POST requests,
+// finishes every statement with an empty result, and accepts every ingest.
+func newRecordingTestServer(t *testing.T) (*httptest.Server, func() []recordedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, err := decodeCompressedRequestBody(r)
+			require.NoError(t, err)
+			var decoded map[string]any
+			require.NoError(t, json.Unmarshal(body, &decoded))
+			mu.Lock()
+			requests = append(requests, recordedRequest{Path: r.URL.Path, Body: decoded})
+			mu.Unlock()
+		}
+
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+// writeEmptyResponse finishes a statement with an empty result, or accepts an ingest.
+func writeEmptyResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/v1/ingest" {
+		_ = json.NewEncoder(w).Encode(map[string]any{"num_rows_inserted": 1})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"statement_id": uuid.NewString(),
+		"status":       StatementStatusFinished,
+		"created_at":   "2026-01-01T00:00:00Z",
+		"progress":     map[string]any{},
+		"result_set": map[string]any{
+			"metadata": map[string]any{"fields": []any{}, "num_rows": 0},
+			"format":   "json",
+			"rows":     []any{},
+		},
+	})
+}
+
+func TestNodeGroup(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	s := c.Statement("VALUES (1)")
+	s.NodeGroup = "interactive"
+	_, err := s.Execute(ctx)
+	require.NoError(t, err)
+	_, err = c.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.NodeGroup = "ingest"
+	cable.BatchInterval = time.Millisecond
+	require.NoError(t, cable.Start(ctx))
+	require.NoError(t, <-cable.Send(map[string]int{"v": 1}))
+	cable.Close()
+
+	recorded := requests()
+	require.Len(t, recorded, 3)
+	require.Equal(t, "interactive", recorded[0].Body["nodegroup"])
+	require.NotContains(t, recorded[1].Body, "nodegroup")
+	require.Equal(t, "/v1/ingest", recorded[2].Path)
+	require.Equal(t, "ingest", recorded[2].Body["nodegroup"])
+}
+
 func decodeCompressedRequestBody(r *http.Request) ([]byte, error) {
 	compressedBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -125,3 +239,208 @@ func decodeCompressedRequestBody(r *http.Request) ([]byte, error) {
 		return nil, io.ErrUnexpectedEOF
 	}
 }
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestIDs = append(requestIDs, r.Header.Get("X-Request-ID"))
+		mu.Unlock()
+		if r.URL.Path == "/v1/ingest" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"table not found"}`))
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	h, err := c.Statement("VALUES (1)").Submit(ctx)
+	require.NoError(t, err)
+	err = c.Ingest(ctx, "SELECT $0 INSERT INTO t (v)", 1)
+	var serverErr *Error
+	require.ErrorAs(t, err, &serverErr)
+
+	mu.Lock()
+	require.Len(t, requestIDs, 2)
+	require.NotEqual(t, requestIDs[0], requestIDs[1])
+	require.Equal(t, requestIDs[0], h.RequestID())
+	require.Equal(t, requestIDs[1], RequestIDOf(err))
+	mu.Unlock()
+	require.EqualError(t, err, "ingest: status 400: table not found (request id: "+requestIDs[1]+")")
+
+	server.Close()
+	_, err = c.Statement("VALUES (1)").Submit(ctx)
+	require.Error(t, err)
+	_, parseErr := uuid.Parse(RequestIDOf(err))
+	require.NoError(t, parseErr)
+	require.Empty(t, RequestIDOf(ErrNoRows))
+}
+
+func TestRequestDeadlineHeader(t *testing.T) {
+	t.Parallel()
+
+	headers := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get("X-ScopeDB-Request-Deadline-Millis")
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, c.Ingest(ctx, "SELECT $0 INSERT INTO t (v)", 1))
+	millis, err := strconv.ParseInt(<-headers, 10, 64)
+	require.NoError(t, err)
+	require.InDelta(t, 30000, millis, 1000)
+
+	_, err = c.StatementHandle(uuid.New()).Fetch(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, <-headers)
+}
+
+func TestResponseError(t *testing.T) {
+	t.Parallel()
+
+	html := "<html><body>" + strings.Repeat("Bad Gateway ", 100) + "</body></html>"
+	for _, tc := range []struct {
+		name      string
+		path      string
+		status    int
+		body      string
+		operation string
+		message   string
+		excerpt   string
+	}{
+		{
+			name:      "json",
+			path:      "/v1/statements",
+			status:    http.StatusBadRequest,
+			body:      `{"message":"syntax error"}`,
+			operation: "submit statement",
+			message:   "syntax error",
+			excerpt:   `{"message":"syntax error"}`,
+		},
+		{
+			name:      "html",
+			path:      "/v1/ingest",
+			status:    http.StatusBadGateway,
+			body:      html,
+			operation: "ingest",
+			message:   html[:maxBodyExcerpt] + "...",
+			excerpt:   html[:maxBodyExcerpt] + "...",
+		},
+		{
+			name:      "empty",
+			path:      "/v1/statements/" + uuid.NewString() + "/cancel",
+			status:    http.StatusServiceUnavailable,
+			operation: "cancel statement",
+			message:   "Service Unavailable",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.Header.Set(requestIDHeader, "id")
+			resp := &http.Response{
+				StatusCode: tc.status,
+				Body:       io.NopCloser(strings.NewReader(tc.body)),
+				Request:    req,
+			}
+			var err error
+			switch tc.operation {
+			case "ingest":
+				_, err = checkIngestResponse(resp)
+			case "cancel statement":
+				_, err = checkStatementCancelResponse(resp)
+			default:
+				_, err = checkStatementResponse(resp)
+			}
+
+			var reqErr *RequestError
+			require.ErrorAs(t, err, &reqErr)
+			require.Equal(t, tc.operation, reqErr.Operation)
+			require.Equal(t, tc.status, reqErr.StatusCode)
+			require.Equal(t, tc.status, StatusCodeOf(err))
+			require.Equal(t, tc.excerpt, reqErr.Body)
+			require.Equal(t, tc.message, reqErr.Err.Error())
+			require.Equal(t, fmt.Sprintf("%s: status %d: %s (request id: id)", tc.operation, tc.status, tc.message), err.Error())
+		})
+	}
+
+	require.Zero(t, StatusCodeOf(ErrNoRows))
+}
+
+func TestEndpointURL(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.MustParse("c8fe71d6-3695-11f0-85b3-063c3400fda9")
+	for _, tc := range []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "http://localhost:6543", want: "http://localhost:6543/v1/statements/" + id.String()},
+		{endpoint: "http://localhost:6543/", want: "http://localhost:6543/v1/statements/" + id.String()},
+		{endpoint: "https://gateway.example.com/scopedb", want: "https://gateway.example.com/scopedb/v1/statements/" + id.String()},
+		{endpoint: "https://gateway.example.com/scopedb/", want: "https://gateway.example.com/scopedb/v1/statements/" + id.String()},
+		{endpoint: "https://gateway.example.com:8443/scopedb/?tenant=a", want: "https://gateway.example.com:8443/scopedb/v1/statements/" + id.String() + "?tenant=a"},
+	} {
+		u, err := NewClient(&Config{Endpoint: tc.endpoint}).endpointURL("v1", "statements", id.String())
+		require.NoError(t, err, tc.endpoint)
+		require.Equal(t, tc.want, u.String(), tc.endpoint)
+	}
+
+	_, err := NewClient(&Config{Endpoint: "http://[::1"}).Statement("VALUES (1)").Execute(context.Background())
+	require.ErrorContains(t, err, "missing ']' in host")
+}
+
+func TestEndpointPathPrefix(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery)
+		mu.Unlock()
+		if r.URL.Path == "/scopedb/v1/ingest" {
+			writeEmptyResponse(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"statement_id": "c8fe71d6-3695-11f0-85b3-063c3400fda9",
+			"status":       StatementStatusRunning,
+			"created_at":   "2026-01-01T00:00:00Z",
+			"progress":     map[string]any{},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewClient(&Config{Endpoint: server.URL + "/scopedb/?tenant=a"})
+	ctx := context.Background()
+	h, err := c.Statement("VALUES (1)").Submit(ctx)
+	require.NoError(t, err)
+	require.NoError(t, h.FetchOnce(ctx))
+	_, err = h.Cancel(ctx)
+	require.NoError(t, err)
+	_, err = c.ingest(ctx, &ingestRequest{Statement: "INSERT INTO t"})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{
+		"/scopedb/v1/statements?tenant=a",
+		"/scopedb/v1/statements/c8fe71d6-3695-11f0-85b3-063c3400fda9?format=json&tenant=a",
+		"/scopedb/v1/statements/c8fe71d6-3695-11f0-85b3-063c3400fda9/cancel?tenant=a",
+		"/scopedb/v1/ingest?tenant=a",
+	}, paths)
+}
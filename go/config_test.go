@@ -0,0 +1,70 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigStatementDefaults(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(&Config{})
+	s := c.Statement("VALUES (1)")
+	require.Equal(t, ResultFormatJSON, s.ResultFormat)
+	require.Empty(t, s.ExecTimeout)
+	require.Zero(t, s.WaitTimeout)
+	require.Equal(t, ResultFormatJSON, c.StatementHandle(uuid.New()).Format)
+	require.Zero(t, c.StatementHandle(uuid.New()).WaitTimeout)
+
+	c = NewClient(&Config{DefaultResultFormat: ResultFormatJSON, DefaultExecTimeout: "PT30S", DefaultWaitTimeout: 10 * time.Second})
+	s = c.Statement("VALUES (1)")
+	require.Equal(t, ResultFormatJSON, s.ResultFormat)
+	require.Equal(t, "PT30S", s.ExecTimeout)
+	require.Equal(t, 10*time.Second, s.WaitTimeout)
+	require.Equal(t, 10*time.Second, c.StatementHandle(uuid.New()).WaitTimeout)
+	s.ExecTimeout = "1h"
+	require.Equal(t, "1h", s.ExecTimeout)
+	s.WaitTimeout = time.Second
+	require.Equal(t, time.Second, s.WaitTimeout)
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, timeout := range []string{"1h", "1h30m", "500ms", "PT1S", "P1DT2H", "PT0.5S"} {
+		require.NoError(t, (&Config{DefaultExecTimeout: timeout}).Validate(), timeout)
+	}
+	for _, timeout := range []string{"1 hour", "P", "PT", "-1s", "0s", "1d"} {
+		require.Error(t, (&Config{DefaultExecTimeout: timeout}).Validate(), timeout)
+	}
+	require.EqualError(t, (&Config{DefaultResultFormat: "arrow"}).Validate(), `invalid default result format: "arrow"`)
+	require.EqualError(t, (&Config{MaxConcurrentRequests: -1}).Validate(), "invalid max concurrent requests: -1")
+	require.NoError(t, (&Config{DefaultWaitTimeout: 10 * time.Second}).Validate())
+	require.EqualError(t, (&Config{DefaultWaitTimeout: -time.Second}).Validate(), "invalid default wait timeout: -1s")
+
+	c := NewClient(&Config{DefaultExecTimeout: "1 hour"})
+	_, err := c.Statement("VALUES (1)").Execute(context.Background())
+	require.EqualError(t, err, `invalid default exec timeout: "1 hour" is not a duration like "1h30m" or "PT1S"`)
+	_, err = c.Statementf("VALUES (?)", 1).Execute(context.Background())
+	require.ErrorContains(t, err, "invalid default exec timeout")
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+var ratPtrType = reflect.TypeFor[*big.Rat]()
+
+// convertAssign stores the converted cell value src into dst.
+//
+// The conversion follows the values produced by ResultSet.ToValues. Cells of
+// array, object, and any types are JSON strings, which are unmarshaled when
+// dst is not a string.
+func convertAssign(dst reflect.Value, src Value, fs *FieldSchema) error {
+	if dst.CanAddr() {
+		switch scanner := dst.Addr().Interface().(type) {
+		case cellScanner:
+			return scanner.scanCell(src, fs)
+		case sql.Scanner:
+			return scanSQL(scanner, src, fs)
+		default:
+		}
+	}
+
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Pointer && dst.Type() != ratPtrType {
+		elem := reflect.New(dst.Type().Elem())
+		if err := convertAssign(elem.Elem(), src, fs); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	switch fs.Type {
+	case ArrayDataType, ObjectDataType, AnyDataType:
+		if s, ok := src.(string); ok && dst.Kind() != reflect.String && dst.Kind() != reflect.Interface {
+			return unmarshalUseNumber([]byte(s), dst.Addr().Interface())
+		}
+	default:
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+
+	switch v := src.(type) {
+	case int64:
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if dst.OverflowInt(v) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+			dst.SetInt(v)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v < 0 || dst.OverflowUint(uint64(v)) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+			dst.SetUint(uint64(v))
+			return nil
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(float64(v))
+			return nil
+		default:
+		}
+	case uint64:
+		switch dst.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if dst.OverflowUint(v) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+			dst.SetUint(v)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v > 1<<63-1 || dst.OverflowInt(int64(v)) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+			dst.SetInt(int64(v))
+			return nil
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(float64(v))
+			return nil
+		default:
+		}
+	case float64:
+		if dst.Kind() == reflect.Float32 || dst.Kind() == reflect.Float64 {
+			dst.SetFloat(v)
+			return nil
+		}
+	case time.Duration:
+		if dst.Kind() == reflect.Int64 {
+			dst.SetInt(int64(v))
+			return nil
+		}
+	case *big.Rat:
+		switch dst.Kind() {
+		case reflect.String:
+			dst.SetString(v.FloatString(fs.Scale))
+			return nil
+		case reflect.Float32, reflect.Float64:
+			f, _ := v.Float64()
+			dst.SetFloat(f)
+			return nil
+		default:
+		}
+	case string:
+		if dst.Kind() == reflect.String {
+			dst.SetString(v)
+			return nil
+		}
+	default:
+	}
+
+	if sv.Type().ConvertibleTo(dst.Type()) && sv.Kind() == dst.Kind() {
+		dst.Set(sv.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot convert %s to %s", sv.Type(), dst.Type())
+}
+
+// unmarshalUseNumber is json.Unmarshal, except that numbers stored in
+// interface values are json.Number rather than float64, so that integers
+// beyond 2^53 keep their precision.
+func unmarshalUseNumber(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
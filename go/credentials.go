@@ -0,0 +1,106 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+)
+
+// Credentials authenticate requests; see WithCredentials.
+type Credentials struct {
+	// APIKey, if set, is sent as the Authorization header using the Bearer
+	// scheme.
+	APIKey string
+	// Username and Password, if Username is set and APIKey is not, are sent
+	// as the Authorization header using the Basic scheme.
+	Username string
+	Password string
+}
+
+// authorization returns the Authorization header of the credentials, or an
+// empty string if there are none.
+func (cred Credentials) authorization() string {
+	switch {
+	case cred.APIKey != "":
+		return "Bearer " + cred.APIKey
+	case cred.Username != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password))
+	default:
+		return ""
+	}
+}
+
+type credentialsKey struct{}
+
+type headersKey struct{}
+
+// WithCredentials returns a context whose requests are authenticated with
+// cred instead of the credentials of the client, e.g., to query on behalf of
+// different tenants with one client. Zero credentials send no Authorization
+// header.
+//
+// The context applies to every request made with it, e.g., by
+// Statement.Execute and the fetches of the statement, or by Client.Ingest.
+// Cables ingest with the context passed to DataCable.Start, unless
+// DataCable.FlushContext overrides it.
+func WithCredentials(ctx context.Context, cred Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, cred)
+}
+
+// WithHeader returns a context whose requests carry the header key with
+// value, in addition to the headers of ctx, e.g., to route requests to a
+// tenant at a proxy.
+//
+// Headers set by the client itself, such as Authorization, Content-Type, and
+// X-Request-ID, take precedence; use WithCredentials to override the
+// Authorization header. See WithCredentials for which requests use the
+// context.
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	header, _ := ctx.Value(headersKey{}).(http.Header)
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Add(key, value)
+	return context.WithValue(ctx, headersKey{}, header)
+}
+
+// applyContext sets the headers and credentials of the request context on req.
+// It returns false if the context has no credentials.
+func applyContext(req *http.Request) bool {
+	ctx := req.Context()
+	if header, ok := ctx.Value(headersKey{}).(http.Header); ok {
+		for key, values := range header {
+			if _, ok := req.Header[key]; !ok {
+				req.Header[key] = values
+			}
+		}
+	}
+
+	cred, ok := ctx.Value(credentialsKey{}).(Credentials)
+	if !ok {
+		return false
+	}
+	if auth := cred.authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	} else {
+		req.Header.Del("Authorization")
+	}
+	return true
+}
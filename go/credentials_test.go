@@ -0,0 +1,70 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCredentials(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.URL.Path+" "+r.Header.Get("Authorization")+" "+r.Header.Get("X-Tenant")+" "+r.Header.Get("Content-Type"))
+		mu.Unlock()
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient(&Config{Endpoint: server.URL, APIKey: "default"})
+
+	ctx := context.Background()
+	tenant := WithCredentials(ctx, Credentials{APIKey: "tenant-a"})
+	tenant = WithHeader(tenant, "X-Tenant", "a")
+	tenant = WithHeader(tenant, "Content-Type", "text/plain")
+	_, err := c.Statement("VALUES (1)").Execute(tenant)
+	require.NoError(t, err)
+	require.NoError(t, c.Ingest(WithCredentials(ctx, Credentials{Username: "b", Password: "pw"}), "INSERT INTO t", 1))
+	require.NoError(t, c.Ingest(WithCredentials(ctx, Credentials{}), "INSERT INTO t", 1))
+	require.NoError(t, c.Ingest(ctx, "INSERT INTO t", 1))
+
+	cable := c.DataCable("INSERT INTO t")
+	cable.FlushContext = func(ctx context.Context) context.Context {
+		return WithCredentials(ctx, Credentials{APIKey: "cable"})
+	}
+	require.NoError(t, cable.Start(ctx))
+	require.NoError(t, <-cable.Send(1))
+	cable.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{
+		"/v1/statements Bearer tenant-a a application/json",
+		"/v1/ingest Basic Yjpwdw==  application/json",
+		"/v1/ingest   application/json",
+		"/v1/ingest Bearer default  application/json",
+		"/v1/ingest Bearer cable  application/json",
+	}, seen)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CSVWriteOptions configures ResultSet.WriteCSV.
+type CSVWriteOptions struct {
+	// Delimiter is the field delimiter. The default is ','; use '\t' for TSV.
+	Delimiter rune
+	// SkipHeader omits the header row of column names.
+	SkipHeader bool
+	// NullString is written for NULL cells. The default is an empty string.
+	NullString string
+	// TimestampLayout is the time.Format layout for timestamp cells.
+	//
+	// If empty, timestamps are written as returned by ScopeDB.
+	TimestampLayout string
+	// UseCRLF uses \r\n as the line terminator.
+	UseCRLF bool
+}
+
+// WriteCSV writes the result set to w as CSV, quoting fields as described in RFC 4180.
+//
+// Rows are decoded and written one at a time, so the converted result set
+// never needs to fit in memory.
+//
+// This method is only valid if the result set is of the JSON format.
+func (rs *ResultSet) WriteCSV(w io.Writer, opts CSVWriteOptions) error {
+	if rs.Format != ResultFormatJSON {
+		return fmt.Errorf("unexpected result set format: %s", rs.Format)
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		cw.Comma = opts.Delimiter
+	}
+	cw.UseCRLF = opts.UseCRLF
+
+	record := make([]string, len(rs.Schema))
+	if !opts.SkipHeader {
+		for i, fs := range rs.Schema {
+			record[i] = fs.Name
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if err := rs.scanRows(func(row []*string) error {
+		for i, v := range row {
+			switch {
+			case v == nil:
+				record[i] = opts.NullString
+			case opts.TimestampLayout != "" && rs.Schema[i].Type == TimestampDataType:
+				ts, err := time.Parse(time.RFC3339Nano, *v)
+				if err != nil {
+					return err
+				}
+				record[i] = ts.Format(opts.TimestampLayout)
+			default:
+				record[i] = *v
+			}
+		}
+		return cw.Write(record)
+	}); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/require"
+)
+
+func csvTestResultSet(t *testing.T) *ResultSet {
+	t.Helper()
+
+	rows, err := json.Marshal([][]*string{
+		{ptr("1970-01-01T00:00:00Z"), ptr("plain"), ptr("1")},
+		{ptr("1970-01-01T00:00:01.5Z"), ptr(`with "quotes", and commas`), nil},
+		{nil, ptr("multi\nline\ttabbed"), ptr("-3")},
+	})
+	require.NoError(t, err)
+
+	return &ResultSet{
+		TotalRows: 3,
+		Schema: Schema{
+			newFieldSchema("ts", "timestamp"),
+			newFieldSchema("s", "string"),
+			newFieldSchema("i", "int"),
+		},
+		Format: ResultFormatJSON,
+		rows:   rows,
+	}
+}
+
+func TestResultSetWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	rs := csvTestResultSet(t)
+
+	var b bytes.Buffer
+	require.NoError(t, rs.WriteCSV(&b, CSVWriteOptions{}))
+	snaps.MatchSnapshot(t, b.String())
+
+	b.Reset()
+	require.NoError(t, rs.WriteCSV(&b, CSVWriteOptions{
+		Delimiter:       '\t',
+		SkipHeader:      true,
+		NullString:      `\N`,
+		TimestampLayout: time.DateTime,
+	}))
+	snaps.MatchSnapshot(t, b.String())
+}
+
+func TestResultSetWriteCSVEmpty(t *testing.T) {
+	t.Parallel()
+
+	rs := csvTestResultSet(t)
+	rs.rows = json.RawMessage(`[]`)
+
+	var b bytes.Buffer
+	require.NoError(t, rs.WriteCSV(&b, CSVWriteOptions{}))
+	require.Equal(t, "ts,s,i\n", b.String())
+}
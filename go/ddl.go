@@ -0,0 +1,287 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Column describes a column to create.
+type Column struct {
+	// Name is the column name.
+	Name string
+	// Type is the column data type, e.g., IntDataType or "array(int)".
+	Type DataType
+}
+
+// Columns is a list of columns to create.
+type Columns []Column
+
+// CreateOptions configures Table.Create.
+type CreateOptions struct {
+	// IfNotExists makes the creation a no-op if the table already exists.
+	IfNotExists bool
+	// Comment is the table comment.
+	Comment string
+	// RawTypes skips the validation of column types so that types unknown to
+	// this SDK can be used as is.
+	RawTypes bool
+}
+
+// Create creates the table with the given columns.
+//
+// This method issues a CREATE TABLE statement generated by CreateDDL to
+// ScopeDB and blocks until done.
+func (t *Table) Create(ctx context.Context, columns Columns, opts CreateOptions) error {
+	ddl, err := t.CreateDDL(columns, opts)
+	if err != nil {
+		return err
+	}
+	_, err = t.c.Statement(ddl).Execute(ctx)
+	return err
+}
+
+// CreateDDL returns the CREATE TABLE statement that Create issues, e.g., for logging.
+//
+// Column names are quoted with QuoteIdent, and column types are validated
+// against the known data types unless opts.RawTypes is set.
+func (t *Table) CreateDDL(columns Columns, opts CreateOptions) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("table %s must have at least one column", t.Identifier())
+	}
+
+	defs := make([]string, len(columns))
+	for i, column := range columns {
+		if column.Name == "" {
+			return "", fmt.Errorf("column %d has an empty name", i)
+		}
+		if !opts.RawTypes {
+			if err := validateDataType(column.Type); err != nil {
+				return "", fmt.Errorf("column %q: %w", column.Name, err)
+			}
+		}
+		defs[i] = QuoteIdent(column.Name) + " " + string(column.Type)
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE TABLE ")
+	if opts.IfNotExists {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(t.Identifier())
+	b.WriteString(" (")
+	b.WriteString(strings.Join(defs, ", "))
+	b.WriteString(")")
+	if opts.Comment != "" {
+		b.WriteString(" COMMENT = ")
+		b.WriteString(QuoteString(opts.Comment))
+	}
+	return b.String(), nil
+}
+
+// EnsureOptions configures Table.EnsureSchema.
+type EnsureOptions struct {
+	// AllowAdd allows adding the columns that are missing from the table.
+	AllowAdd bool
+}
+
+// EnsureSchema evolves the table towards the desired schema in an
+// append-only way and returns the changes applied.
+//
+// The desired schema is compared against TableSchema with Schema.Diff.
+// Missing columns are added with AddColumn if opts.AllowAdd is set. Columns
+// with a different type and columns not in the desired schema are never
+// applied; if any exist, or if columns are missing without AllowAdd, a
+// *SchemaMismatchError listing them is returned before anything is altered.
+// Column order is ignored.
+//
+// Adding a column that a concurrent caller has just added is treated as
+// success, but the change is not included in the result.
+func (t *Table) EnsureSchema(ctx context.Context, desired Schema, opts EnsureOptions) ([]SchemaChange, error) {
+	current, err := t.TableSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) == 0 {
+		return nil, fmt.Errorf("table %s not found", t.Identifier())
+	}
+
+	var additions, conflicts []SchemaChange
+	for _, change := range current.Diff(desired) {
+		switch change.Kind {
+		case ColumnAdded:
+			if opts.AllowAdd {
+				additions = append(additions, change)
+			} else {
+				conflicts = append(conflicts, change)
+			}
+		case ColumnRemoved, ColumnTypeChanged:
+			conflicts = append(conflicts, change)
+		case ColumnReordered:
+		default:
+			conflicts = append(conflicts, change)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &SchemaMismatchError{Table: t.Identifier(), Changes: conflicts}
+	}
+
+	var applied []SchemaChange
+	for _, change := range additions {
+		err := t.AddColumn(ctx, change.Column, change.NewType, AddColumnOptions{RawTypes: true})
+		if isAlreadyExists(err) {
+			continue
+		}
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, change)
+	}
+	return applied, nil
+}
+
+// isAlreadyExists reports whether err is a server error about an object that
+// already exists.
+func isAlreadyExists(err error) bool {
+	var serverErr *Error
+	return errors.As(err, &serverErr) && strings.Contains(strings.ToLower(serverErr.Message), "already exists")
+}
+
+// ShowCreate returns a CREATE TABLE statement that recreates the table with
+// its columns in order, their types, and the table comment.
+//
+// The statement is reconstructed from TableSchema and Comment, so it issues
+// meta queries to ScopeDB and blocks until their results are fetched. Column
+// types are taken as reported by ScopeDB without validation.
+func (t *Table) ShowCreate(ctx context.Context) (string, error) {
+	schema, err := t.TableSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(schema) == 0 {
+		return "", fmt.Errorf("table %s not found", t.Identifier())
+	}
+	comment, err := t.Comment(ctx)
+	if err != nil {
+		return "", err
+	}
+	return t.CreateDDL(schema.Columns(), CreateOptions{Comment: comment, RawTypes: true})
+}
+
+// DropOptions configures Table.Drop.
+type DropOptions struct {
+	// IfExists makes the drop a no-op if the table does not exist.
+	IfExists bool
+}
+
+// AddColumnOptions configures Table.AddColumn.
+type AddColumnOptions struct {
+	// IfNotExists makes the addition a no-op if a column with the same name
+	// already exists, regardless of its type.
+	IfNotExists bool
+	// RawTypes skips the validation of the column type.
+	RawTypes bool
+}
+
+// AddColumn adds a column to the table.
+//
+// This method issues an ALTER TABLE ADD COLUMN statement to ScopeDB and blocks
+// until done. With IfNotExists, the current schema is fetched with TableSchema
+// first so that migrations can be rerun. At most one AddColumnOptions may be given.
+func (t *Table) AddColumn(ctx context.Context, name string, typ DataType, opts ...AddColumnOptions) error {
+	var opt AddColumnOptions
+	for _, o := range opts {
+		opt = o
+	}
+
+	if name == "" {
+		return fmt.Errorf("column name must not be empty")
+	}
+	if !opt.RawTypes {
+		if err := validateDataType(typ); err != nil {
+			return fmt.Errorf("column %q: %w", name, err)
+		}
+	}
+
+	if opt.IfNotExists {
+		schema, err := t.TableSchema(ctx)
+		if err != nil {
+			return err
+		}
+		for _, field := range schema {
+			if field.Name == name {
+				return nil
+			}
+		}
+	}
+
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", t.Identifier(), QuoteIdent(name), typ)
+	_, err := t.c.Statement(stmt).Execute(ctx)
+	return err
+}
+
+// DropColumn drops a column from the table.
+//
+// This method issues an ALTER TABLE DROP COLUMN statement to ScopeDB and blocks until done.
+func (t *Table) DropColumn(ctx context.Context, name string) error {
+	stmt := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.Identifier(), QuoteIdent(name))
+	_, err := t.c.Statement(stmt).Execute(ctx)
+	return err
+}
+
+// RenameColumn renames a column of the table.
+//
+// This method issues an ALTER TABLE RENAME COLUMN statement to ScopeDB and blocks until done.
+func (t *Table) RenameColumn(ctx context.Context, oldName, newName string) error {
+	if newName == "" {
+		return fmt.Errorf("column name must not be empty")
+	}
+	stmt := fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
+		t.Identifier(), QuoteIdent(oldName), QuoteIdent(newName))
+	_, err := t.c.Statement(stmt).Execute(ctx)
+	return err
+}
+
+// validateDataType checks that typ and its nested types are known data types.
+func validateDataType(typ DataType) error {
+	return validateTypeInfo(ParseTypeInfo(string(typ)))
+}
+
+func validateTypeInfo(info *TypeInfo) error {
+	switch info.Kind {
+	case StringDataType, IntDataType, UIntDataType, FloatDataType, BooleanDataType,
+		TimestampDataType, IntervalDataType, AnyDataType, DecimalDataType, BinaryDataType:
+		return nil
+	case ArrayDataType:
+		if info.Elem != nil {
+			return validateTypeInfo(info.Elem)
+		}
+		return nil
+	case ObjectDataType:
+		for _, field := range info.Fields {
+			if err := validateTypeInfo(field.TypeInfo); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown data type: %q", info.Raw)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableCreateDDL(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(&Config{})
+	tbl := c.Table("events")
+
+	ddl, err := tbl.CreateDDL(Columns{
+		{"ts", TimestampDataType},
+		{"my col", "array(int)"},
+		{"d", "decimal(38,10)"},
+		{"v", AnyDataType},
+	}, CreateOptions{IfNotExists: true, Comment: "it's events"})
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE IF NOT EXISTS `events` (`ts` timestamp, `my col` array(int), `d` decimal(38,10), `v` any) COMMENT = 'it\\'s events'", ddl)
+
+	_, err = tbl.CreateDDL(Columns{{"v", "variant"}}, CreateOptions{})
+	require.EqualError(t, err, `column "v": unknown data type: "variant"`)
+	_, err = tbl.CreateDDL(Columns{{"v", "array(variant)"}}, CreateOptions{})
+	require.EqualError(t, err, `column "v": unknown data type: "variant"`)
+
+	ddl, err = tbl.CreateDDL(Columns{{"v", "variant"}}, CreateOptions{RawTypes: true})
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE `events` (`v` variant)", ddl)
+
+	_, err = tbl.CreateDDL(nil, CreateOptions{})
+	require.EqualError(t, err, "table `events` must have at least one column")
+}
+
+func TestTableAlterColumns(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var stmts []string
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		mu.Lock()
+		defer mu.Unlock()
+		stmts = append(stmts, stmt)
+		if strings.Contains(stmt, "system.columns") {
+			return []resultSetField{
+				{Name: "column_name", DataType: "string"},
+				{Name: "data_type", DataType: "string"},
+			}, [][]*string{{ptr("ts"), ptr("timestamp")}}
+		}
+		return nil, nil
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table("events")
+	require.NoError(t, tbl.AddColumn(ctx, "my col", "array(int)"))
+	require.NoError(t, tbl.AddColumn(ctx, "ts", TimestampDataType, AddColumnOptions{IfNotExists: true}))
+	require.NoError(t, tbl.AddColumn(ctx, "v", AnyDataType, AddColumnOptions{IfNotExists: true}))
+	require.NoError(t, tbl.DropColumn(ctx, "a`b"))
+	require.NoError(t, tbl.RenameColumn(ctx, "old", "new"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	var alters []string
+	for _, stmt := range stmts {
+		if strings.HasPrefix(stmt, "ALTER") && !slices.Contains(alters, stmt) {
+			alters = append(alters, stmt)
+		}
+	}
+	require.Equal(t, []string{
+		"ALTER TABLE `events` ADD COLUMN `my col` array(int)",
+		"ALTER TABLE `events` ADD COLUMN `v` any",
+		"ALTER TABLE `events` DROP COLUMN `a\\`b`",
+		"ALTER TABLE `events` RENAME COLUMN `old` TO `new`",
+	}, alters)
+
+	require.EqualError(t, tbl.AddColumn(ctx, "v", "variant"), `column "v": unknown data type: "variant"`)
+	require.EqualError(t, tbl.AddColumn(ctx, "", IntDataType), "column name must not be empty")
+}
+
+func TestTableShowCreate(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		if strings.Contains(stmt, "system.columns") {
+			return []resultSetField{
+				{Name: "column_name", DataType: "string"},
+				{Name: "data_type", DataType: "string"},
+			}, [][]*string{{ptr("ts"), ptr("timestamp")}, {ptr("my v"), ptr("array(int)")}}
+		}
+		return []resultSetField{{Name: "comment", DataType: "string"}}, [][]*string{{ptr("it's events")}}
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ddl, err := c.Table("events").ShowCreate(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE `events` (`ts` timestamp, `my v` array(int)) COMMENT = 'it\\'s events'", ddl)
+
+	empty, _ := newResultTestServer(t, []resultSetField{{Name: "column_name", DataType: "string"}}, nil)
+	c = NewClient(&Config{Endpoint: empty.URL})
+	defer c.Close()
+	_, err = c.Table("events").ShowCreate(context.Background())
+	require.EqualError(t, err, "table `events` not found")
+}
+
+func TestTableEnsureSchema(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var alters []string
+	routing, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		if strings.HasPrefix(stmt, "ALTER") {
+			mu.Lock()
+			defer mu.Unlock()
+			if !slices.Contains(alters, stmt) {
+				alters = append(alters, stmt)
+			}
+			return nil, nil
+		}
+		return []resultSetField{
+			{Name: "column_name", DataType: "string"},
+			{Name: "data_type", DataType: "string"},
+		}, [][]*string{{ptr("ts"), ptr("timestamp")}, {ptr("n"), ptr("int")}}
+	})
+	// Fail adding the column `raced` as if a concurrent caller had added it.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		if r.Method == http.MethodPost {
+			clone := r.Clone(r.Context())
+			clone.Body = io.NopCloser(bytes.NewReader(raw))
+			body, err := decodeCompressedRequestBody(clone)
+			require.NoError(t, err)
+			if strings.Contains(string(body), "`raced`") {
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"statement_id": uuid.NewString(),
+					"status":       StatementStatusFailed,
+					"created_at":   "2026-01-01T00:00:00Z",
+					"progress":     map[string]any{},
+					"message":      "column raced already exists",
+				})
+				return
+			}
+		}
+		routing.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table("events")
+	desired := Schema{
+		newFieldSchema("n", "int"),
+		newFieldSchema("ts", "timestamp"),
+		newFieldSchema("v", "any"),
+		newFieldSchema("raced", "string"),
+	}
+
+	_, err := tbl.EnsureSchema(ctx, desired, EnsureOptions{})
+	var mismatch *SchemaMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	require.Len(t, mismatch.Changes, 2)
+	require.EqualError(t, err, "schema of table `events` does not match: add column `v` any; add column `raced` string")
+
+	applied, err := tbl.EnsureSchema(ctx, desired, EnsureOptions{AllowAdd: true})
+	require.NoError(t, err)
+	require.Equal(t, []SchemaChange{
+		{Kind: ColumnAdded, Column: "v", NewType: AnyDataType, OldIndex: -1, NewIndex: 2},
+	}, applied)
+	mu.Lock()
+	require.Equal(t, []string{"ALTER TABLE `events` ADD COLUMN `v` any"}, alters)
+	mu.Unlock()
+
+	_, err = tbl.EnsureSchema(ctx, Schema{newFieldSchema("ts", "string")}, EnsureOptions{AllowAdd: true})
+	require.ErrorAs(t, err, &mismatch)
+	require.Equal(t, []SchemaChange{
+		{Kind: ColumnRemoved, Column: "n", OldType: IntDataType, OldIndex: 1, NewIndex: -1},
+		{Kind: ColumnTypeChanged, Column: "ts", OldType: TimestampDataType, NewType: StringDataType, OldIndex: 0, NewIndex: 0},
+	}, mismatch.Changes)
+}
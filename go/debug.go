@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxDebugBodyBytes is the maximum length of a request body in debug logs.
+const maxDebugBodyBytes = 2048
+
+// sensitiveHeaders are the headers whose values are never logged.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+func debugLogger(config *Config) *slog.Logger {
+	if config == nil {
+		return nil
+	}
+	return config.DebugLogger
+}
+
+func statementScrubber(config *Config) func(string) string {
+	if config == nil {
+		return nil
+	}
+	return config.ScrubStatement
+}
+
+// logRequest logs a finished request at the debug level.
+func (c *httpClient) logRequest(req *http.Request, body []byte, resp *http.Response, err error, elapsed time.Duration) {
+	ctx := req.Context()
+	if !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.String("request_id", req.Header.Get(requestIDHeader)),
+		slog.Any("headers", redactHeaders(req.Header)),
+	}
+	if body != nil {
+		attrs = append(attrs, slog.String("body", c.debugBody(body)))
+	}
+	attrs = append(attrs, slog.Duration("elapsed", elapsed))
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		c.logger.LogAttrs(context.WithoutCancel(ctx), slog.LevelDebug, "scopedb request failed", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	c.logger.LogAttrs(context.WithoutCancel(ctx), slog.LevelDebug, "scopedb request", attrs...)
+}
+
+func redactHeaders(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range sensitiveHeaders {
+		if header.Get(key) != "" {
+			header.Set(key, "REDACTED")
+		}
+	}
+	return header
+}
+
+// debugBody renders a JSON request body for debug logs: ingested rows are
+// replaced with their length, statements are scrubbed, and the result is
+// truncated to maxDebugBodyBytes.
+func (c *httpClient) debugBody(body []byte) string {
+	var v map[string]any
+	if err := unmarshalUseNumber(body, &v); err != nil {
+		return truncateDebugBody(string(body))
+	}
+	if stmt, ok := v["statement"].(string); ok && c.scrubStatement != nil {
+		v["statement"] = c.scrubStatement(stmt)
+	}
+	if data, ok := v["data"].(map[string]any); ok {
+		if rows, ok := data["rows"].(string); ok {
+			data["rows"] = fmt.Sprintf("<%d bytes>", len(rows))
+		}
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return truncateDebugBody(string(body))
+	}
+	return truncateDebugBody(strings.TrimSuffix(b.String(), "\n"))
+}
+
+func truncateDebugBody(s string) string {
+	if len(s) <= maxDebugBodyBytes {
+		return s
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", s[:maxDebugBodyBytes], len(s)-maxDebugBodyBytes)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) lines(t *testing.T) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v))
+		lines = append(lines, v)
+	}
+	return lines
+}
+
+func TestDebugLogger(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newRecordingTestServer(t)
+	var logs syncBuffer
+	c := NewClient(&Config{
+		Endpoint:    server.URL,
+		APIKey:      "secret",
+		DebugLogger: slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		ScrubStatement: func(stmt string) string {
+			return strings.ReplaceAll(stmt, "'hunter2'", "'***'")
+		},
+	})
+	defer c.Close()
+
+	ctx := context.Background()
+	_, err := c.Execute(ctx, "SELECT 'hunter2'")
+	require.NoError(t, err)
+	require.NoError(t, c.Ingest(ctx, "SELECT $0 INSERT INTO t (v)", "secret row"))
+
+	lines := logs.lines(t)
+	require.Len(t, lines, 2)
+	require.Equal(t, "scopedb request", lines[0]["msg"])
+	require.Equal(t, "POST", lines[0]["method"])
+	require.Equal(t, server.URL+"/v1/statements", lines[0]["url"])
+	require.Equal(t, []any{"REDACTED"}, lines[0]["headers"].(map[string]any)["Authorization"])
+	require.Contains(t, lines[0]["body"], `"statement":"SELECT '***'"`)
+	require.InDelta(t, 200, lines[0]["status"], 0)
+	require.Contains(t, lines[0], "elapsed")
+	require.Contains(t, lines[1]["body"], `"rows":"<12 bytes>"`)
+	require.NotContains(t, logs.buf.String(), "secret")
+	require.NotContains(t, logs.buf.String(), "hunter2")
+}
+
+func TestDebugBodyTruncated(t *testing.T) {
+	t.Parallel()
+
+	c := &httpClient{}
+	body := c.debugBody([]byte(strings.Repeat("x", maxDebugBodyBytes+10)))
+	require.Equal(t, strings.Repeat("x", maxDebugBodyBytes)+"... (10 bytes truncated)", body)
+}
@@ -38,7 +38,9 @@ Use DataCable to write data to ScopeDB:
 		SELECT $0["ts"], $0["v"]
 		INSERT INTO %s (ts, v)
 	`, tbl.Identifier()))
-	cable.Start(ctx)
+	if err := cable.Start(ctx); err != nil {
+		return err
+	}
 	defer cable.Close()
 
 	resCh := cable.Send(struct {
@@ -17,10 +17,68 @@
 package scopedb
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrColumnNotFound is returned when a column name does not match any field.
+	ErrColumnNotFound = errors.New("column not found")
+	// ErrAmbiguousColumn is returned when a column name matches more than one field.
+	ErrAmbiguousColumn = errors.New("ambiguous column")
+	// ErrDatabaseNotFound is returned when the requested database does not exist.
+	ErrDatabaseNotFound = errors.New("database not found")
+	// ErrNoRows is returned by Row.Scan when the result set has no rows.
+	ErrNoRows = errors.New("no rows in result set")
+	// ErrResultTooLarge is returned when a result set has more rows than the
+	// configured MaxResultRows.
+	ErrResultTooLarge = errors.New("result too large")
+	// ErrCableStopped is returned for records sent to a cable that is closed
+	// or whose Start context is done.
+	ErrCableStopped = errors.New("cable stopped")
+	// ErrCableStarted is returned by DataCable.Start for a cable that has
+	// been started before.
+	ErrCableStarted = errors.New("cable already started")
+	// ErrStatementClosed is returned when executing a PreparedStatement that
+	// is closed.
+	ErrStatementClosed = errors.New("prepared statement closed")
+	// ErrCircuitOpen is returned for requests that fail fast because the
+	// circuit breaker of the endpoint is open. See Config.CircuitBreaker.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+	// ErrAuth wraps the errors of obtaining credentials, e.g., from
+	// Config.TokenSource, as opposed to errors returned by ScopeDB.
+	ErrAuth = errors.New("authentication failed")
+	// ErrProxy wraps the errors of connecting to the proxy of Config.ProxyURL
+	// or Config.Proxy, as opposed to errors of reaching ScopeDB.
+	ErrProxy = errors.New("proxy error")
+	// ErrUnsupported is returned when the server does not support a requested feature.
+	ErrUnsupported = errors.New("unsupported by the server")
+	// ErrStatementNotFound is returned when fetching a statement that ScopeDB
+	// does not know, e.g., because of a wrong ID.
+	ErrStatementNotFound = errors.New("statement not found")
+	// ErrResultExpired is returned when fetching a statement whose result
+	// ScopeDB no longer retains.
+	ErrResultExpired = errors.New("statement result expired")
+	// ErrTableNotFound matches server errors about a table that does not exist.
+	ErrTableNotFound = errors.New("table not found")
+	// ErrDuplicateStatementID matches server errors about submitting a
+	// statement with the ID of an existing statement.
+	ErrDuplicateStatementID = errors.New("duplicate statement id")
+	// ErrExecTimeout matches server errors about a statement that exceeded
+	// its exec timeout.
+	ErrExecTimeout = errors.New("exec timeout")
+	// ErrSyntax matches server errors about a statement that does not parse.
+	ErrSyntax = errors.New("syntax error")
 )
 
 // Error represents an error response from the ScopeDB server.
@@ -32,49 +90,341 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// Is reports whether e is of the well-known kind target, so that, e.g.,
+// errors.Is(err, ErrTableNotFound) matches the server error for a missing
+// table. See serverErrorKinds.
+func (e *Error) Is(target error) bool {
+	for _, kind := range serverErrorKinds {
+		if kind.err == target {
+			return kind.message.MatchString(e.Message)
+		}
+	}
+	return false
+}
+
+// serverErrorKinds maps server error messages to the well-known errors that
+// they match with errors.Is.
+//
+// ScopeDB does not expose error codes yet, so the messages are matched by
+// pattern. To recognize a new kind of error, add it here with a sample of the
+// server message in TestServerErrorKinds.
+var serverErrorKinds = []struct {
+	err     error
+	message *regexp.Regexp
+}{
+	{ErrStatementNotFound, regexp.MustCompile(`(?i)\b(statement|result set)( \S+)? not found\b`)},
+	{ErrResultExpired, regexp.MustCompile(`(?i)\bresult\b.*\bexpired\b|\bexpired\b.*\bresult\b`)},
+	{ErrTableNotFound, regexp.MustCompile(`(?i)\btable \S+ not found\b|\bunknown table\b`)},
+	{ErrDuplicateStatementID, regexp.MustCompile(`(?i)\bduplicate statement id\b|\bstatement\b.*\balready exists\b`)},
+	{ErrExecTimeout, regexp.MustCompile(`(?i)\bexec(ution)? timeout\b|\b(statement|execution|query) timed out\b`)},
+	{ErrSyntax, regexp.MustCompile(`(?i)\bsyntax error\b|\bfailed to parse\b`)},
+}
+
+// SchemaMismatchError is returned by Table.EnsureSchema when the table schema
+// differs from the desired schema in ways that are not applied automatically.
+type SchemaMismatchError struct {
+	// Table is the quoted table identifier.
+	Table string
+	// Changes are the differences that were not applied.
+	Changes []SchemaChange
+}
+
+func (e *SchemaMismatchError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, change := range e.Changes {
+		changes[i] = change.String()
+	}
+	return fmt.Sprintf("schema of table %s does not match: %s", e.Table, strings.Join(changes, "; "))
+}
+
+// RecordTooLargeError is returned by DataCable.Send when a record is larger
+// than the cable's MaxRecordBytes.
+type RecordTooLargeError struct {
+	// Size is the size in bytes of the JSON-encoded record.
+	Size uint64
+	// Limit is the configured MaxRecordBytes.
+	Limit uint64
+}
+
+func (e *RecordTooLargeError) Error() string {
+	return fmt.Sprintf("record of %d bytes exceeds the limit of %d bytes", e.Size, e.Limit)
+}
+
+// RequestTooLargeError is returned for requests whose uncompressed body is
+// larger than Config.MaxRequestBytes. Such requests are not sent.
+type RequestTooLargeError struct {
+	// Size is the size in bytes of the uncompressed request body.
+	Size int64
+	// Limit is the configured MaxRequestBytes.
+	Limit int64
+}
+
+func (e *RequestTooLargeError) Error() string {
+	return fmt.Sprintf("request of %d bytes exceeds the limit of %d bytes", e.Size, e.Limit)
+}
+
+// RequestError is an error of an HTTP request to ScopeDB, carrying the ID that
+// the client sent in the X-Request-ID header to correlate with server logs.
+type RequestError struct {
+	// RequestID is the client-generated ID of the request.
+	RequestID string
+	// Operation is the API operation of the request, e.g., "submit statement"
+	// or "ingest". It is empty for requests to unknown endpoints.
+	Operation string
+	// StatusCode is the HTTP status code of the response, or zero if the
+	// request failed before a response was received.
+	StatusCode int
+	// Body is an excerpt of the response body, capped at maxBodyExcerpt bytes.
+	// It is empty if no response was received.
+	Body string
+	// Err is the underlying error, e.g., an *Error with the message parsed
+	// from the response body.
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	var b strings.Builder
+	if e.Operation != "" {
+		b.WriteString(e.Operation)
+		b.WriteString(": ")
+	}
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, "status %d: ", e.StatusCode)
+	}
+	fmt.Fprint(&b, e.Err)
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request id: %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// FetchError is returned by StatementHandle.Fetch when fetching the statement
+// failed more consecutive times than StatementHandle.MaxFetchFailures allows.
+type FetchError struct {
+	// StatementID is the ID of the statement.
+	StatementID uuid.UUID
+	// Failures is the number of consecutive failures.
+	Failures int
+	// Err is the error of the last failure.
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetch statement %s: %d consecutive failures: %v", e.StatementID, e.Failures, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// StatementGoneError is returned by StatementHandle.Fetch and FetchOnce when
+// the statement or its result is gone from ScopeDB. Retrying does not help.
+//
+// It matches ErrStatementNotFound or ErrResultExpired with errors.Is.
+type StatementGoneError struct {
+	// StatementID is the ID of the statement.
+	StatementID uuid.UUID
+	// Reason is ErrStatementNotFound or ErrResultExpired.
+	Reason error
+	// Err is the error returned by the server.
+	Err error
+}
+
+func (e *StatementGoneError) Error() string {
+	return fmt.Sprintf("%v: %s: %v", e.Reason, e.StatementID, e.Err)
+}
+
+func (e *StatementGoneError) Unwrap() []error {
+	return []error{e.Reason, e.Err}
+}
+
+// statementGone wraps err in a *StatementGoneError if it reports that the
+// statement or its result is gone; otherwise it returns err as is.
+func statementGone(id uuid.UUID, err error) error {
+	if isTransientError(err) {
+		return err
+	}
+
+	var status int
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		status = reqErr.StatusCode
+	}
+
+	switch {
+	case status == http.StatusGone || errors.Is(err, ErrResultExpired):
+		return &StatementGoneError{StatementID: id, Reason: ErrResultExpired, Err: err}
+	case status == http.StatusNotFound || errors.Is(err, ErrStatementNotFound):
+		return &StatementGoneError{StatementID: id, Reason: ErrStatementNotFound, Err: err}
+	default:
+		return err
+	}
+}
+
+// RequestIDOf returns the request ID of the HTTP request that err comes from,
+// or an empty string if err does not come from a request.
+func RequestIDOf(err error) string {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.RequestID
+	}
+	return ""
+}
+
+// StatusCodeOf returns the HTTP status code of the response that err comes
+// from, or zero if err does not come from a response.
+func StatusCodeOf(err error) int {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode
+	}
+	return 0
+}
+
+// isTransientError reports whether err is a request error that may succeed
+// if retried: a connection failure, or a 429 or 5xx response.
+func isTransientError(err error) bool {
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		return false
+	}
+	switch {
+	case reqErr.StatusCode == 0:
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	case reqErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	default:
+		return reqErr.StatusCode >= http.StatusInternalServerError
+	}
+}
+
+// proxyError wraps err with ErrProxy if the transport failed to connect to
+// the proxy.
+func proxyError(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return fmt.Errorf("%w: %w", ErrProxy, err)
+	}
+	return err
+}
+
+// maxBodyExcerpt is the maximum length of RequestError.Body.
+const maxBodyExcerpt = 512
+
+// responseError returns the error of resp, whose body is data.
+//
+// The message of a JSON error body is parsed into an *Error; any other body
+// becomes the message as is, truncated to maxBodyExcerpt.
+func responseError(resp *http.Response, data []byte) error {
+	body := excerpt(data)
+	var err error
+	var errResp Error
+	switch {
+	case json.Unmarshal(data, &errResp) == nil && errResp.Message != "":
+		err = &errResp
+	case body != "":
+		err = errors.New(body)
+	default:
+		err = errors.New(http.StatusText(resp.StatusCode))
+	}
+	return &RequestError{
+		RequestID:  requestIDOf(resp),
+		Operation:  operationOf(resp.Request),
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		Err:        err,
+	}
+}
+
+// readError returns the error of reading the body of resp.
+func readError(resp *http.Response, err error) error {
+	return &RequestError{
+		RequestID:  requestIDOf(resp),
+		Operation:  operationOf(resp.Request),
+		StatusCode: resp.StatusCode,
+		Err:        err,
+	}
+}
+
+// excerpt returns data as a string of at most maxBodyExcerpt bytes.
+func excerpt(data []byte) string {
+	body := strings.TrimSpace(string(data))
+	if len(body) <= maxBodyExcerpt {
+		return body
+	}
+	return strings.ToValidUTF8(body[:maxBodyExcerpt], "") + "..."
+}
+
+// operationOf names the API operation of req.
+func operationOf(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	path := req.URL.Path
+	switch {
+	case strings.HasSuffix(path, "/v1/ingest"):
+		return "ingest"
+	case strings.HasSuffix(path, "/v1/statements"):
+		return "submit statement"
+	case strings.Contains(path, "/v1/statements/") && strings.HasSuffix(path, "/cancel"):
+		return "cancel statement"
+	case strings.Contains(path, "/v1/statements/"):
+		return "fetch statement"
+	default:
+		return ""
+	}
+}
+
+func requestIDOf(resp *http.Response) string {
+	if resp == nil || resp.Request == nil {
+		return ""
+	}
+	return resp.Request.Header.Get(requestIDHeader)
+}
+
 func checkStatementResponse(resp *http.Response) (*statementResponse, error) {
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, readError(resp, err)
 	}
 
 	var stmtResp statementResponse
 	_ = json.Unmarshal(data, &stmtResp)
 	if stmtResp.Status != "" {
+		stmtResp.requestID = requestIDOf(resp)
 		return &stmtResp, nil
 	}
-
-	var errResp Error
-	if err := json.Unmarshal(data, &errResp); err != nil {
-		msg := string(data)
-		return nil, fmt.Errorf("%d: %s", resp.StatusCode, msg)
-	}
-	return nil, &errResp
+	return nil, responseError(resp, data)
 }
 
 func checkStatementCancelResponse(resp *http.Response) (*statementCancelResponse, error) {
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
-	}
-
-	var stmtResp statementCancelResponse
-	if err := json.Unmarshal(data, &stmtResp); err == nil {
-		return &stmtResp, nil
+		return nil, readError(resp, err)
 	}
 
-	var errResp Error
-	if err := json.Unmarshal(data, &errResp); err != nil {
-		msg := string(data)
-		return nil, fmt.Errorf("%d: %s", resp.StatusCode, msg)
+	if resp.StatusCode/100 == 2 {
+		// A 202 Accepted or 204 No Content may come without a body; the
+		// cancellation was still accepted.
+		if len(bytes.TrimSpace(data)) == 0 {
+			return &statementCancelResponse{Status: StatementStatusCancelled}, nil
+		}
+		var stmtResp statementCancelResponse
+		if err := json.Unmarshal(data, &stmtResp); err == nil {
+			return &stmtResp, nil
+		}
 	}
-	return nil, &errResp
+	return nil, responseError(resp, data)
 }
 
 func checkIngestResponse(resp *http.Response) (*ingestResponse, error) {
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, readError(resp, err)
 	}
 
 	if resp.StatusCode == http.StatusOK {
@@ -83,13 +433,7 @@ func checkIngestResponse(resp *http.Response) (*ingestResponse, error) {
 			return &stmtResp, nil
 		}
 	}
-
-	var errResp Error
-	if err := json.Unmarshal(data, &errResp); err != nil {
-		msg := string(data)
-		return nil, fmt.Errorf("%d: %s", resp.StatusCode, msg)
-	}
-	return nil, &errResp
+	return nil, responseError(resp, data)
 }
 
 // sneakyBodyClose closes the body and ignores the error.
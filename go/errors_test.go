@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerErrorKinds(t *testing.T) {
+	t.Parallel()
+
+	kinds := []error{
+		ErrStatementNotFound,
+		ErrResultExpired,
+		ErrTableNotFound,
+		ErrDuplicateStatementID,
+		ErrExecTimeout,
+		ErrSyntax,
+	}
+	for _, tc := range []struct {
+		message  string
+		expected error
+	}{
+		// Captured from ScopeDB, see itcases/__snapshots__/error_test.snap.
+		{message: "result set not found: c8fe71d6-3695-11f0-85b3-063c3400fda9", expected: ErrStatementNotFound},
+		{message: "0: failed to prepare statement: \"SELECT UNKNOWN_FUNCTION()\"\n1: failed to build physical plan\n" +
+			"2: failed to type check function: unknown_function()\n3: function not found: unknown_function()"},
+
+		{message: "statement 0195f0b4-3c1a-7d3e-8a2b-5c6d7e8f9a0b not found", expected: ErrStatementNotFound},
+		{message: "result of statement has expired", expected: ErrResultExpired},
+		{message: "table `scopedb`.`public`.`events` not found", expected: ErrTableNotFound},
+		{message: "unknown table: events", expected: ErrTableNotFound},
+		{message: "duplicate statement id: 0195f0b4-3c1a-7d3e-8a2b-5c6d7e8f9a0b", expected: ErrDuplicateStatementID},
+		{message: "statement 0195f0b4-3c1a-7d3e-8a2b-5c6d7e8f9a0b already exists", expected: ErrDuplicateStatementID},
+		{message: "statement exceeded exec timeout of 10s", expected: ErrExecTimeout},
+		{message: "statement timed out after 1m", expected: ErrExecTimeout},
+		{message: "execution timed out", expected: ErrExecTimeout},
+		{message: "table t: column x not found"},
+		{message: "connect timed out"},
+		{message: "lock wait timed out"},
+		{message: "upstream request timed out"},
+		{message: "syntax error at 1:8: unexpected token", expected: ErrSyntax},
+		{message: "failed to parse statement", expected: ErrSyntax},
+		{message: "division by zero"},
+	} {
+		err := fmt.Errorf("wrapped: %w", &RequestError{RequestID: "id", Err: &Error{Message: tc.message}})
+		for _, kind := range kinds {
+			require.Equal(t, kind == tc.expected, errors.Is(err, kind), "%q is %v", tc.message, kind)
+		}
+		var serverErr *Error
+		require.ErrorAs(t, err, &serverErr)
+	}
+
+	require.False(t, errors.Is(&Error{Message: "table not found"}, ErrNoRows))
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Plan is the query plan of a statement returned by Statement.Explain.
+type Plan struct {
+	// Raw is the plan text as returned by ScopeDB, one line per row.
+	Raw string
+	// Nodes are the top-level nodes of the plan, usually a single root.
+	Nodes []*PlanNode
+}
+
+// PlanNode is an operator in a query plan.
+type PlanNode struct {
+	// Operator is the operator name, e.g., "TableScan".
+	Operator string
+	// Detail is the text following the operator name on its line.
+	Detail string
+	// Attributes are the "key=value" or "key: value" pairs found in Detail.
+	Attributes map[string]string
+	// EstimatedRows is the number of rows estimated by the planner, or the
+	// number of rows observed for ExplainAnalyze, or -1 if not reported.
+	EstimatedRows int64
+	// Partitions is the number of partitions to scan, or -1 if not reported.
+	Partitions int64
+	// Filters are the filter predicates of the operator.
+	Filters []string
+	// Children are the input operators.
+	Children []*PlanNode
+}
+
+func (p *Plan) String() string {
+	return p.Raw
+}
+
+// Explain returns the query plan of the statement without executing it.
+//
+// This method submits the statement prefixed with EXPLAIN and blocks until
+// the plan is fetched. The plan text is parsed as an indented tree with one
+// operator per line; the exact text is not a stable interface of ScopeDB, so
+// Plan.Raw should be used for display.
+func (s *Statement) Explain(ctx context.Context) (*Plan, error) {
+	return s.explain(ctx, "EXPLAIN ")
+}
+
+// ExplainAnalyze executes the statement and returns its query plan annotated
+// with the metrics observed for each node.
+//
+// See Explain for how the plan is parsed. The observed metrics are available
+// in PlanNode.Attributes.
+func (s *Statement) ExplainAnalyze(ctx context.Context) (*Plan, error) {
+	return s.explain(ctx, "EXPLAIN ANALYZE ")
+}
+
+func (s *Statement) explain(ctx context.Context, prefix string) (*Plan, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	stmt := s.c.Statement(prefix + s.stmt)
+	stmt.ExecTimeout = s.ExecTimeout
+	stmt.ExecTimeoutDuration = s.ExecTimeoutDuration
+	stmt.Tags = s.Tags
+	stmt.NodeGroup = s.NodeGroup
+	stmt.Priority = s.Priority
+	stmt.WaitTimeout = s.WaitTimeout
+	stmt.FetchOptions = s.FetchOptions
+	stmt.MaxResultRows = 0
+	rs, err := stmt.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if err := rs.scanRows(func(row []*string) error {
+		var cells []string
+		for _, cell := range row {
+			if cell != nil {
+				cells = append(cells, *cell)
+			}
+		}
+		lines = append(lines, strings.Split(strings.Join(cells, " "), "\n")...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return parsePlan(strings.Join(lines, "\n")), nil
+}
+
+// parsePlan parses an indented plan tree. Tree drawing characters such as
+// "├─" and "└─" count as indentation.
+func parsePlan(raw string) *Plan {
+	plan := &Plan{Raw: raw}
+
+	type frame struct {
+		indent int
+		node   *PlanNode
+	}
+	var stack []frame
+	for _, line := range strings.Split(raw, "\n") {
+		indent := 0
+		rest := strings.TrimLeftFunc(line, func(r rune) bool {
+			if !strings.ContainsRune(" \t│├└─|+`->*", r) {
+				return false
+			}
+			if r == '\t' {
+				indent += 4
+			} else {
+				indent++
+			}
+			return true
+		})
+		if rest == "" {
+			continue
+		}
+
+		node := parsePlanNode(rest)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			plan.Nodes = append(plan.Nodes, node)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, frame{indent: indent, node: node})
+	}
+	return plan
+}
+
+func parsePlanNode(line string) *PlanNode {
+	end := strings.IndexFunc(line, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("([{:", r)
+	})
+	if end < 0 {
+		end = len(line)
+	}
+
+	node := &PlanNode{
+		Operator:      line[:end],
+		Detail:        strings.TrimSpace(line[end:]),
+		Attributes:    map[string]string{},
+		EstimatedRows: -1,
+		Partitions:    -1,
+	}
+
+	detail := strings.TrimSpace(strings.TrimPrefix(node.Detail, ":"))
+	if len(detail) >= 2 && strings.ContainsRune("([{", rune(detail[0])) && strings.ContainsRune(")]}", rune(detail[len(detail)-1])) {
+		detail = detail[1 : len(detail)-1]
+	}
+	for _, part := range splitTopLevel(detail) {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			key, value, ok = strings.Cut(part, ":")
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" || strings.ContainsFunc(key, unicode.IsSpace) {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		node.Attributes[key] = value
+
+		switch key {
+		case "rows", "estimated_rows", "est_rows", "output_rows":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				node.EstimatedRows = n
+			}
+		case "partitions", "num_partitions":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				node.Partitions = n
+			}
+		case "filter", "filters", "predicate":
+			node.Filters = append(node.Filters, value)
+		}
+	}
+	return node
+}
+
+// splitTopLevel splits s by commas that are not nested in brackets or quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	var quote rune
+	depth, start := 0, 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case strings.ContainsRune("([{", r):
+			depth++
+		case strings.ContainsRune(")]}", r):
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Walk calls fn for the node and its descendants in depth-first order.
+func (n *PlanNode) Walk(fn func(node *PlanNode, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *PlanNode) walk(fn func(node *PlanNode, depth int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+func (n *PlanNode) String() string {
+	if n.Detail == "" {
+		return n.Operator
+	}
+	return fmt.Sprintf("%s %s", n.Operator, n.Detail)
+}
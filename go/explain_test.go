@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementExplain(t *testing.T) {
+	t.Parallel()
+
+	var explained string
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		explained = stmt
+		return []resultSetField{{Name: "plan", DataType: "string"}}, [][]*string{
+			{ptr("Limit [limit=10]")},
+			{ptr("└─ Filter [filter=(v > 1, 'a,b'), rows=42]")},
+			{ptr("   ├─ TableScan: table=`events`, partitions=7, rows=1000\n   │  └─ Prune")},
+			{ptr("   └─ Values")},
+		}
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	plan, err := c.Statement("FROM `events` WHERE v > 1 LIMIT 10").Explain(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "EXPLAIN FROM `events` WHERE v > 1 LIMIT 10", explained)
+	require.True(t, strings.HasPrefix(plan.String(), "Limit [limit=10]\n└─ Filter"))
+
+	require.Len(t, plan.Nodes, 1)
+	limit := plan.Nodes[0]
+	require.Equal(t, "Limit", limit.Operator)
+	require.Equal(t, map[string]string{"limit": "10"}, limit.Attributes)
+	require.EqualValues(t, -1, limit.EstimatedRows)
+
+	filter := limit.Children[0]
+	require.Equal(t, "Filter", filter.Operator)
+	require.Equal(t, []string{"(v > 1, 'a,b')"}, filter.Filters)
+	require.EqualValues(t, 42, filter.EstimatedRows)
+	require.Len(t, filter.Children, 2)
+
+	scan := filter.Children[0]
+	require.Equal(t, "TableScan", scan.Operator)
+	require.Equal(t, "`events`", scan.Attributes["table"])
+	require.EqualValues(t, 7, scan.Partitions)
+	require.EqualValues(t, 1000, scan.EstimatedRows)
+	require.Equal(t, "Prune", scan.Children[0].Operator)
+	require.Equal(t, "Values", filter.Children[1].Operator)
+
+	var operators []string
+	limit.Walk(func(node *PlanNode, depth int) {
+		operators = append(operators, strings.Repeat(" ", depth)+node.Operator)
+	})
+	require.Equal(t, []string{"Limit", " Filter", "  TableScan", "   Prune", "  Values"}, operators)
+
+	_, err = c.Statement("FROM `events`").ExplainAnalyze(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "EXPLAIN ANALYZE FROM `events`", explained)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNull is returned by the typed getters of ResultSet for NULL cells.
+var ErrNull = errors.New("null value")
+
+// errCellFound stops scanRows once the requested row is found.
+var errCellFound = errors.New("cell found")
+
+// cell returns the value of the cell at row in column, converted as ToValues
+// does. The column must be of one of the given types.
+func (rs *ResultSet) cell(row int, column string, types ...DataType) (Value, error) {
+	if rs.Format != ResultFormatJSON {
+		return nil, fmt.Errorf("unexpected result set format: %s", rs.Format)
+	}
+	i, err := rs.Schema.FieldIndex(column)
+	if err != nil {
+		return nil, err
+	}
+	fs := rs.Schema[i]
+	if !slices.Contains(types, fs.Type) {
+		names := make([]string, len(types))
+		for j, typ := range types {
+			names[j] = string(typ)
+		}
+		return nil, fmt.Errorf("column %q is of type %s, not %s", column, fs.Type, strings.Join(names, " or "))
+	}
+	if row < 0 {
+		return nil, fmt.Errorf("row %d out of range", row)
+	}
+
+	var raw *string
+	n := 0
+	err = rs.scanRows(func(r []*string) error {
+		if n == row {
+			raw = r[i]
+			return errCellFound
+		}
+		n++
+		return nil
+	})
+	switch {
+	case errors.Is(err, errCellFound):
+	case err != nil:
+		return nil, err
+	default:
+		return nil, fmt.Errorf("row %d out of range: result set has %d rows", row, n)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("row %d, column %q: %w", row, column, ErrNull)
+	}
+
+	v, err := convertValue(*raw, fs.Type)
+	if err != nil {
+		return nil, fmt.Errorf("row %d, column %q: %w", row, column, err)
+	}
+	return v, nil
+}
+
+// getCell returns the cell at row in column as a T.
+func getCell[T any](rs *ResultSet, row int, column string, types ...DataType) (T, error) {
+	v, err := rs.cell(row, column, types...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// GetInt64 returns the value of the int column at row.
+//
+// Like the other typed getters, it decodes the rows up to row and converts
+// the cell as ToValues does, so it suits reading a few cells; use Rows or
+// ToValues to read many. It returns ErrNull for a NULL cell, and an error
+// naming the column or row if the column does not exist, has another type,
+// or the row is out of range.
+func (rs *ResultSet) GetInt64(row int, column string) (int64, error) {
+	return getCell[int64](rs, row, column, IntDataType)
+}
+
+// GetUint64 returns the value of the uint column at row. See GetInt64.
+func (rs *ResultSet) GetUint64(row int, column string) (uint64, error) {
+	return getCell[uint64](rs, row, column, UIntDataType)
+}
+
+// GetFloat64 returns the value of the float column at row. See GetInt64.
+func (rs *ResultSet) GetFloat64(row int, column string) (float64, error) {
+	return getCell[float64](rs, row, column, FloatDataType)
+}
+
+// GetString returns the value of the string column at row. See GetInt64.
+func (rs *ResultSet) GetString(row int, column string) (string, error) {
+	return getCell[string](rs, row, column, StringDataType)
+}
+
+// GetBool returns the value of the boolean column at row. See GetInt64.
+func (rs *ResultSet) GetBool(row int, column string) (bool, error) {
+	return getCell[bool](rs, row, column, BooleanDataType)
+}
+
+// GetTime returns the value of the timestamp column at row. See GetInt64.
+func (rs *ResultSet) GetTime(row int, column string) (time.Time, error) {
+	return getCell[time.Time](rs, row, column, TimestampDataType)
+}
+
+// GetDuration returns the value of the interval column at row. See GetInt64.
+func (rs *ResultSet) GetDuration(row int, column string) (time.Duration, error) {
+	return getCell[time.Duration](rs, row, column, IntervalDataType)
+}
+
+// GetRawJSON returns the JSON value of the array, object, or any column at
+// row. See GetInt64.
+func (rs *ResultSet) GetRawJSON(row int, column string) (json.RawMessage, error) {
+	s, err := getCell[string](rs, row, column, ArrayDataType, ObjectDataType, AnyDataType)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(s), nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultSetGetters(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema: Schema{
+			newFieldSchema("i", "int"),
+			newFieldSchema("u", "uint"),
+			newFieldSchema("f", "float"),
+			newFieldSchema("s", "string"),
+			newFieldSchema("b", "boolean"),
+			newFieldSchema("ts", "timestamp"),
+			newFieldSchema("d", "interval"),
+			newFieldSchema("o", "object"),
+		},
+		Format: ResultFormatJSON,
+		rows: json.RawMessage(`[
+			["-1","18446744073709551615","1.5","a","true","1970-01-01T00:00:01Z","1m30s","{\"k\":1}"],
+			[null,null,null,null,null,null,null,null]
+		]`),
+	}
+
+	i, err := rs.GetInt64(0, "i")
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), i)
+	u, err := rs.GetUint64(0, "u")
+	require.NoError(t, err)
+	require.Equal(t, uint64(18446744073709551615), u)
+	f, err := rs.GetFloat64(0, "f")
+	require.NoError(t, err)
+	require.Equal(t, 1.5, f)
+	s, err := rs.GetString(0, "s")
+	require.NoError(t, err)
+	require.Equal(t, "a", s)
+	b, err := rs.GetBool(0, "b")
+	require.NoError(t, err)
+	require.True(t, b)
+	ts, err := rs.GetTime(0, "ts")
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1, 0).UTC(), ts)
+	d, err := rs.GetDuration(0, "d")
+	require.NoError(t, err)
+	require.Equal(t, 90*time.Second, d)
+	raw, err := rs.GetRawJSON(0, "o")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"k":1}`, string(raw))
+
+	i, err = rs.GetInt64(1, "i")
+	require.ErrorIs(t, err, ErrNull)
+	require.Zero(t, i)
+	raw, err = rs.GetRawJSON(1, "o")
+	require.ErrorIs(t, err, ErrNull)
+	require.Nil(t, raw)
+
+	_, err = rs.GetInt64(2, "i")
+	require.EqualError(t, err, "row 2 out of range: result set has 2 rows")
+	_, err = rs.GetInt64(-1, "i")
+	require.EqualError(t, err, "row -1 out of range")
+	_, err = rs.GetInt64(0, "missing")
+	require.ErrorIs(t, err, ErrColumnNotFound)
+	require.ErrorContains(t, err, `"missing"`)
+	_, err = rs.GetInt64(0, "s")
+	require.EqualError(t, err, `column "s" is of type string, not int`)
+	_, err = rs.GetRawJSON(0, "i")
+	require.EqualError(t, err, `column "i" is of type int, not array or object or any`)
+
+	rs.rows = json.RawMessage(`[["x","1","1","a","true","1970-01-01T00:00:01Z","1s","{}"]]`)
+	_, err = rs.GetInt64(0, "i")
+	require.ErrorContains(t, err, `row 0, column "i": strconv.ParseInt`)
+}
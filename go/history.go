@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxStatementInfoText is the maximum number of characters of the statement
+// text kept in StatementInfo.
+const maxStatementInfoText = 256
+
+// ListStatementsOptions configures Client.ListStatements.
+type ListStatementsOptions struct {
+	// Status filters the statements by status. The default is all statuses.
+	Status StatementStatus
+	// Since filters the statements created at or after the given time.
+	// The default is no lower bound.
+	Since time.Time
+	// Limit is the maximum number of statements to return. The default is 100.
+	Limit int
+}
+
+// StatementInfo describes a statement in the statement history.
+type StatementInfo struct {
+	c *Client
+
+	// ID is the statement ID.
+	ID uuid.UUID
+	// Status is the status of the statement when it was listed.
+	Status StatementStatus
+	// CreatedAt is the time the statement was submitted.
+	CreatedAt time.Time
+	// Duration is the execution time of the statement so far.
+	Duration time.Duration
+	// Statement is the statement text, truncated to 256 characters with a
+	// trailing "…" if longer.
+	Statement string
+}
+
+// Handle returns a StatementHandle for the statement, e.g., to cancel it.
+func (i *StatementInfo) Handle() *StatementHandle {
+	return i.c.StatementHandle(i.ID)
+}
+
+// ListStatements returns the recently submitted statements, newest first.
+//
+// This method issues a meta query on scopedb.system.statements to ScopeDB and
+// blocks until the result is fetched.
+func (c *Client) ListStatements(ctx context.Context, opts ListStatementsOptions) ([]*StatementInfo, error) {
+	var filters []string
+	if opts.Status != "" {
+		filters = append(filters, "status = "+QuoteString(string(opts.Status)))
+	}
+	if !opts.Since.IsZero() {
+		since, err := formatLiteral(opts.Since)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, "created_at >= "+since)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var b strings.Builder
+	b.WriteString("FROM scopedb.system.statements\n")
+	if len(filters) > 0 {
+		b.WriteString("WHERE " + strings.Join(filters, " AND ") + "\n")
+	}
+	b.WriteString("SELECT statement_id, status, created_at, duration, statement\n")
+	b.WriteString("ORDER BY created_at DESC\nLIMIT " + strconv.Itoa(limit))
+
+	var rows []struct {
+		ID        string        `json:"statement_id"`
+		Status    string        `json:"status"`
+		CreatedAt time.Time     `json:"created_at"`
+		Duration  time.Duration `json:"duration"`
+		Statement string        `json:"statement"`
+	}
+	if err := c.queryStructs(ctx, b.String(), &rows); err != nil {
+		return nil, err
+	}
+
+	infos := make([]*StatementInfo, len(rows))
+	for i, row := range rows {
+		id, err := uuid.Parse(row.ID)
+		if err != nil {
+			return nil, fmt.Errorf("statement_id %q: %w", row.ID, err)
+		}
+		infos[i] = &StatementInfo{
+			c:         c,
+			ID:        id,
+			Status:    StatementStatus(row.Status),
+			CreatedAt: row.CreatedAt,
+			Duration:  row.Duration,
+			Statement: truncateText(row.Statement, maxStatementInfoText),
+		}
+	}
+	return infos, nil
+}
+
+// truncateText truncates s to n characters, marking truncation with "…".
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientListStatements(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.New()
+	long := strings.Repeat("x", 300)
+	var listed string
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		listed = stmt
+		return []resultSetField{
+			{Name: "statement_id", DataType: "string"},
+			{Name: "status", DataType: "string"},
+			{Name: "created_at", DataType: "timestamp"},
+			{Name: "duration", DataType: "interval"},
+			{Name: "statement", DataType: "string"},
+		}, [][]*string{{ptr(id.String()), ptr("running"), ptr("2026-01-01T00:00:00Z"), ptr("1m30s"), ptr(long)}}
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	infos, err := c.ListStatements(context.Background(), ListStatementsOptions{
+		Status: StatementStatusRunning,
+		Since:  time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "FROM scopedb.system.statements\n"+
+		"WHERE status = 'running' AND created_at >= '2025-12-31T00:00:00Z'::timestamp\n"+
+		"SELECT statement_id, status, created_at, duration, statement\n"+
+		"ORDER BY created_at DESC\nLIMIT 5", listed)
+
+	require.Len(t, infos, 1)
+	info := infos[0]
+	require.Equal(t, id, info.ID)
+	require.Equal(t, StatementStatusRunning, info.Status)
+	require.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), info.CreatedAt)
+	require.Equal(t, 90*time.Second, info.Duration)
+	require.Equal(t, strings.Repeat("x", 255)+"…", info.Statement)
+	require.Equal(t, id, info.Handle().ID())
+
+	_, err = c.ListStatements(context.Background(), ListStatementsOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "FROM scopedb.system.statements\n"+
+		"SELECT statement_id, status, created_at, duration, statement\n"+
+		"ORDER BY created_at DESC\nLIMIT 100", listed)
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForceHTTP2(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var protos []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_, err := decodeCompressedRequestBody(r)
+			require.NoError(t, err)
+		}
+		mu.Lock()
+		protos = append(protos, r.Proto)
+		mu.Unlock()
+		writeEmptyResponse(w, r)
+	}))
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	server.Config.Protocols = &protocols
+	server.Start()
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	for _, compression := range []Compression{CompressionZstd, CompressionGzip} {
+		c := NewClient(&Config{Endpoint: server.URL, Compression: compression, ForceHTTP2: true})
+		_, err := c.Statement("VALUES (1)").Execute(ctx)
+		require.NoError(t, err)
+		_, err = c.ingest(ctx, &ingestRequest{Statement: "INSERT INTO t", Data: ingestData{Format: writeFormatJSON, Rows: `{"v":1}`}})
+		require.NoError(t, err)
+		c.Close()
+	}
+
+	c := NewClient(&Config{Endpoint: server.URL})
+	_, err := c.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"HTTP/2.0", "HTTP/2.0", "HTTP/2.0", "HTTP/2.0", "HTTP/1.1"}, protos)
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IngestBuilder builds and runs an ingestion of records in a single request,
+// generating its transforms from the select expressions and the target. See
+// Client.NewIngest.
+type IngestBuilder struct {
+	c       *Client
+	records []any
+	exprs   []string
+	target  *Table
+	columns []string
+	merge   *MergeBuilder
+}
+
+// IngestResponse reports a finished ingestion.
+type IngestResponse struct {
+	// RowsInserted is the number of rows that ScopeDB inserted.
+	RowsInserted int
+}
+
+// NewIngest starts an ingestion that generates its transforms, e.g.:
+//
+//	result, err := c.NewIngest().
+//		Records(records...).
+//		Select(`$0["id"]::int`, `$0["message"]::string`, `$0`).
+//		Into(tbl, "id", "message", "var").
+//		Run(ctx)
+//
+// The source of the select expressions has the single column $0 holding each
+// JSON-serialized record, as for Client.Ingest.
+func (c *Client) NewIngest() *IngestBuilder {
+	return &IngestBuilder{c: c}
+}
+
+// Records appends records to ingest. All of them should be JSON-serializable.
+func (b *IngestBuilder) Records(records ...any) *IngestBuilder {
+	b.records = append(b.records, records...)
+	return b
+}
+
+// Select sets the expressions that compute the inserted columns from the
+// source, in the order of the target columns.
+func (b *IngestBuilder) Select(exprs ...string) *IngestBuilder {
+	b.exprs = exprs
+	return b
+}
+
+// Into inserts the selected rows into the given columns of table, or into
+// all of its columns in order if none are given.
+func (b *IngestBuilder) Into(table *Table, columns ...string) *IngestBuilder {
+	b.target, b.columns, b.merge = table, columns, nil
+	return b
+}
+
+// Merge merges the selected rows into the target of merge instead, with the
+// selected expressions as the columns of its USING source.
+func (b *IngestBuilder) Merge(merge *MergeBuilder) *IngestBuilder {
+	b.target, b.columns, b.merge = nil, nil, merge
+	return b
+}
+
+// Transforms validates the ingestion and returns its generated transforms.
+//
+// For an Into target without columns, the expression count is checked
+// against the columns of the table by Run, since that needs a meta query.
+func (b *IngestBuilder) Transforms() (string, error) {
+	if len(b.exprs) == 0 {
+		return "", errors.New("missing select expressions")
+	}
+	for i, expr := range b.exprs {
+		if column, ok := sourceColumnOutOfRange(expr); ok {
+			return "", fmt.Errorf("select expression %d (%s) references $%d, but the source has only column $0", i+1, expr, column)
+		}
+	}
+	selectClause := "SELECT " + strings.Join(b.exprs, ", ")
+
+	if b.merge != nil {
+		if n := len(b.merge.columns); n > 0 && n != len(b.exprs) {
+			return "", fmt.Errorf("select has %d expressions, but the USING source %s has %d columns",
+				len(b.exprs), QuoteIdent(b.merge.alias), n)
+		}
+		merge, err := b.merge.Build()
+		if err != nil {
+			return "", err
+		}
+		return selectClause + "\n" + merge, nil
+	}
+
+	if b.target == nil {
+		return "", errors.New("missing target: call Into or Merge")
+	}
+	insert := "INSERT INTO " + b.target.Identifier()
+	if len(b.columns) > 0 {
+		if len(b.columns) != len(b.exprs) {
+			return "", fmt.Errorf("select has %d expressions, but INSERT INTO %s has %d columns",
+				len(b.exprs), b.target.Identifier(), len(b.columns))
+		}
+		names := make([]string, len(b.columns))
+		for i, column := range b.columns {
+			names[i] = QuoteIdent(column)
+		}
+		insert += " (" + strings.Join(names, ", ") + ")"
+	}
+	return selectClause + "\n" + insert, nil
+}
+
+// Run ingests the records through the generated transforms and commits them
+// before returning.
+//
+// For an Into target without columns, Run first fetches the schema of the
+// table with Table.TableSchema.
+func (b *IngestBuilder) Run(ctx context.Context) (*IngestResponse, error) {
+	if b.c.configErr != nil {
+		return nil, b.c.configErr
+	}
+	transforms, err := b.Transforms()
+	if err != nil {
+		return nil, err
+	}
+	if b.merge == nil && len(b.columns) == 0 {
+		schema, err := b.target.TableSchema(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(schema) != len(b.exprs) {
+			return nil, fmt.Errorf("select has %d expressions, but table %s has %d columns",
+				len(b.exprs), b.target.Identifier(), len(schema))
+		}
+	}
+	resp, err := b.c.ingestRecords(ctx, transforms, b.records)
+	if err != nil {
+		return nil, err
+	}
+	return &IngestResponse{RowsInserted: resp.NumRowsInserted}, nil
+}
+
+// sourceColumnOutOfRange returns the first source column other than $0 that
+// expr references outside of literals and quoted identifiers.
+func sourceColumnOutOfRange(expr string) (int, bool) {
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(expr) && expr[i] != c; i++ {
+				if expr[i] == '\\' {
+					i++
+				}
+			}
+		case c == '$':
+			j := i + 1
+			for j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
+				j++
+			}
+			if column, err := strconv.Atoi(expr[i+1 : j]); err == nil && column > 0 {
+				return column, true
+			}
+			i = j - 1
+		}
+	}
+	return 0, false
+}
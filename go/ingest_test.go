@@ -0,0 +1,132 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestBuilder(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	tbl := c.Table("events")
+	result, err := c.NewIngest().
+		Records(map[string]any{"id": 1, "message": "hello"}).
+		Select(`$0["id"]::int`, `$0["message"]::string`, `$0`).
+		Into(tbl, "id", "message", "var").
+		Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &IngestResponse{RowsInserted: 1}, result)
+
+	reqs := requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "/v1/ingest", reqs[0].Path)
+	require.Equal(t, "SELECT $0[\"id\"]::int, $0[\"message\"]::string, $0\nINSERT INTO `events` (`id`, `message`, `var`)", reqs[0].Body["statement"])
+	require.Equal(t, `{"id":1,"message":"hello"}`, reqs[0].Body["data"].(map[string]any)["rows"])
+}
+
+func TestIngestBuilderMerge(t *testing.T) {
+	t.Parallel()
+
+	tbl := NewClient(&Config{}).Table("events")
+	transforms, err := NewClient(&Config{}).NewIngest().
+		Select(`$0["id"]::int`, `$0["message"]::string`).
+		Merge(MergeInto(tbl).
+			Using("s", "id", "message").
+			On("`s`.`id` = `events`.`id`").
+			WhenMatched("", UpdateAll).
+			WhenNotMatched(InsertAll)).
+		Transforms()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT $0[\"id\"]::int, $0[\"message\"]::string\n"+
+		"MERGE INTO `events` USING `s` (`id`, `message`)\n"+
+		"ON `s`.`id` = `events`.`id`\n"+
+		"WHEN MATCHED THEN UPDATE ALL\n"+
+		"WHEN NOT MATCHED THEN INSERT ALL", transforms)
+}
+
+func TestIngestBuilderArity(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(&Config{})
+	tbl := c.Table("events")
+	for _, tc := range []struct {
+		builder *IngestBuilder
+		err     string
+	}{
+		{builder: c.NewIngest().Into(tbl), err: "missing select expressions"},
+		{builder: c.NewIngest().Select("$0"), err: "missing target: call Into or Merge"},
+		{
+			builder: c.NewIngest().Select("$0", "$1", "PARSE_JSON($2)").Into(tbl, "id", "message", "var"),
+			err:     "select expression 2 ($1) references $1, but the source has only column $0",
+		},
+		{
+			builder: c.NewIngest().Select("$0", `'$1'`).Into(tbl, "id"),
+			err:     "select has 2 expressions, but INSERT INTO `events` has 1 columns",
+		},
+		{
+			builder: c.NewIngest().Select("$0").Merge(MergeInto(tbl).Using("s", "a", "b").On("true").WhenNotMatched(InsertAll)),
+			err:     "select has 1 expressions, but the USING source `s` has 2 columns",
+		},
+		{
+			builder: c.NewIngest().Select("$0").Merge(MergeInto(tbl).Using("s")),
+			err:     "missing ON condition",
+		},
+	} {
+		_, err := tc.builder.Run(context.Background())
+		require.EqualError(t, err, tc.err)
+	}
+}
+
+func TestIngestBuilderTableArity(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newResultTestServer(t, []resultSetField{
+		{Name: "column_name", DataType: "string"},
+		{Name: "data_type", DataType: "string"},
+	}, [][]*string{
+		{ptr("id"), ptr("int")},
+		{ptr("message"), ptr("string")},
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	tbl := c.Table("events")
+	_, err := c.NewIngest().
+		Records(map[string]any{"id": 1}).
+		Select(`$0["id"]::int`, `$0["message"]::string`, `$0`).
+		Into(tbl).
+		Run(context.Background())
+	require.EqualError(t, err, "select has 3 expressions, but table `events` has 2 columns")
+	require.Len(t, *requests, 1)
+
+	_, err = c.NewIngest().
+		Records(map[string]any{"id": 1}).
+		Select(`$0["id"]::int`, `$0["message"]::string`).
+		Into(tbl).
+		Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, *requests, 3)
+	require.Equal(t, "/v1/ingest", (*requests)[2].URL.Path)
+}
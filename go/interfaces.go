@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// The interfaces below are small, stable views of Client intended for
+// application code to depend on, so that it can be tested with hand-rolled
+// or generated mocks instead of a server. Client implements all of them.
+//
+// New methods are not added to these interfaces; new capabilities get new
+// interfaces instead.
+var (
+	_ StatementExecutor = (*Client)(nil)
+	_ Ingestor          = (*Client)(nil)
+	_ CableFactory      = (*Client)(nil)
+)
+
+// StatementExecutor executes ScopeQL statements.
+type StatementExecutor interface {
+	// Execute executes stmt and returns its result set.
+	Execute(ctx context.Context, stmt string) (*ResultSet, error)
+}
+
+// Ingestor ingests records through ScopeQL transforms.
+type Ingestor interface {
+	// Ingest ingests the JSON-serializable records through transforms and
+	// commits them before returning.
+	Ingest(ctx context.Context, transforms string, records ...any) error
+}
+
+// CableFactory creates data cables.
+type CableFactory interface {
+	// DataCable creates a new DataCable with the specified transforms.
+	DataCable(transforms string) *DataCable
+}
+
+// Execute executes stmt with the default options and returns its result set.
+//
+// It is a shorthand for c.Statement(stmt).Execute(ctx).
+func (c *Client) Execute(ctx context.Context, stmt string) (*ResultSet, error) {
+	return c.Statement(stmt).Execute(ctx)
+}
+
+// Ingest ingests the JSON-serializable records through transforms in a single
+// request, and commits them before returning.
+//
+// The transforms follow the same rules as those of DataCable. Use a DataCable
+// to ingest a stream of records in batches.
+func (c *Client) Ingest(ctx context.Context, transforms string, records ...any) error {
+	if c.configErr != nil {
+		return c.configErr
+	}
+
+	_, err := c.ingestRecords(ctx, transforms, records)
+	return err
+}
+
+// ingestRecords ingests records through transforms in a single committed request.
+func (c *Client) ingestRecords(ctx context.Context, transforms string, records []any) (*ingestResponse, error) {
+	var rows bytes.Buffer
+	for i, record := range records {
+		bs, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		if i > 0 {
+			rows.WriteByte('\n')
+		}
+		if err := json.Compact(&rows, bs); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+
+	return c.ingest(ctx, &ingestRequest{
+		Data: ingestData{
+			Format: writeFormatJSON,
+			Rows:   rows.String(),
+		},
+		Type:      writeTypeCommitted,
+		Statement: transforms,
+	})
+}
+
+// NewResultSet creates a result set from rows encoded as ScopeDB encodes JSON
+// results: each cell is the string form of its value, or nil for NULL.
+//
+// It is intended for mocks of StatementExecutor.
+func NewResultSet(schema Schema, rows [][]*string) (*ResultSet, error) {
+	for i, row := range rows {
+		if len(row) != len(schema) {
+			return nil, fmt.Errorf("row %d has %d cells, expected %d", i, len(row), len(schema))
+		}
+	}
+	if rows == nil {
+		rows = [][]*string{}
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &ResultSet{
+		TotalRows: uint64(len(rows)),
+		Schema:    schema,
+		Format:    ResultFormatJSON,
+		rows:      data,
+	}, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockExecutor struct {
+	rs *ResultSet
+}
+
+func (m *mockExecutor) Execute(context.Context, string) (*ResultSet, error) {
+	return m.rs, nil
+}
+
+func TestNewResultSet(t *testing.T) {
+	t.Parallel()
+
+	schema := Schema{{Name: "v", Type: IntDataType}, {Name: "s", Type: StringDataType}}
+	rs, err := NewResultSet(schema, [][]*string{{ptr("1"), ptr("a")}, {ptr("2"), nil}})
+	require.NoError(t, err)
+
+	var e StatementExecutor = &mockExecutor{rs: rs}
+	actual, err := e.Execute(context.Background(), "FROM t")
+	require.NoError(t, err)
+	values, err := actual.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, [][]Value{{int64(1), "a"}, {int64(2), nil}}, values)
+
+	_, err = NewResultSet(schema, [][]*string{{ptr("1")}})
+	require.EqualError(t, err, "row 0 has 1 cells, expected 2")
+}
+
+func TestClientIngest(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	var ingestor Ingestor = c
+	require.NoError(t, ingestor.Ingest(context.Background(), "SELECT $0 INSERT INTO t (v)", map[string]int{"v": 1}, "x"))
+	_, err := c.Execute(context.Background(), "FROM t")
+	require.NoError(t, err)
+
+	recorded := requests()
+	require.Len(t, recorded, 2)
+	require.Equal(t, map[string]any{
+		"data":      map[string]any{"format": "json", "rows": "{\"v\":1}\n\"x\""},
+		"type":      "committed",
+		"statement": "SELECT $0 INSERT INTO t (v)",
+	}, recorded[0].Body)
+	require.Equal(t, "FROM t", recorded[1].Body["statement"])
+}
@@ -19,9 +19,13 @@ package itcases
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gkampitakis/go-snaps/snaps"
+	scopedb "github.com/scopedb/scopedb-sdk/go"
 	"github.com/stretchr/testify/require"
 )
 
@@ -56,7 +60,7 @@ func TestDataCable(t *testing.T) {
 	cable.BatchSize = 0
 	cable.AutoCommit = true
 
-	cable.Start(ctx)
+	require.NoError(t, cable.Start(ctx))
 	defer cable.Close()
 
 	type TestData struct {
@@ -87,3 +91,118 @@ func TestDataCable(t *testing.T) {
 	snaps.MatchSnapshot(t, result.Schema)
 	snaps.MatchSnapshot(t, records)
 }
+
+func TestDataCableTimestamp(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	_, err := c.Statement(fmt.Sprintf(`CREATE TABLE %s (ts timestamp)`, tbl.Identifier())).Execute(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	cable := c.DataCable(fmt.Sprintf(`
+		SELECT $0["ts"]::timestamp AS ts
+		INSERT INTO %s (ts)
+	`, tbl.Identifier()))
+	cable.BatchSize = 0
+	cable.AutoCommit = true
+	require.NoError(t, cable.Start(ctx))
+	defer cable.Close()
+
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.FixedZone("UTC+8", 8*3600))
+	require.NoError(t, <-cable.Send(map[string]any{"ts": scopedb.Timestamp(expected)}))
+
+	var actual time.Time
+	require.NoError(t, c.Statement(fmt.Sprintf(`FROM %s SELECT ts`, tbl.Identifier())).QueryRow(ctx).Scan(&actual))
+	require.True(t, expected.Equal(actual), "expected %s, got %s", expected, actual)
+}
+
+func TestDataCableNodeGroup(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	_, err := c.Statement(fmt.Sprintf(`CREATE TABLE %s (i int)`, tbl.Identifier())).Execute(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	cable := c.DataCable(fmt.Sprintf(`
+		SELECT $0["i"]::int AS i
+		INSERT INTO %s (i)
+	`, tbl.Identifier()))
+	cable.BatchSize = 0
+	cable.AutoCommit = true
+	cable.NodeGroup = "default"
+	require.NoError(t, cable.Start(ctx))
+	defer cable.Close()
+	require.NoError(t, <-cable.Send(map[string]any{"i": 1}))
+
+	// Find the ingest statement in the history by its target table.
+	infos, err := c.ListStatements(ctx, scopedb.ListStatementsOptions{})
+	require.NoError(t, err)
+	var ingest *scopedb.StatementInfo
+	for _, info := range infos {
+		if strings.Contains(info.Statement, "INSERT INTO "+tbl.Identifier()) {
+			ingest = info
+			break
+		}
+	}
+	require.NotNil(t, ingest, "no ingest statement into %s", tbl.Identifier())
+
+	var nodeGroup string
+	err = c.Statementf(`
+		FROM scopedb.system.statements
+		WHERE statement_id = ?
+		SELECT node_group
+	`, ingest.ID.String()).QueryRow(ctx).Scan(&nodeGroup)
+	require.NoError(t, err)
+	require.Equal(t, "default", nodeGroup)
+}
+
+func TestDataCableIntegerPrecision(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	_, err := c.Statement(fmt.Sprintf(`CREATE TABLE %s (u uint, i int)`, tbl.Identifier())).Execute(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	cable := c.DataCable(fmt.Sprintf(`
+		SELECT $0["u"]::uint AS u, $0["i"]::int AS i
+		INSERT INTO %s (u, i)
+	`, tbl.Identifier()))
+	cable.BatchSize = 0
+	cable.AutoCommit = true
+	require.NoError(t, cable.Start(ctx))
+	defer cable.Close()
+
+	type record struct {
+		U uint64 `json:"u"`
+		I int64  `json:"i"`
+	}
+	expected := []record{
+		{U: 0, I: math.MinInt64},
+		{U: 1<<53 + 1, I: -(1<<53 + 1)},
+		{U: math.MaxUint64, I: math.MaxInt64},
+	}
+	for _, r := range expected {
+		require.NoError(t, <-cable.Send(r))
+	}
+
+	result, err := c.Statement(fmt.Sprintf(`FROM %s ORDER BY u`, tbl.Identifier())).Execute(ctx)
+	require.NoError(t, err)
+	var actual []record
+	require.NoError(t, result.ToStructs(&actual))
+	require.Equal(t, expected, actual)
+}
@@ -35,7 +35,7 @@ func TestFetchStatementFail(t *testing.T) {
 	require.NoError(t, err)
 	err = c.StatementHandle(id).FetchOnce(ctx)
 	require.Error(t, err)
-	snaps.MatchSnapshot(t, err.Error())
+	snaps.MatchSnapshot(t, ErrorMessage(err))
 }
 
 func TestSubmitStatementFail(t *testing.T) {
@@ -46,5 +46,5 @@ func TestSubmitStatementFail(t *testing.T) {
 
 	_, err := c.Statement("SELECT UNKNOWN_FUNCTION()").Execute(ctx)
 	require.Error(t, err)
-	snaps.MatchSnapshot(t, err.Error())
+	snaps.MatchSnapshot(t, ErrorMessage(err))
 }
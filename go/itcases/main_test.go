@@ -17,6 +17,7 @@
 package itcases
 
 import (
+	"errors"
 	"os"
 	"strings"
 	"testing"
@@ -50,3 +51,13 @@ func RandomName(t testing.TB) string {
 	require.NoError(t, err)
 	return strings.ReplaceAll(codename.Generate(rng, 10), "-", "_")
 }
+
+// ErrorMessage returns the message of err without the request ID, which
+// differs between runs.
+func ErrorMessage(err error) string {
+	var reqErr *scopedb.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Err.Error()
+	}
+	return err.Error()
+}
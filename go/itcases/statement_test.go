@@ -0,0 +1,69 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package itcases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementNodeGroup(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	id := uuid.New()
+	s := c.Statement("VALUES (1)")
+	s.ID = &id
+	s.NodeGroup = "default"
+	rs, err := s.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), rs.TotalRows)
+
+	var nodeGroup string
+	err = c.Statementf(`
+		FROM scopedb.system.statements
+		WHERE statement_id = ?
+		SELECT node_group
+	`, id.String()).QueryRow(ctx).Scan(&nodeGroup)
+	require.NoError(t, err)
+	require.Equal(t, "default", nodeGroup)
+}
+
+func TestStatementDDL(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	for _, stmt := range []string{
+		fmt.Sprintf(`CREATE TABLE %s (i int)`, tbl.Identifier()),
+		fmt.Sprintf(`OPTIMIZE TABLE %s`, tbl.Identifier()),
+		fmt.Sprintf(`DROP TABLE %s`, tbl.Identifier()),
+	} {
+		rs, err := c.Statement(stmt).Execute(ctx)
+		require.NoError(t, err, stmt)
+		require.NotNil(t, rs, stmt)
+		values, err := rs.ToValues()
+		require.NoError(t, err, stmt)
+		require.Len(t, values, int(rs.TotalRows), stmt)
+	}
+}
@@ -19,9 +19,14 @@ package itcases
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/google/uuid"
+	scopedb "github.com/scopedb/scopedb-sdk/go"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,3 +56,187 @@ func TestTableSchema(t *testing.T) {
 	require.NoError(t, err)
 	snaps.MatchSnapshot(t, schema)
 }
+
+func FuzzQuoteRoundTrip(f *testing.F) {
+	for _, seed := range []string{"plain", "with space", "a`b", "it's", `back\slash`, "new\nline\ttab", "数据库"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if s == "" || !utf8.ValidString(s) || strings.ContainsRune(s, 0) {
+			t.Skip()
+		}
+
+		c := NewClient(t)
+		defer c.Close()
+
+		ctx := context.Background()
+		tbl := c.Table(RandomName(t))
+		_, err := c.Statement(fmt.Sprintf(`CREATE TABLE %s (%s string)`, tbl.Identifier(), scopedb.QuoteIdent(s))).Execute(ctx)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, tbl.Drop(ctx))
+		}()
+
+		schema, err := tbl.TableSchema(ctx)
+		require.NoError(t, err)
+		require.Len(t, schema, 1)
+		require.Equal(t, s, schema[0].Name)
+
+		v, err := c.QueryValue(ctx, fmt.Sprintf(`SELECT %s`, scopedb.QuoteString(s)))
+		require.NoError(t, err)
+		require.Equal(t, s, v)
+	})
+}
+
+func TestTableOptimize(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	_, err := c.Statement(fmt.Sprintf(`CREATE TABLE %s (i int)`, tbl.Identifier())).Execute(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	_, err = c.Statement(fmt.Sprintf(`VALUES (1), (2) INSERT INTO %s (i)`, tbl.Identifier())).Execute(ctx)
+	require.NoError(t, err)
+
+	result, err := tbl.Optimize(ctx, scopedb.OptimizeOptions{})
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, result.StatementID)
+}
+
+func TestTableCreate(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	columns := scopedb.Columns{
+		{Name: "ts", Type: scopedb.TimestampDataType},
+		{Name: "v", Type: scopedb.AnyDataType},
+	}
+	require.NoError(t, tbl.Create(ctx, columns, scopedb.CreateOptions{}))
+	require.NoError(t, tbl.Create(ctx, columns, scopedb.CreateOptions{IfNotExists: true}))
+
+	schema, err := tbl.TableSchema(ctx)
+	require.NoError(t, err)
+	require.Len(t, schema, 2)
+	require.Equal(t, scopedb.TimestampDataType, schema[0].Type)
+	require.Equal(t, scopedb.AnyDataType, schema[1].Type)
+
+	require.NoError(t, tbl.Drop(ctx))
+	require.NoError(t, tbl.Drop(ctx, scopedb.DropOptions{IfExists: true}))
+}
+
+func TestTableAlterColumns(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	require.NoError(t, tbl.Create(ctx, scopedb.Columns{{Name: "ts", Type: scopedb.TimestampDataType}}, scopedb.CreateOptions{}))
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	opts := scopedb.AddColumnOptions{IfNotExists: true}
+	require.NoError(t, tbl.AddColumn(ctx, "v", scopedb.AnyDataType, opts))
+	require.NoError(t, tbl.AddColumn(ctx, "v", scopedb.AnyDataType, opts))
+	require.NoError(t, tbl.RenameColumn(ctx, "v", "my v"))
+	require.NoError(t, tbl.AddColumn(ctx, "n", scopedb.IntDataType))
+	require.NoError(t, tbl.DropColumn(ctx, "n"))
+
+	schema, err := tbl.TableSchema(ctx)
+	require.NoError(t, err)
+	require.Len(t, schema, 2)
+	require.Equal(t, "my v", schema[1].Name)
+	require.Equal(t, scopedb.AnyDataType, schema[1].Type)
+}
+
+func TestTableComment(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	require.NoError(t, tbl.Create(ctx, scopedb.Columns{{Name: "v", Type: scopedb.AnyDataType}}, scopedb.CreateOptions{Comment: "created"}))
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	comment, err := tbl.Comment(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "created", comment)
+
+	for _, want := range []string{"it's \"quoted\"", "multi\nline\ttext", `back\slash`, "数据", ""} {
+		require.NoError(t, tbl.SetComment(ctx, want))
+		got, err := tbl.Comment(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+}
+
+func TestTableShowCreate(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	columns := scopedb.Columns{
+		{Name: "ts", Type: scopedb.TimestampDataType},
+		{Name: "my name", Type: scopedb.StringDataType},
+		{Name: "v", Type: scopedb.AnyDataType},
+	}
+	require.NoError(t, tbl.Create(ctx, columns, scopedb.CreateOptions{Comment: "it's a table"}))
+	want, err := tbl.TableSchema(ctx)
+	require.NoError(t, err)
+
+	ddl, err := tbl.ShowCreate(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tbl.Drop(ctx))
+	_, err = c.Statement(ddl).Execute(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	got, err := tbl.TableSchema(ctx)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	comment, err := tbl.Comment(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "it's a table", comment)
+}
+
+func TestTableEnsureSchema(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t))
+	require.NoError(t, tbl.Create(ctx, scopedb.Columns{{Name: "ts", Type: scopedb.TimestampDataType}}, scopedb.CreateOptions{}))
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	type event struct {
+		TS   time.Time `json:"ts"`
+		Name string    `json:"name"`
+	}
+	desired, err := scopedb.SchemaOf[event]()
+	require.NoError(t, err)
+
+	applied, err := tbl.EnsureSchema(ctx, desired, scopedb.EnsureOptions{AllowAdd: true})
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	applied, err = tbl.EnsureSchema(ctx, desired, scopedb.EnsureOptions{AllowAdd: true})
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	schema, err := tbl.TableSchema(ctx)
+	require.NoError(t, err)
+	require.True(t, schema.Equal(desired), schema.Diff(desired))
+}
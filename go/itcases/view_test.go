@@ -0,0 +1,66 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package itcases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	scopedb "github.com/scopedb/scopedb-sdk/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestView(t *testing.T) {
+	c := NewClient(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	tbl := c.Table(RandomName(t) + " with space")
+	require.NoError(t, tbl.Create(ctx, scopedb.Columns{
+		{Name: "ts", Type: scopedb.TimestampDataType},
+		{Name: "v", Type: scopedb.AnyDataType},
+	}, scopedb.CreateOptions{}))
+	defer func() {
+		require.NoError(t, tbl.Drop(ctx))
+	}()
+
+	view := c.View(RandomName(t))
+	query := fmt.Sprintf("FROM %s SELECT ts", tbl.Identifier())
+	require.NoError(t, view.CreateView(ctx, query, scopedb.CreateViewOptions{}))
+	defer func() {
+		require.NoError(t, view.DropView(ctx, scopedb.DropOptions{IfExists: true}))
+	}()
+
+	definition, err := view.ViewDefinition(ctx)
+	require.NoError(t, err)
+	require.Contains(t, definition, tbl.Identifier())
+
+	schema, err := view.TableSchema(ctx)
+	require.NoError(t, err)
+	require.Len(t, schema, 1)
+	require.Equal(t, scopedb.TimestampDataType, schema[0].Type)
+
+	tables, err := c.ListTables(ctx, "scopedb", "public")
+	require.NoError(t, err)
+	kinds := map[string]scopedb.TableKind{}
+	for _, table := range tables {
+		kinds[table.Table] = table.Kind
+	}
+	require.Equal(t, scopedb.TableKindTable, kinds[tbl.Table])
+	require.Equal(t, scopedb.TableKindView, kinds[view.Table])
+}
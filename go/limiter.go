@@ -0,0 +1,83 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// requestLimiter bounds the number of requests in flight; see
+// Config.MaxConcurrentRequests.
+type requestLimiter struct {
+	// slots has a buffered element for each request in flight, or is nil if
+	// the number of requests is unlimited.
+	slots    chan struct{}
+	inFlight atomic.Int64
+}
+
+func newRequestLimiter(config *Config) *requestLimiter {
+	l := &requestLimiter{}
+	if config != nil && config.MaxConcurrentRequests > 0 {
+		l.slots = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	return l
+}
+
+// acquire waits for a slot until ctx is done. The returned function releases
+// the slot; it may be called more than once.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	l.inFlight.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.inFlight.Add(-1)
+			if l.slots != nil {
+				<-l.slots
+			}
+		})
+	}, nil
+}
+
+// releaseOnClose releases the slot of a request when its response body is
+// closed, so that reading the body counts as in flight.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// InFlightRequests returns the number of requests to ScopeDB in flight,
+// including those whose response is still being read.
+func (c *Client) InFlightRequests() int {
+	return int(c.http.limiter.inFlight.Load())
+}
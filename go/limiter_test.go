@@ -0,0 +1,71 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	t.Parallel()
+
+	var inflight, peak atomic.Int32
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		<-unblock
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewClient(&Config{Endpoint: server.URL, MaxConcurrentRequests: 2})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Statement("VALUES (1)").Execute(ctx)
+			require.NoError(t, err)
+		}()
+	}
+	require.Eventually(t, func() bool { return c.InFlightRequests() == 2 && inflight.Load() == 2 }, time.Second, time.Millisecond)
+
+	// Waiting for a slot respects the context.
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Statement("VALUES (1)").Execute(waitCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, "submit statement", err.(*RequestError).Operation)
+
+	close(unblock)
+	wg.Wait()
+	require.EqualValues(t, 2, peak.Load())
+	require.Zero(t, c.InFlightRequests())
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statementf creates a new statement from a format string whose placeholders
+// are replaced with safely quoted ScopeQL literals of args in order.
+//
+// Both {} and ? are placeholders; they are ignored inside string literals,
+// quoted identifiers, and comments. Arguments are rendered as follows:
+//
+//   - nil and nil pointers become NULL.
+//   - strings are single-quoted with special characters escaped.
+//   - integers, floats, and booleans are written as is.
+//   - time.Time and Timestamp become a timestamp literal, e.g., '2024-01-01T00:00:00Z'::timestamp.
+//   - time.Duration becomes an interval literal, e.g., '1h30m0s'::interval.
+//   - []byte and Binary become a hex binary literal, e.g., 'cafe'::binary.
+//   - *big.Rat becomes an exact decimal number; rationals without a finite
+//     decimal expansion, e.g., 1/3, are an error.
+//   - other slices and arrays become a parenthesized list, e.g., (1, 2, 3), for IN clauses.
+//
+// If the format string and arguments do not match, or an argument cannot be
+// rendered, the error is returned when the statement is submitted.
+func (c *Client) Statementf(format string, args ...any) *Statement {
+	s := c.Statement("")
+	stmt, err := renderPositional(format, args)
+	s.stmt = stmt
+	if s.err == nil {
+		s.err = err
+	}
+	return s
+}
+
+// renderPositional replaces the placeholders in format with the literals of args.
+func renderPositional(format string, args []any) (string, error) {
+	return parseTemplate(format).render(args)
+}
+
+// statementTemplate is a statement text split at its placeholders.
+type statementTemplate struct {
+	// parts are the texts between placeholders; there are len(parts)-1
+	// placeholders.
+	parts []string
+	// size is the total length of parts.
+	size int
+}
+
+// parseTemplate splits format at the placeholders that are not inside
+// literals, quoted identifiers, or comments.
+func parseTemplate(format string) *statementTemplate {
+	t := &statementTemplate{size: len(format)}
+	start := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(format) && format[j] != c; j++ {
+				if format[j] == '\\' {
+					j++
+				}
+			}
+			i = min(j, len(format)-1)
+		case c == '-' && strings.HasPrefix(format[i:], "--"):
+			j := strings.IndexByte(format[i:], '\n')
+			if j < 0 {
+				j = len(format) - i - 1
+			}
+			i += j
+		case c == '/' && strings.HasPrefix(format[i:], "/*"):
+			j := strings.Index(format[i+2:], "*/")
+			if j < 0 {
+				j = len(format) - i - 1
+			} else {
+				j += 3
+			}
+			i += j
+		case c == '?' || (c == '{' && strings.HasPrefix(format[i:], "{}")):
+			t.parts = append(t.parts, format[start:i])
+			if c == '{' {
+				i++
+			}
+			start = i + 1
+		}
+	}
+	t.parts = append(t.parts, format[start:])
+	return t
+}
+
+// placeholders returns the number of placeholders in the template.
+func (t *statementTemplate) placeholders() int {
+	return len(t.parts) - 1
+}
+
+// render replaces the placeholders with the literals of args.
+func (t *statementTemplate) render(args []any) (string, error) {
+	var b strings.Builder
+	b.Grow(t.size)
+	b.WriteString(t.parts[0])
+	for i, part := range t.parts[1:] {
+		if i >= len(args) {
+			return "", fmt.Errorf("missing argument for placeholder %d", i)
+		}
+		lit, err := formatLiteral(args[i])
+		if err != nil {
+			return "", fmt.Errorf("argument %d: %w", i, err)
+		}
+		b.WriteString(lit)
+		b.WriteString(part)
+	}
+	if n := t.placeholders(); n != len(args) {
+		return "", fmt.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+	return b.String(), nil
+}
+
+// formatLiteral renders v as a ScopeQL literal.
+func formatLiteral(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return QuoteString(v), nil
+	case time.Time:
+		return QuoteString(v.UTC().Format(time.RFC3339Nano)) + "::timestamp", nil
+	case Timestamp:
+		return QuoteString(v.String()) + "::timestamp", nil
+	case time.Duration:
+		return QuoteString(v.String()) + "::interval", nil
+	case []byte:
+		return QuoteString(hex.EncodeToString(v)) + "::binary", nil
+	case Binary:
+		return QuoteString(hex.EncodeToString(v)) + "::binary", nil
+	case *big.Rat:
+		if v == nil {
+			return "NULL", nil
+		}
+		return formatRat(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return formatLiteral(rv.Elem().Interface())
+	case reflect.String:
+		return formatLiteral(rv.String())
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("unsupported float value: %v", f)
+		}
+		return strconv.FormatFloat(f, 'g', -1, rv.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return "", fmt.Errorf("unsupported nil slice of type %T", v)
+		}
+		if rv.Len() == 0 {
+			return "", fmt.Errorf("unsupported empty list of type %T", v)
+		}
+		items := make([]string, rv.Len())
+		for i := range rv.Len() {
+			item, err := formatLiteral(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return "(" + strings.Join(items, ", ") + ")", nil
+	default:
+		return "", fmt.Errorf("unsupported argument type %T", v)
+	}
+}
+
+// formatRat renders v as an exact decimal number, e.g., 1.5 for 3/2. It fails
+// if v has no finite decimal expansion, i.e., its denominator has prime
+// factors other than 2 and 5.
+func formatRat(v *big.Rat) (string, error) {
+	if v.IsInt() {
+		return v.Num().String(), nil
+	}
+	// A denominator of 2^a*5^b needs max(a, b) decimal places.
+	d := new(big.Int).Set(v.Denom())
+	twos, fives := removeFactor(d, 2), removeFactor(d, 5)
+	if !d.IsInt64() || d.Int64() != 1 {
+		return "", fmt.Errorf("unsupported decimal value %s: no finite decimal expansion", v.RatString())
+	}
+	return v.FloatString(max(twos, fives)), nil
+}
+
+// removeFactor divides d by factor as many times as it is divisible, and
+// returns the number of times.
+func removeFactor(d *big.Int, factor int64) int {
+	f := big.NewInt(factor)
+	q, r := new(big.Int), new(big.Int)
+	n := 0
+	for {
+		q.QuoRem(d, f, r)
+		if r.Sign() != 0 {
+			return n
+		}
+		d.Set(q)
+		n++
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatLiteral(t *testing.T) {
+	t.Parallel()
+
+	type label string
+	for _, tc := range []struct {
+		arg      any
+		expected string
+	}{
+		{nil, "NULL"},
+		{(*int)(nil), "NULL"},
+		{"scopedb", `'scopedb'`},
+		{"it's", `'it\'s'`},
+		{`back\slash`, `'back\\slash'`},
+		{"multi\nline\r\n\ttabbed", `'multi\nline\r\n\ttabbed'`},
+		{"bell\a", `'bell\x07'`},
+		{"数据库 🚀", `'数据库 🚀'`},
+		{`"double"`, `'"double"'`},
+		{label("named"), `'named'`},
+		{ptr("pointer"), `'pointer'`},
+		{true, "true"},
+		{int8(-8), "-8"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{1.5, "1.5"},
+		{float32(0.1), "0.1"},
+		{1e21, "1e+21"},
+		{big.NewRat(3, 2), "1.5"},
+		{big.NewRat(-1, 8), "-0.125"},
+		{big.NewRat(7, 20), "0.35"},
+		{big.NewRat(42, 1), "42"},
+		{new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)), "0.000000000000000000000000000001"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("UTC+8", 8*3600)), `'2024-01-01T19:04:05.000000006Z'::timestamp`},
+		{Timestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), `'2024-01-02T03:04:05Z'::timestamp`},
+		{90 * time.Minute, `'1h30m0s'::interval`},
+		{[]byte{0xca, 0xfe}, `'cafe'::binary`},
+		{Binary{}, `''::binary`},
+		{[]string{"a", "b'c"}, `('a', 'b\'c')`},
+		{[3]int{1, 2, 3}, `(1, 2, 3)`},
+	} {
+		actual, err := formatLiteral(tc.arg)
+		require.NoError(t, err, "%#v", tc.arg)
+		require.Equal(t, tc.expected, actual, "%#v", tc.arg)
+	}
+
+	for _, arg := range []any{math.NaN(), math.Inf(1), big.NewRat(1, 3), big.NewRat(5, 6), []int{}, []int(nil), struct{}{}, map[string]int{}} {
+		_, err := formatLiteral(arg)
+		require.Error(t, err, "%#v", arg)
+	}
+}
+
+func TestRenderPositional(t *testing.T) {
+	t.Parallel()
+
+	actual, err := renderPositional(
+		"FROM t WHERE name = ? AND id IN {} AND note = '?{}' -- ?\nAND v = {} /* {} */",
+		[]any{"x'y", []int{1, 2}, 3},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "FROM t WHERE name = 'x\\'y' AND id IN (1, 2) AND note = '?{}' -- ?\nAND v = 3 /* {} */", actual)
+
+	_, err = renderPositional("SELECT ?, ?", []any{1})
+	require.EqualError(t, err, "missing argument for placeholder 1")
+	_, err = renderPositional("SELECT ?", []any{1, 2})
+	require.EqualError(t, err, "expected 1 arguments, got 2")
+	_, err = renderPositional("SELECT ?", []any{struct{}{}})
+	require.EqualError(t, err, "argument 0: unsupported argument type struct {}")
+}
+
+func TestStatementfDefersRenderError(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(&Config{Endpoint: "http://127.0.0.1:0"})
+	defer c.Close()
+
+	s := c.Statementf("SELECT {}", "x")
+	require.Equal(t, "SELECT 'x'", s.stmt)
+
+	_, err := c.Statementf("SELECT {}").Submit(t.Context())
+	require.EqualError(t, err, "missing argument for placeholder 0")
+}
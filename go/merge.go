@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MergeAction is the action of a WHEN clause of a MERGE statement.
+type MergeAction string
+
+const (
+	// UpdateAll updates all columns of the matched target row from the source
+	// row. It is valid for WhenMatched.
+	UpdateAll MergeAction = "UPDATE ALL"
+	// DeleteRow deletes the matched target row. It is valid for WhenMatched.
+	DeleteRow MergeAction = "DELETE"
+	// InsertAll inserts the source row into the target table. It is valid for
+	// WhenNotMatched.
+	InsertAll MergeAction = "INSERT ALL"
+)
+
+// MergeBuilder builds the text of a MERGE statement; see MergeInto.
+type MergeBuilder struct {
+	target  *Table
+	alias   string
+	columns []string
+	on      string
+	clauses []mergeClause
+	err     error
+}
+
+type mergeClause struct {
+	matched bool
+	and     string
+	action  MergeAction
+}
+
+// MergeInto starts a MERGE statement into target, e.g., to upsert ingested
+// rows:
+//
+//	stmt, err := scopedb.MergeInto(tbl).
+//		Using("s", "id", "message").
+//		On("`s`.`id` = " + tbl.Identifier() + ".`id`").
+//		WhenMatched("", scopedb.UpdateAll).
+//		WhenNotMatched(scopedb.InsertAll).
+//		Build()
+//
+// Identifiers are quoted; conditions are inserted as is and must be built
+// safely, e.g., with Render.
+func MergeInto(target *Table) *MergeBuilder {
+	return &MergeBuilder{target: target}
+}
+
+// Using names the source rows alias, with the given column names in order.
+func (b *MergeBuilder) Using(alias string, columns ...string) *MergeBuilder {
+	b.alias = alias
+	b.columns = columns
+	return b
+}
+
+// On sets the condition that matches source rows with target rows.
+func (b *MergeBuilder) On(condition string) *MergeBuilder {
+	b.on = condition
+	return b
+}
+
+// WhenMatched adds a WHEN MATCHED clause applying action to the matched rows
+// that also satisfy and, if not empty. The action must be UpdateAll or
+// DeleteRow.
+func (b *MergeBuilder) WhenMatched(and string, action MergeAction) *MergeBuilder {
+	if action != UpdateAll && action != DeleteRow && b.err == nil {
+		b.err = fmt.Errorf("invalid action for WHEN MATCHED: %q", action)
+	}
+	b.clauses = append(b.clauses, mergeClause{matched: true, and: and, action: action})
+	return b
+}
+
+// WhenNotMatched adds a WHEN NOT MATCHED clause applying action to the source
+// rows that match no target row. The action must be InsertAll.
+func (b *MergeBuilder) WhenNotMatched(action MergeAction) *MergeBuilder {
+	if action != InsertAll && b.err == nil {
+		b.err = fmt.Errorf("invalid action for WHEN NOT MATCHED: %q", action)
+	}
+	b.clauses = append(b.clauses, mergeClause{action: action})
+	return b
+}
+
+// Build validates the statement and returns its text.
+func (b *MergeBuilder) Build() (string, error) {
+	switch {
+	case b.err != nil:
+		return "", b.err
+	case b.target == nil:
+		return "", errors.New("missing MERGE target")
+	case b.alias == "":
+		return "", errors.New("missing USING source alias")
+	case strings.TrimSpace(b.on) == "":
+		return "", errors.New("missing ON condition")
+	case len(b.clauses) == 0:
+		return "", errors.New("missing WHEN clause")
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "MERGE INTO %s USING %s", b.target.Identifier(), QuoteIdent(b.alias))
+	if len(b.columns) > 0 {
+		columns := make([]string, len(b.columns))
+		for i, column := range b.columns {
+			columns[i] = QuoteIdent(column)
+		}
+		fmt.Fprintf(&s, " (%s)", strings.Join(columns, ", "))
+	}
+	fmt.Fprintf(&s, "\nON %s", b.on)
+	for _, clause := range b.clauses {
+		s.WriteString("\nWHEN ")
+		if !clause.matched {
+			s.WriteString("NOT ")
+		}
+		s.WriteString("MATCHED")
+		if clause.and != "" {
+			fmt.Fprintf(&s, " AND %s", clause.and)
+		}
+		fmt.Fprintf(&s, " THEN %s", clause.action)
+	}
+	return s.String(), nil
+}
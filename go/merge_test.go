@@ -0,0 +1,57 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeInto(t *testing.T) {
+	t.Parallel()
+
+	tbl := NewClient(&Config{}).Table("events")
+	stmt, err := MergeInto(tbl).
+		Using("s", "id", "message").
+		On("`s`.`id` = `events`.`id`").
+		WhenMatched("`s`.`message` IS NULL", DeleteRow).
+		WhenMatched("", UpdateAll).
+		WhenNotMatched(InsertAll).
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, "MERGE INTO `events` USING `s` (`id`, `message`)\n"+
+		"ON `s`.`id` = `events`.`id`\n"+
+		"WHEN MATCHED AND `s`.`message` IS NULL THEN DELETE\n"+
+		"WHEN MATCHED THEN UPDATE ALL\n"+
+		"WHEN NOT MATCHED THEN INSERT ALL", stmt)
+
+	for _, tc := range []struct {
+		builder *MergeBuilder
+		err     string
+	}{
+		{builder: MergeInto(nil).Using("s").On("true").WhenNotMatched(InsertAll), err: "missing MERGE target"},
+		{builder: MergeInto(tbl).On("true").WhenNotMatched(InsertAll), err: "missing USING source alias"},
+		{builder: MergeInto(tbl).Using("s").WhenNotMatched(InsertAll), err: "missing ON condition"},
+		{builder: MergeInto(tbl).Using("s").On("true"), err: "missing WHEN clause"},
+		{builder: MergeInto(tbl).Using("s").On("true").WhenMatched("", InsertAll), err: `invalid action for WHEN MATCHED: "INSERT ALL"`},
+		{builder: MergeInto(tbl).Using("s").On("true").WhenNotMatched(UpdateAll), err: `invalid action for WHEN NOT MATCHED: "UPDATE ALL"`},
+	} {
+		_, err := tc.builder.Build()
+		require.EqualError(t, err, tc.err)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// NDJSONWriteOptions configures ResultSet.WriteNDJSON.
+type NDJSONWriteOptions struct {
+	// RawVariants embeds the cells of array, object, and any columns as JSON
+	// values instead of JSON strings that hold the encoded value.
+	RawVariants bool
+}
+
+// WriteNDJSON writes the result set to w as newline-delimited JSON: one JSON
+// object per row, keyed by the column names in schema order.
+//
+// Int, uint, and float cells are written as JSON numbers, except NaN and
+// infinities, which JSON cannot represent and are written as strings.
+// Boolean cells are written as JSON booleans, NULL cells as null, and the
+// others as JSON strings as returned by ScopeDB, which keeps decimals exact.
+//
+// Rows are decoded and written one at a time, so the result set never needs
+// to fit in memory.
+//
+// This method is only valid if the result set is of the JSON format.
+func (rs *ResultSet) WriteNDJSON(w io.Writer, opts NDJSONWriteOptions) error {
+	if rs.Format != ResultFormatJSON {
+		return fmt.Errorf("unexpected result set format: %s", rs.Format)
+	}
+
+	keys := make([][]byte, len(rs.Schema))
+	for i, fs := range rs.Schema {
+		key, err := json.Marshal(fs.Name)
+		if err != nil {
+			return err
+		}
+		keys[i] = append(key, ':')
+	}
+
+	bw := bufio.NewWriter(w)
+	var line []byte
+	if err := rs.scanRows(func(row []*string) error {
+		line = append(line[:0], '{')
+		for i, v := range row {
+			if i > 0 {
+				line = append(line, ',')
+			}
+			line = append(line, keys[i]...)
+			var err error
+			if line, err = appendNDJSONCell(line, v, rs.Schema[i].Type, opts); err != nil {
+				return fmt.Errorf("column %q: %w", rs.Schema[i].Name, err)
+			}
+		}
+		line = append(line, '}', '\n')
+		_, err := bw.Write(line)
+		return err
+	}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// appendNDJSONCell appends the JSON value of the cell v of type typ to b.
+func appendNDJSONCell(b []byte, v *string, typ DataType, opts NDJSONWriteOptions) ([]byte, error) {
+	if v == nil {
+		return append(b, "null"...), nil
+	}
+
+	switch typ {
+	case IntDataType:
+		n, err := strconv.ParseInt(*v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(b, n, 10), nil
+	case UIntDataType:
+		n, err := strconv.ParseUint(*v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendUint(b, n, 10), nil
+	case FloatDataType:
+		f, err := parseFloat(*v)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.AppendQuote(b, strconv.FormatFloat(f, 'g', -1, 64)), nil
+		}
+		return strconv.AppendFloat(b, f, 'g', -1, 64), nil
+	case BooleanDataType:
+		t, err := strconv.ParseBool(*v)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendBool(b, t), nil
+	case ArrayDataType, ObjectDataType, AnyDataType:
+		if opts.RawVariants && json.Valid([]byte(*v)) {
+			return append(b, *v...), nil
+		}
+	default:
+	}
+
+	s, err := json.Marshal(*v)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, s...), nil
+}
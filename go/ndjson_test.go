@@ -0,0 +1,69 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultSetWriteNDJSON(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 3,
+		Schema: Schema{
+			newFieldSchema("i", "int"),
+			newFieldSchema("u", "uint"),
+			newFieldSchema("f", "float"),
+			newFieldSchema("b", "boolean"),
+			newFieldSchema("s", "string"),
+			newFieldSchema("d", "decimal(38,10)"),
+			newFieldSchema("v", "object"),
+		},
+		Format: ResultFormatJSON,
+		rows: json.RawMessage(`[
+			["-1","18446744073709551615","1.5E3","true","a \"q\"","12345678901234567890.0123456789","{\"k\":[1,2]}"],
+			[null,null,"NaN",null,null,null,null],
+			["0","0","-Infinity","false","","0","not json"]
+		]`),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, rs.WriteNDJSON(&buf, NDJSONWriteOptions{}))
+	require.Equal(t, `{"i":-1,"u":18446744073709551615,"f":1500,"b":true,"s":"a \"q\"","d":"12345678901234567890.0123456789","v":"{\"k\":[1,2]}"}
+{"i":null,"u":null,"f":"NaN","b":null,"s":null,"d":null,"v":null}
+{"i":0,"u":0,"f":"-Inf","b":false,"s":"","d":"0","v":"not json"}
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, rs.WriteNDJSON(&buf, NDJSONWriteOptions{RawVariants: true}))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		require.True(t, json.Valid([]byte(line)), line)
+	}
+	require.Contains(t, lines[0], `"v":{"k":[1,2]}}`)
+	require.Contains(t, lines[2], `"v":"not json"}`)
+
+	rs.rows = json.RawMessage(`[["x","1","1","true","","0",null]]`)
+	require.ErrorContains(t, rs.WriteNDJSON(&buf, NDJSONWriteOptions{}), `column "i": strconv.ParseInt`)
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Null is a value of type T that may be NULL. It is a destination of
+// Rows.Scan, Row.Scan, and ResultSet.ToStructs that keeps NULL apart from the
+// zero value, like sql.Null:
+//
+//	var count scopedb.Null[int64]
+//	if err := rows.Scan(&count); err != nil {
+//		return err
+//	}
+//	if count.Valid {
+//		...
+//	}
+//
+// How a cell is stored into each kind of destination:
+//
+//	destination         NULL cell            non-NULL cell
+//	T                   zero value of T      converted value
+//	*T                  nil                  pointer to converted value
+//	Null[T]             Valid is false       V is converted value, Valid is true
+//	sql.Scanner         Scan(nil)            Scan(driver value), see below
+//
+// The non-NULL value is converted to T as for a destination of type T.
+// Types that implement sql.Scanner, such as sql.NullInt64 or sql.Null, are
+// passed the cell as a driver.Value: uint64 values that fit in int64,
+// intervals, and decimals are passed as int64, int64 nanoseconds, and
+// strings, respectively, and other values are passed as is.
+//
+// Null marshals to JSON null or the JSON of V, so it can also be sent
+// through a DataCable.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NullOf returns a valid Null with the value v.
+func NullOf[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = Null[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// scanCell implements cellScanner.
+func (n *Null[T]) scanCell(src Value, fs *FieldSchema) error {
+	if src == nil {
+		*n = Null[T]{}
+		return nil
+	}
+	if err := convertAssign(reflect.ValueOf(&n.V).Elem(), src, fs); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// cellScanner is implemented by destinations that convert cells themselves.
+type cellScanner interface {
+	scanCell(src Value, fs *FieldSchema) error
+}
+
+// scanSQL stores src into a sql.Scanner as a driver.Value.
+func scanSQL(scanner sql.Scanner, src Value, fs *FieldSchema) error {
+	var v driver.Value
+	switch src := src.(type) {
+	case uint64:
+		if src > math.MaxInt64 {
+			return fmt.Errorf("value %d overflows int64", src)
+		}
+		v = int64(src)
+	case time.Duration:
+		v = int64(src)
+	case *big.Rat:
+		v = src.FloatString(fs.Scale)
+	default:
+		v = src
+	}
+	return scanner.Scan(v)
+}
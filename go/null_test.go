@@ -0,0 +1,161 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullScan(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema: Schema{
+			newFieldSchema("i", "int"),
+			newFieldSchema("s", "string"),
+			newFieldSchema("ts", "timestamp"),
+			newFieldSchema("d", "decimal(10,2)"),
+			newFieldSchema("u", "uint"),
+		},
+		Format: ResultFormatJSON,
+		rows: json.RawMessage(`[
+			["42","a","1970-01-01T00:00:01Z","1.5","7"],
+			[null,null,null,null,null]
+		]`),
+	}
+	ts := time.Unix(1, 0).UTC()
+
+	for _, tc := range []struct {
+		name     string
+		dest     func() []any
+		valid    []any
+		null     []any
+		scanFail bool
+	}{
+		{
+			name:  "values",
+			dest:  func() []any { return []any{new(int64), new(string), new(time.Time), new(string), new(uint64)} },
+			valid: []any{int64(42), "a", ts, "1.50", uint64(7)},
+			null:  []any{int64(0), "", time.Time{}, "", uint64(0)},
+		},
+		{
+			name: "pointers",
+			dest: func() []any { return []any{new(*int64), new(*string), new(*time.Time), new(*string), new(*uint64)} },
+			valid: []any{
+				ptr(int64(42)), ptr("a"), ptr(ts), ptr("1.50"), ptr(uint64(7)),
+			},
+			null: []any{(*int64)(nil), (*string)(nil), (*time.Time)(nil), (*string)(nil), (*uint64)(nil)},
+		},
+		{
+			name: "null",
+			dest: func() []any {
+				return []any{new(Null[int64]), new(Null[string]), new(Null[time.Time]), new(Null[*big.Rat]), new(Null[int32])}
+			},
+			valid: []any{
+				NullOf(int64(42)), NullOf("a"), NullOf(ts), NullOf(big.NewRat(3, 2)), NullOf(int32(7)),
+			},
+			null: []any{Null[int64]{}, Null[string]{}, Null[time.Time]{}, Null[*big.Rat]{}, Null[int32]{}},
+		},
+		{
+			name: "sql",
+			dest: func() []any {
+				return []any{new(sql.NullInt64), new(sql.NullString), new(sql.NullTime), new(sql.NullString), new(sql.Null[int64])}
+			},
+			valid: []any{
+				sql.NullInt64{Int64: 42, Valid: true},
+				sql.NullString{String: "a", Valid: true},
+				sql.NullTime{Time: ts, Valid: true},
+				sql.NullString{String: "1.50", Valid: true},
+				sql.Null[int64]{V: 7, Valid: true},
+			},
+			null: []any{sql.NullInt64{}, sql.NullString{}, sql.NullTime{}, sql.NullString{}, sql.Null[int64]{}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, err := rs.Rows()
+			require.NoError(t, err)
+			for _, expected := range [][]any{tc.valid, tc.null} {
+				require.True(t, rows.Next())
+				dest := tc.dest()
+				require.NoError(t, rows.Scan(dest...))
+				for i, d := range dest {
+					actual := reflect.ValueOf(d).Elem().Interface()
+					if rat, ok := actual.(Null[*big.Rat]); ok && rat.Valid {
+						require.Zero(t, rat.V.Cmp(expected[i].(Null[*big.Rat]).V))
+						continue
+					}
+					require.Equal(t, expected[i], actual, "column %d", i)
+				}
+			}
+		})
+	}
+
+	rows, err := rs.Rows()
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	var s string
+	var d Null[int64]
+	require.ErrorContains(t, rows.Scan(new(int64), &s, new(time.Time), &d, new(uint64)), "cannot convert *big.Rat to int64")
+	require.False(t, d.Valid)
+}
+
+func TestNullToStructs(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema:    Schema{newFieldSchema("n", "int"), newFieldSchema("v", "object")},
+		Format:    ResultFormatJSON,
+		rows:      json.RawMessage(`[["1","{\"k\":1}"],[null,null]]`),
+	}
+	type row struct {
+		N Null[int64]          `json:"n"`
+		V Null[map[string]int] `json:"v"`
+	}
+	var rows []row
+	require.NoError(t, rs.ToStructs(&rows))
+	require.Equal(t, []row{
+		{N: NullOf(int64(1)), V: NullOf(map[string]int{"k": 1})},
+		{},
+	}, rows)
+}
+
+func TestNullJSON(t *testing.T) {
+	t.Parallel()
+
+	type record struct {
+		A Null[int64]  `json:"a"`
+		B Null[string] `json:"b"`
+	}
+	data, err := json.Marshal(record{A: NullOf(int64(1))})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":1,"b":null}`, string(data))
+
+	var r record
+	require.NoError(t, json.Unmarshal([]byte(`{"a":null,"b":"x"}`), &r))
+	require.Equal(t, record{B: NullOf("x")}, r)
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a client created by New or derived by Client.With.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	config Config
+	client *http.Client
+}
+
+// New creates a new ScopeDB client for endpoint configured by opts. It is
+// equivalent to NewClient with a Config whose Endpoint is endpoint and whose
+// other fields are set by opts.
+func New(endpoint string, opts ...Option) *Client {
+	o := clientOptions{config: Config{Endpoint: endpoint}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newClient(&o.config, o.client)
+}
+
+// With returns a new client with a copy of the configuration of c modified
+// by opts, e.g., to use another default schema.
+//
+// The new client shares the HTTP client, and thus the connections, of c
+// unless WithHTTPClient replaces it, so the configuration of the transport,
+// i.e., Transport, ProxyURL, Proxy, and ForceHTTP2, is not changed by
+// WithConfig. Everything else, such as cables, the result cache, and the
+// request limit, is separate.
+func (c *Client) With(opts ...Option) *Client {
+	o := clientOptions{config: *c.config, client: c.http.client}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newClient(&o.config, o.client)
+}
+
+// WithConfig modifies the configuration with fn, for fields that have no
+// dedicated option.
+func WithConfig(fn func(config *Config)) Option {
+	return func(o *clientOptions) {
+		fn(&o.config)
+	}
+}
+
+// WithHTTPClient sends requests with client instead of one built from the
+// configuration.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) {
+		o.client = client
+	}
+}
+
+// WithToken authenticates with the API key token, replacing other
+// credentials. See Config.APIKey.
+func WithToken(token string) Option {
+	return func(o *clientOptions) {
+		o.setCredentials(token, nil, "", "")
+	}
+}
+
+// WithTokenSource authenticates with the tokens of source, replacing other
+// credentials. See Config.TokenSource.
+func WithTokenSource(source TokenSource) Option {
+	return func(o *clientOptions) {
+		o.setCredentials("", source, "", "")
+	}
+}
+
+// WithBasicAuth authenticates with username and password, replacing other
+// credentials. See Config.Username.
+func WithBasicAuth(username, password string) Option {
+	return func(o *clientOptions) {
+		o.setCredentials("", nil, username, password)
+	}
+}
+
+func (o *clientOptions) setCredentials(apiKey string, source TokenSource, username, password string) {
+	o.config.APIKey = apiKey
+	o.config.TokenSource = source
+	o.config.Username = username
+	o.config.Password = password
+}
+
+// WithCompression compresses request bodies with compression. See Config.Compression.
+func WithCompression(compression Compression) Option {
+	return func(o *clientOptions) {
+		o.config.Compression = compression
+	}
+}
+
+// WithDefaultFormat sets the default result format. See Config.DefaultResultFormat.
+func WithDefaultFormat(format ResultFormat) Option {
+	return func(o *clientOptions) {
+		o.config.DefaultResultFormat = format
+	}
+}
+
+// WithDefaultExecTimeout sets the default exec timeout, e.g., "1h". See
+// Config.DefaultExecTimeout.
+func WithDefaultExecTimeout(timeout string) Option {
+	return func(o *clientOptions) {
+		o.config.DefaultExecTimeout = timeout
+	}
+}
+
+// WithDefaultWaitTimeout sets the default wait timeout. See
+// Config.DefaultWaitTimeout.
+func WithDefaultWaitTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.config.DefaultWaitTimeout = timeout
+	}
+}
+
+// WithDatabase sets the default database of tables. See Config.Database.
+func WithDatabase(database string) Option {
+	return func(o *clientOptions) {
+		o.config.Database = database
+	}
+}
+
+// WithSchema sets the default schema of tables. See Config.Schema.
+func WithSchema(schema string) Option {
+	return func(o *clientOptions) {
+		o.config.Schema = schema
+	}
+}
+
+// WithApplicationName sets the application tag of statements. See
+// Config.ApplicationName.
+func WithApplicationName(name string) Option {
+	return func(o *clientOptions) {
+		o.config.ApplicationName = name
+	}
+}
+
+// WithMaxResultRows sets the default row limit of Statement.Execute. See
+// Config.MaxResultRows.
+func WithMaxResultRows(n uint64) Option {
+	return func(o *clientOptions) {
+		o.config.MaxResultRows = n
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		mu.Unlock()
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	httpClient := &http.Client{}
+	c := New(server.URL,
+		WithToken("key"),
+		WithHTTPClient(httpClient),
+		WithDefaultFormat(ResultFormatJSON),
+		WithDefaultExecTimeout("1h"),
+		WithDefaultWaitTimeout(10*time.Second),
+		WithSchema("logs"),
+		WithConfig(func(config *Config) { config.MaxConcurrentRequests = 4 }),
+	)
+	require.Same(t, httpClient, c.http.client)
+	require.Equal(t, &Config{
+		Endpoint:              server.URL,
+		APIKey:                "key",
+		DefaultResultFormat:   ResultFormatJSON,
+		DefaultExecTimeout:    "1h",
+		DefaultWaitTimeout:    10 * time.Second,
+		Schema:                "logs",
+		MaxConcurrentRequests: 4,
+	}, c.config)
+	require.Equal(t, "1h", c.Statement("VALUES (1)").ExecTimeout)
+	require.Equal(t, 10*time.Second, c.Statement("VALUES (1)").WaitTimeout)
+	require.Equal(t, "`logs`.`events`", c.Table("events").Identifier())
+
+	derived := c.With(WithSchema("metrics"), WithBasicAuth("user", "pw"))
+	require.Same(t, httpClient, derived.http.client)
+	require.Equal(t, "`metrics`.`events`", derived.Table("events").Identifier())
+	require.Equal(t, "`logs`.`events`", c.Table("events").Identifier())
+	require.Empty(t, derived.config.APIKey)
+
+	ctx := context.Background()
+	_, err := c.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+	_, err = derived.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"Bearer key", "Basic dXNlcjpwdw=="}, auths)
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxInFlight is the default ParallelOptions.MaxInFlight.
+	defaultMaxInFlight = 8
+	// abortCancelTimeout bounds the requests that cancel the remaining
+	// statements when ExecuteAll aborts.
+	abortCancelTimeout = 5 * time.Second
+)
+
+// ParallelOptions configures ExecuteAll.
+type ParallelOptions struct {
+	// MaxInFlight is the maximum number of statements waited for at once.
+	// The default is 8.
+	MaxInFlight int
+	// ContinueOnError keeps executing the other statements when one fails.
+	//
+	// By default, the first failure aborts ExecuteAll: statements that are
+	// not submitted yet are skipped, and those still running are cancelled
+	// on the server.
+	ContinueOnError bool
+}
+
+// StatementError is the failure of one statement of ExecuteAll or Client.SubmitBatch.
+type StatementError struct {
+	// Index is the zero-based index of the failed statement.
+	Index int
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("statement %d failed: %v", e.Index, e.Err)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteAllError is returned by ExecuteAll and Client.SubmitBatch when
+// statements fail.
+type ExecuteAllError struct {
+	// Errors are the failures of the statements, ordered by index.
+	Errors []*StatementError
+}
+
+func (e *ExecuteAllError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ExecuteAllError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ExecuteAll executes independent statements of c concurrently and returns
+// their result sets in the order of stmts.
+//
+// All statements are submitted first, so that ScopeDB runs them in parallel,
+// and then at most opts.MaxInFlight of them are waited for at once. Each
+// statement is executed as Statement.Execute would.
+//
+// If statements fail, the returned slice holds the result sets of those that
+// succeeded, nil for the others, along with an *ExecuteAllError.
+func ExecuteAll(ctx context.Context, c *Client, stmts []*Statement, opts ParallelOptions) ([]*ResultSet, error) {
+	for i, s := range stmts {
+		if s.c != c {
+			return nil, fmt.Errorf("statement %d belongs to another client", i)
+		}
+	}
+	maxInFlight := opts.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = defaultMaxInFlight
+	}
+
+	runCtx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	results := make([]*ResultSet, len(stmts))
+	errs := make([]error, len(stmts))
+	handles := make([]*StatementHandle, len(stmts))
+	fail := func(i int, err error) {
+		errs[i] = err
+		if !opts.ContinueOnError {
+			abort()
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxInFlight)
+	for i, s := range stmts {
+		if runCtx.Err() != nil {
+			break
+		}
+		h, err := s.Submit(runCtx)
+		mu.Lock()
+		if err != nil {
+			fail(i, err)
+			mu.Unlock()
+			continue
+		}
+		handles[i] = h
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var rs *ResultSet
+			var err error
+			select {
+			case sem <- struct{}{}:
+				rs, err = s.fetchResult(runCtx, h)
+				<-sem
+			case <-runCtx.Done():
+				err = runCtx.Err()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fail(i, err)
+				return
+			}
+			results[i] = rs
+		}()
+	}
+	wg.Wait()
+
+	aborted := runCtx.Err() != nil
+	if aborted {
+		cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), abortCancelTimeout)
+		defer cancel()
+		for i, h := range handles {
+			if h != nil && results[i] == nil {
+				_, _ = h.Cancel(cancelCtx)
+			}
+		}
+	}
+
+	var failures []*StatementError
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		// Skip the statements that were interrupted by the abort itself.
+		if aborted && ctx.Err() == nil && errors.Is(err, context.Canceled) {
+			continue
+		}
+		failures = append(failures, &StatementError{Index: i, Err: err})
+	}
+	if len(failures) > 0 {
+		return results, &ExecuteAllError{Errors: failures}
+	}
+	return results, nil
+}
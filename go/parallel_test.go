@@ -0,0 +1,210 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// parallelTestServer serves statements by their text: "FAIL" fails, "SLOW"
+// runs until cancelled, and any other statement finishes with its text as
+// the only cell.
+type parallelTestServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	stmts      map[string]string
+	cancelled  map[string]bool
+	inflight   atomic.Int32
+	peak       atomic.Int32
+	fetchDelay time.Duration
+}
+
+func newParallelTestServer(t *testing.T) *parallelTestServer {
+	t.Helper()
+
+	s := &parallelTestServer{stmts: map[string]string{}, cancelled: map[string]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/statements", func(w http.ResponseWriter, r *http.Request) {
+		body, err := decodeCompressedRequestBody(r)
+		require.NoError(t, err)
+		var req statementRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		id := uuid.NewString()
+		s.mu.Lock()
+		s.stmts[id] = req.Statement
+		s.mu.Unlock()
+		s.writeStatement(w, id, true)
+	})
+	mux.HandleFunc("GET /v1/statements/{id}", func(w http.ResponseWriter, r *http.Request) {
+		n := s.inflight.Add(1)
+		defer s.inflight.Add(-1)
+		for peak := s.peak.Load(); n > peak && !s.peak.CompareAndSwap(peak, n); peak = s.peak.Load() {
+		}
+		time.Sleep(s.fetchDelay)
+		s.writeStatement(w, r.PathValue("id"), false)
+	})
+	mux.HandleFunc("POST /v1/statements/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.cancelled[r.PathValue("id")] = true
+		s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status":     StatementStatusCancelled,
+			"message":    "",
+			"created_at": "2026-01-01T00:00:00Z",
+		})
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// writeStatement writes the status of the statement. Every statement is
+// running when submitted, so that it has to be fetched.
+func (s *parallelTestServer) writeStatement(w http.ResponseWriter, id string, submitted bool) {
+	s.mu.Lock()
+	stmt, cancelled := s.stmts[id], s.cancelled[id]
+	s.mu.Unlock()
+
+	resp := map[string]any{
+		"statement_id": id,
+		"created_at":   "2026-01-01T00:00:00Z",
+		"progress":     map[string]any{},
+	}
+	switch {
+	case cancelled:
+		resp["status"] = StatementStatusCancelled
+	case submitted:
+		resp["status"] = StatementStatusRunning
+	case stmt == "FAIL":
+		resp["status"] = StatementStatusFailed
+		resp["message"] = "boom"
+	case stmt == "SLOW":
+		resp["status"] = StatementStatusRunning
+	default:
+		resp["status"] = StatementStatusFinished
+		resp["result_set"] = map[string]any{
+			"metadata": map[string]any{
+				"fields":   []any{map[string]any{"name": "v", "data_type": "string"}},
+				"num_rows": 1,
+			},
+			"format": "json",
+			"rows":   [][]string{{stmt}},
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *parallelTestServer) cancelledStatements() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stmts []string
+	for id := range s.cancelled {
+		stmts = append(stmts, s.stmts[id])
+	}
+	return stmts
+}
+
+func TestExecuteAll(t *testing.T) {
+	t.Parallel()
+
+	server := newParallelTestServer(t)
+	server.fetchDelay = 10 * time.Millisecond
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	var stmts []*Statement
+	for i := range 10 {
+		stmts = append(stmts, c.Statement("VALUES "+strings.Repeat("x", i)))
+	}
+	results, err := ExecuteAll(context.Background(), c, stmts, ParallelOptions{MaxInFlight: 3})
+	require.NoError(t, err)
+	require.Len(t, results, 10)
+	for i, rs := range results {
+		v, err := rs.GetString(0, "v")
+		require.NoError(t, err)
+		require.Equal(t, "VALUES "+strings.Repeat("x", i), v)
+	}
+	require.Positive(t, server.peak.Load())
+	require.LessOrEqual(t, server.peak.Load(), int32(3))
+
+	other := NewClient(&Config{Endpoint: server.URL})
+	defer other.Close()
+	_, err = ExecuteAll(context.Background(), other, stmts, ParallelOptions{})
+	require.EqualError(t, err, "statement 0 belongs to another client")
+}
+
+func TestExecuteAllContinueOnError(t *testing.T) {
+	t.Parallel()
+
+	server := newParallelTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	stmts := []*Statement{c.Statement("A"), c.Statement("FAIL"), c.Statement("B"), c.Statement("FAIL")}
+	results, err := ExecuteAll(context.Background(), c, stmts, ParallelOptions{ContinueOnError: true})
+	var execErr *ExecuteAllError
+	require.ErrorAs(t, err, &execErr)
+	require.Len(t, execErr.Errors, 2)
+	require.Equal(t, 1, execErr.Errors[0].Index)
+	require.Equal(t, 3, execErr.Errors[1].Index)
+	require.EqualError(t, err, "statement 1 failed: boom; statement 3 failed: boom")
+	var serverErr *Error
+	require.ErrorAs(t, err, &serverErr)
+
+	require.Len(t, results, 4)
+	require.NotNil(t, results[0])
+	require.Nil(t, results[1])
+	require.NotNil(t, results[2])
+	require.Nil(t, results[3])
+	require.Empty(t, server.cancelledStatements())
+}
+
+func TestExecuteAllFailFast(t *testing.T) {
+	t.Parallel()
+
+	server := newParallelTestServer(t)
+	server.fetchDelay = 20 * time.Millisecond
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	stmts := []*Statement{c.Statement("SLOW"), c.Statement("SLOW"), c.Statement("FAIL")}
+	start := time.Now()
+	results, err := ExecuteAll(context.Background(), c, stmts, ParallelOptions{})
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	var execErr *ExecuteAllError
+	require.ErrorAs(t, err, &execErr)
+	require.Len(t, execErr.Errors, 1)
+	require.Equal(t, 2, execErr.Errors[0].Index)
+	require.False(t, errors.Is(err, context.Canceled))
+	require.Equal(t, []*ResultSet{nil, nil, nil}, results)
+	require.ElementsMatch(t, []string{"SLOW", "SLOW"}, server.cancelledStatements())
+}
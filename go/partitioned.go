@@ -0,0 +1,506 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultPartitionIdleTimeout = 5 * time.Minute // default to 5 minutes
+
+// PartitionedCable is a cable that routes records to different transforms by
+// key, e.g., to ingest events of many tables over one stream.
+//
+// Each partition is a DataCable configured from the fields of the
+// PartitionedCable, and all the partitions share a single flush scheduler. A
+// partition is created when its key is first sent, and is removed after it
+// has been idle for IdleTimeout.
+type PartitionedCable struct {
+	c *Client
+
+	resolve func(key string) string
+	// ctx is the context passed to Start, which the partitions are started with.
+	ctx context.Context
+
+	closeOnce sync.Once
+	// closeCh is closed by Close.
+	closeCh chan struct{}
+	// running is closed by Start.
+	running chan struct{}
+	// stopOnce guards closing stopped.
+	stopOnce sync.Once
+	// stopped is closed once the cable stops accepting records.
+	stopped chan struct{}
+	// done is closed once the cable is closed and all partitions are drained.
+	done chan struct{}
+	// closing is set once Close is called.
+	closing atomic.Bool
+	// drainErrsMu guards drainErrs.
+	drainErrsMu sync.Mutex
+	// drainErrs are the errors of the flushes that finished after Close.
+	drainErrs []error
+	// started is set by Start.
+	started atomic.Bool
+	// drainedRecords, spilledRecords, and undrainedRecords sum the final
+	// FlushResults of the partitions.
+	drainedRecords   atomic.Int64
+	spilledRecords   atomic.Int64
+	undrainedRecords atomic.Int64
+
+	// partitionsMu guards partitions and stopping. Senders hold the read lock
+	// while they hand a record to its partition, so that a partition is not
+	// reaped in the meantime.
+	partitionsMu sync.RWMutex
+	partitions   map[string]*cablePartition
+	// stopping is set once the cable stops accepting records; no partition is
+	// created afterwards.
+	stopping bool
+
+	// AutoCommit indicates whether the cable should automatically commit the batches
+	AutoCommit bool
+	// BatchSize is the maximum size in bytes of the batch of a partition,
+	// counting the newlines between records. Batches are also capped so that
+	// their ingest requests stay within Config.MaxRequestBytes.
+	BatchSize uint64
+	// BatchInterval is the maximum time to wait before sending the batches.
+	BatchInterval time.Duration
+	// IdleTimeout is the time after which a partition that received no records
+	// is removed. Its stats are dropped along with it.
+	IdleTimeout time.Duration
+	// DrainGracePeriod is how long the cable drains once the context passed
+	// to Start is done, as for DataCable.
+	DrainGracePeriod time.Duration
+	// OnFlush, if set, is called from a background goroutine with the key of
+	// the partition and the result of each flush, and once more with an empty
+	// key and a final FlushResult when the cable has stopped and settled all
+	// of its batches.
+	OnFlush func(key string, result FlushResult)
+	// MaxRecordBytes is the maximum size in bytes of a single JSON-encoded
+	// record. Larger records are rejected by Send with a *RecordTooLargeError.
+	// Zero means no limit.
+	MaxRecordBytes uint64
+	// NodeGroup is the node group to run the ingest statements on, e.g., "default".
+	NodeGroup string
+	// Priority is the scheduling priority of the ingest statements.
+	Priority Priority
+	// OnError, if set, is called from a background goroutine with the error of
+	// each batch that fails to be ingested.
+	OnError func(key string, err error, batch BatchInfo)
+	// UnhealthyFlushFailures and UnhealthyBufferAge configure Healthy for
+	// each partition, as for DataCable.
+	UnhealthyFlushFailures int
+	UnhealthyBufferAge     time.Duration
+	// DedupeKeyFunc, if set, deduplicates the records of each partition
+	// within a batch, as for DataCable.
+	DedupeKeyFunc func(record any) (string, bool)
+	// FlushContext, if set, derives the context of each ingest request from
+	// the context passed to Start, as for DataCable.
+	FlushContext func(ctx context.Context) context.Context
+}
+
+// cablePartition is the DataCable and stats of a key of a PartitionedCable.
+type cablePartition struct {
+	key   string
+	cable *DataCable
+	// lastSend is the time in Unix nanoseconds a record was last sent.
+	lastSend atomic.Int64
+
+	records  atomic.Uint64
+	bytes    atomic.Uint64
+	batches  atomic.Uint64
+	failures atomic.Uint64
+}
+
+// PartitionStats are the statistics of a partition of a PartitionedCable.
+type PartitionStats struct {
+	// Key is the key of the partition.
+	Key string
+	// Records is the number of records ingested.
+	Records uint64
+	// Bytes is the size in bytes of the batches ingested.
+	Bytes uint64
+	// Batches is the number of batches ingested.
+	Batches uint64
+	// Failures is the number of batches that failed to be ingested.
+	Failures uint64
+	// Buffered is the number of records waiting to be flushed.
+	Buffered int
+}
+
+// PartitionedCable creates a new PartitionedCable whose partitions ingest
+// through the transforms returned by resolve for their keys.
+//
+// The transforms follow the same rules as those of DataCable. resolve is
+// called once for each new partition; records of a key it returns an empty
+// string for fail.
+func (c *Client) PartitionedCable(resolve func(key string) string) *PartitionedCable {
+	return &PartitionedCable{
+		c:                c,
+		resolve:          resolve,
+		closeCh:          make(chan struct{}),
+		running:          make(chan struct{}),
+		stopped:          make(chan struct{}),
+		done:             make(chan struct{}),
+		partitions:       make(map[string]*cablePartition),
+		BatchSize:        defaultBatchSize,
+		BatchInterval:    defaultBatchInterval,
+		IdleTimeout:      defaultPartitionIdleTimeout,
+		DrainGracePeriod: defaultDrainGracePeriod,
+	}
+}
+
+// Start starts the PartitionedCable background task.
+//
+// As with DataCable, the cable is tracked by its Client until it is closed
+// and drained, and it drains when ctx is done. A cable can be started only
+// once; later calls return ErrCableStarted.
+func (p *PartitionedCable) Start(ctx context.Context) error {
+	if !p.started.CompareAndSwap(false, true) {
+		return ErrCableStarted
+	}
+	p.ctx = ctx
+	ticker := time.Tick(p.BatchInterval)
+
+	p.c.trackCable(p)
+	close(p.running)
+	go func() {
+		defer func() {
+			p.stop()
+			partitions := p.snapshot()
+			for _, partition := range partitions {
+				partition.cable.Close()
+			}
+			for _, partition := range partitions {
+				<-partition.cable.done
+			}
+			if p.OnFlush != nil {
+				p.OnFlush("", FlushResult{
+					Final:   true,
+					Drained: int(p.drainedRecords.Load()),
+					Spilled: int(p.spilledRecords.Load()),
+					Dropped: int(p.undrainedRecords.Load()),
+				})
+			}
+			p.c.untrackCable(p)
+			close(p.done)
+		}()
+
+		for {
+			select {
+			case now := <-ticker:
+				p.tick(now)
+				p.reap(now)
+			case <-p.closeCh:
+				return
+			case <-ctx.Done():
+				// The partitions drain on their own as they share ctx.
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// stop stops creating partitions and accepting records.
+func (p *PartitionedCable) stop() {
+	p.partitionsMu.Lock()
+	defer p.partitionsMu.Unlock()
+	p.stopping = true
+	p.stopOnce.Do(func() { close(p.stopped) })
+}
+
+// snapshot returns the current partitions.
+func (p *PartitionedCable) snapshot() []*cablePartition {
+	p.partitionsMu.RLock()
+	defer p.partitionsMu.RUnlock()
+	return slices.Collect(maps.Values(p.partitions))
+}
+
+// tick asks the partitions to flush their buffered records.
+func (p *PartitionedCable) tick(now time.Time) {
+	p.partitionsMu.RLock()
+	defer p.partitionsMu.RUnlock()
+	for _, partition := range p.partitions {
+		select {
+		case partition.cable.tick <- now:
+		default:
+		}
+	}
+}
+
+// reap closes and removes the partitions with no pending records that have
+// been idle for IdleTimeout.
+func (p *PartitionedCable) reap(now time.Time) {
+	var reaped []*cablePartition
+	p.partitionsMu.Lock()
+	for key, partition := range p.partitions {
+		if partition.cable.pending.Load() == 0 && now.Sub(time.Unix(0, partition.lastSend.Load())) >= p.IdleTimeout {
+			delete(p.partitions, key)
+			reaped = append(reaped, partition)
+		}
+	}
+	p.partitionsMu.Unlock()
+
+	for _, partition := range reaped {
+		partition.cable.Close()
+		<-partition.cable.done
+	}
+}
+
+// partition returns the partition of key, creating it if needed. It must be
+// called with the read lock of partitionsMu held, which it may release and
+// reacquire.
+func (p *PartitionedCable) partition(key string) (*cablePartition, error) {
+	for {
+		if partition, ok := p.partitions[key]; ok {
+			return partition, nil
+		}
+		p.partitionsMu.RUnlock()
+		err := p.addPartition(key)
+		p.partitionsMu.RLock()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// addPartition creates and starts the partition of key, unless it exists.
+func (p *PartitionedCable) addPartition(key string) error {
+	p.partitionsMu.Lock()
+	defer p.partitionsMu.Unlock()
+	if p.stopping {
+		return ErrCableStopped
+	}
+	if _, ok := p.partitions[key]; ok {
+		return nil
+	}
+
+	transforms := p.resolve(key)
+	if transforms == "" {
+		return fmt.Errorf("no transforms for partition %q", key)
+	}
+	partition := &cablePartition{key: key, cable: p.c.DataCable(transforms)}
+	partition.lastSend.Store(time.Now().UnixNano())
+	cable := partition.cable
+	cable.tick = make(chan time.Time, 1)
+	cable.AutoCommit = p.AutoCommit
+	cable.BatchSize = p.BatchSize
+	cable.BatchInterval = p.BatchInterval
+	cable.DrainGracePeriod = p.DrainGracePeriod
+	cable.MaxRecordBytes = p.MaxRecordBytes
+	cable.NodeGroup = p.NodeGroup
+	cable.Priority = p.Priority
+	cable.UnhealthyFlushFailures = p.UnhealthyFlushFailures
+	cable.UnhealthyBufferAge = p.UnhealthyBufferAge
+	cable.DedupeKeyFunc = p.DedupeKeyFunc
+	cable.FlushContext = p.FlushContext
+	cable.OnFlush = func(result FlushResult) { p.recordFlush(partition, result) }
+	cable.OnError = func(err error, batch BatchInfo) {
+		if p.closing.Load() {
+			p.drainErrsMu.Lock()
+			p.drainErrs = append(p.drainErrs, fmt.Errorf("partition %q: %w", key, err))
+			p.drainErrsMu.Unlock()
+		}
+		if p.OnError != nil {
+			p.OnError(key, err, batch)
+		}
+	}
+	// The partition is tracked by the cable rather than by the Client.
+	cable.started.Store(true)
+	cable.run(p.ctx)
+	p.partitions[key] = partition
+	return nil
+}
+
+// recordFlush counts the flush of a partition and reports it to OnFlush.
+// The final results of the partitions are summed up into the final result of
+// the cable.
+func (p *PartitionedCable) recordFlush(partition *cablePartition, result FlushResult) {
+	if result.Final {
+		p.drainedRecords.Add(int64(result.Drained))
+		p.spilledRecords.Add(int64(result.Spilled))
+		p.undrainedRecords.Add(int64(result.Dropped))
+		return
+	}
+	if result.Err != nil {
+		partition.failures.Add(1)
+	} else {
+		partition.records.Add(uint64(result.Records))
+		partition.bytes.Add(uint64(result.Bytes))
+		partition.batches.Add(1)
+	}
+	if p.OnFlush != nil {
+		p.OnFlush(partition.key, result)
+	}
+}
+
+// Send sends a record to the partition of key. The record should be JSON-serializable.
+//
+// Returns a channel that will be closed when the record is sent to ScopeDB, or an error occurs.
+func (p *PartitionedCable) Send(key string, record any) <-chan error {
+	var errCh <-chan error
+	if err := p.enqueue(key, func(cable *DataCable) error {
+		errCh = cable.Send(record)
+		return nil
+	}); err != nil {
+		ch := make(chan error, 1)
+		ch <- err
+		close(ch)
+		return ch
+	}
+	return errCh
+}
+
+// SendNoWait sends a record to the partition of key without waiting for it to
+// be ingested. It returns an error only if the record cannot be enqueued; the
+// ingest errors are reported to OnError.
+func (p *PartitionedCable) SendNoWait(key string, record any) error {
+	return p.enqueue(key, func(cable *DataCable) error {
+		return cable.SendNoWait(record)
+	})
+}
+
+// SendFlush sends a record to the partition of key, and returns the Flush of
+// the batch the record is added to. See DataCable.SendFlush.
+func (p *PartitionedCable) SendFlush(key string, record any) (*Flush, error) {
+	var flush *Flush
+	err := p.enqueue(key, func(cable *DataCable) (err error) {
+		flush, err = cable.SendFlush(record)
+		return err
+	})
+	return flush, err
+}
+
+// enqueue hands a record to the partition of key with send once the cable is
+// started.
+func (p *PartitionedCable) enqueue(key string, send func(cable *DataCable) error) error {
+	select {
+	case <-p.running:
+	case <-p.stopped:
+		return ErrCableStopped
+	}
+
+	p.partitionsMu.RLock()
+	defer p.partitionsMu.RUnlock()
+	partition, err := p.partition(key)
+	if err != nil {
+		return err
+	}
+	partition.lastSend.Store(time.Now().UnixNano())
+	return send(partition.cable)
+}
+
+// Stats returns the statistics of the current partitions, sorted by key.
+func (p *PartitionedCable) Stats() []PartitionStats {
+	p.partitionsMu.RLock()
+	defer p.partitionsMu.RUnlock()
+
+	stats := make([]PartitionStats, 0, len(p.partitions))
+	for _, partition := range p.partitions {
+		stats = append(stats, PartitionStats{
+			Key:      partition.key,
+			Records:  partition.records.Load(),
+			Bytes:    partition.bytes.Load(),
+			Batches:  partition.batches.Load(),
+			Failures: partition.failures.Load(),
+			Buffered: int(partition.cable.pending.Load()),
+		})
+	}
+	slices.SortFunc(stats, func(a, b PartitionStats) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return stats
+}
+
+// Healthy returns ErrCableStopped if the cable is stopped, or the error of
+// the first partition by key that is not healthy as by DataCable.Healthy.
+// Otherwise, it returns nil.
+func (p *PartitionedCable) Healthy() error {
+	select {
+	case <-p.stopped:
+		return ErrCableStopped
+	default:
+	}
+
+	p.partitionsMu.RLock()
+	defer p.partitionsMu.RUnlock()
+	keys := slices.Sorted(maps.Keys(p.partitions))
+	for _, key := range keys {
+		if err := p.partitions[key].cable.Healthy(); err != nil {
+			return fmt.Errorf("partition %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Close closes the PartitionedCable and stops sending batches.
+//
+// The records sent before Close are flushed in the background, and records
+// sent after Close fail with ErrCableStopped. Close may be called more than
+// once. Use CloseContext to wait for the flushes with a deadline.
+func (p *PartitionedCable) Close() {
+	p.closeOnce.Do(func() {
+		p.closing.Store(true)
+		p.stop()
+		close(p.closeCh)
+	})
+}
+
+// CloseContext closes the cable as Close does, and waits until the records
+// sent before are flushed or ctx is done. See DataCable.CloseContext.
+func (p *PartitionedCable) CloseContext(ctx context.Context) (dropped int, err error) {
+	p.Close()
+	var ctxErr error
+	if p.started.Load() {
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			for _, partition := range p.snapshot() {
+				partition.cable.abandon()
+			}
+			<-p.done
+		}
+	}
+	for _, partition := range p.snapshot() {
+		dropped += int(partition.cable.dropped.Load())
+	}
+	p.drainErrsMu.Lock()
+	defer p.drainErrsMu.Unlock()
+	return dropped, errors.Join(ctxErr, lastError(p.drainErrs))
+}
+
+// wait waits until the cable is closed and all batches are flushed, and
+// returns the errors of the flushes that finished after Close.
+func (p *PartitionedCable) wait(ctx context.Context) error {
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	p.drainErrsMu.Lock()
+	defer p.drainErrsMu.Unlock()
+	return errors.Join(p.drainErrs...)
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestPartitionedCable(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+
+	cable := c.PartitionedCable(func(key string) string {
+		if key == "unknown" {
+			return ""
+		}
+		return "SELECT $0 INSERT INTO " + key + " (v)"
+	})
+	cable.BatchInterval = time.Hour
+	require.NoError(t, cable.Start(context.Background()))
+
+	acks := []<-chan error{cable.Send("a", 1), cable.Send("b", 2), cable.Send("a", 3)}
+	require.EqualError(t, <-cable.Send("unknown", 4), `no transforms for partition "unknown"`)
+	require.Equal(t, []PartitionStats{{Key: "a", Buffered: 2}, {Key: "b", Buffered: 1}}, cable.Stats())
+
+	require.NoError(t, c.Shutdown(context.Background()))
+	for _, ack := range acks {
+		require.NoError(t, <-ack)
+	}
+
+	rows := map[string]any{}
+	for _, r := range requests() {
+		rows[r.Body["statement"].(string)] = r.Body["data"].(map[string]any)["rows"]
+	}
+	require.Equal(t, map[string]any{
+		"SELECT $0 INSERT INTO a (v)": "1\n3",
+		"SELECT $0 INSERT INTO b (v)": "2",
+	}, rows)
+	require.Equal(t, []PartitionStats{
+		{Key: "a", Records: 2, Bytes: 3, Batches: 1},
+		{Key: "b", Records: 1, Bytes: 1, Batches: 1},
+	}, cable.Stats())
+	server.Close()
+}
+
+func TestPartitionedCableReapsIdlePartitions(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	var resolved atomic.Int32
+	cable := c.PartitionedCable(func(key string) string {
+		resolved.Add(1)
+		return "SELECT $0 INSERT INTO t (v)"
+	})
+	cable.BatchInterval = 10 * time.Millisecond
+	cable.IdleTimeout = time.Millisecond
+	require.NoError(t, cable.Start(context.Background()))
+	defer cable.Close()
+
+	require.NoError(t, <-cable.Send("a", 1))
+	require.Eventually(t, func() bool {
+		return len(cable.Stats()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, <-cable.Send("a", 2))
+	require.Equal(t, int32(2), resolved.Load())
+}
+
+func TestPartitionedCableCloseContextAbandons(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.PartitionedCable(func(key string) string {
+		return "SELECT $0 INSERT INTO " + key + " (v)"
+	})
+	cable.BatchInterval = time.Hour
+	require.NoError(t, cable.Start(context.Background()))
+	acks := []<-chan error{cable.Send("a", 1), cable.Send("b", 2), cable.Send("a", 3)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	dropped, err := cable.CloseContext(ctx)
+	require.Equal(t, 3, dropped)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	for _, ack := range acks {
+		require.ErrorIs(t, <-ack, context.Canceled)
+	}
+}
+
+func TestPartitionedCableDrainGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := decodeCompressedRequestBody(r)
+		require.NoError(t, err)
+		var req ingestRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		if req.Statement == "SELECT $0 INSERT INTO b (v)" {
+			<-r.Context().Done()
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	var mu sync.Mutex
+	flushed := map[string]error{}
+	final := make(chan FlushResult, 1)
+	cable := c.PartitionedCable(func(key string) string {
+		return "SELECT $0 INSERT INTO " + key + " (v)"
+	})
+	cable.BatchInterval = time.Hour
+	cable.DrainGracePeriod = 50 * time.Millisecond
+	cable.OnFlush = func(key string, result FlushResult) {
+		if result.Final {
+			final <- result
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		flushed[key] = result.Err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, cable.Start(ctx))
+	acks := []<-chan error{cable.Send("a", 1), cable.Send("b", 2)}
+	cancel()
+
+	require.Equal(t, FlushResult{Final: true, Drained: 1, Dropped: 1}, <-final)
+	require.NoError(t, <-acks[0])
+	require.ErrorIs(t, <-acks[1], context.Canceled)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, flushed, 2)
+	require.NoError(t, flushed["a"])
+	require.ErrorIs(t, flushed["b"], context.Canceled)
+}
+
+func TestPartitionedCableStartTwice(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	cable := c.PartitionedCable(func(key string) string {
+		return "SELECT $0 INSERT INTO " + key + " (v)"
+	})
+	cable.BatchInterval = time.Hour
+	require.NoError(t, cable.Start(ctx))
+	require.ErrorIs(t, cable.Start(ctx), ErrCableStarted)
+	ack := cable.Send("a", 1)
+	cable.Close()
+	require.NoError(t, <-ack)
+	require.NoError(t, cable.wait(ctx))
+	require.Len(t, requests(), 1)
+}
+
+func TestPartitionedCableDedupeAndSendFlush(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.PartitionedCable(func(key string) string {
+		return "SELECT $0 INSERT INTO " + key + " (v)"
+	})
+	cable.BatchInterval = time.Hour
+	cable.DedupeKeyFunc = func(record any) (string, bool) {
+		return record.(map[string]any)["id"].(string), true
+	}
+	require.NoError(t, cable.Start(context.Background()))
+	require.NoError(t, cable.Healthy())
+
+	first, err := cable.SendFlush("a", map[string]any{"id": "x", "v": 1})
+	require.NoError(t, err)
+	second, err := cable.SendFlush("a", map[string]any{"id": "x", "v": 2})
+	require.NoError(t, err)
+	require.Same(t, first, second)
+	cable.Close()
+
+	<-first.Done()
+	require.NoError(t, first.Err())
+	require.ErrorIs(t, cable.Healthy(), ErrCableStopped)
+	require.Len(t, requests(), 1)
+	require.Equal(t, `{"id":"x","v":2}`, requests()[0].Body["data"].(map[string]any)["rows"])
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// PreparedStatement is a parameterized statement that is executed repeatedly
+// with different parameters; see Client.Prepare.
+//
+// A PreparedStatement is safe for concurrent use.
+type PreparedStatement struct {
+	c      *Client
+	stmt   string
+	tmpl   *statementTemplate
+	closed atomic.Bool
+}
+
+// Prepare prepares a parameterized statement for repeated execution.
+//
+// The placeholders are those of Statementf, and parameters are rendered as
+// its arguments. ScopeDB has no prepared statements yet, so the statement is
+// prepared on the client: the placeholders are located once, and each
+// execution only renders the parameters and submits the full text. The API
+// is expected to stay the same when ScopeDB prepares statements itself, in
+// which case ctx bounds the preparation request.
+func (c *Client) Prepare(ctx context.Context, stmt string) (*PreparedStatement, error) {
+	if c.configErr != nil {
+		return nil, c.configErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &PreparedStatement{c: c, stmt: stmt, tmpl: parseTemplate(stmt)}, nil
+}
+
+// NumParams returns the number of placeholders in the statement.
+func (p *PreparedStatement) NumParams() int {
+	return p.tmpl.placeholders()
+}
+
+// String returns the statement text with its placeholders.
+func (p *PreparedStatement) String() string {
+	return p.stmt
+}
+
+// Statement creates a new statement with the placeholders replaced by params,
+// e.g., to configure it before execution.
+//
+// If the parameters do not match the placeholders, or the prepared statement
+// is closed, the error is returned when the statement is submitted.
+func (p *PreparedStatement) Statement(params ...any) *Statement {
+	s := p.c.Statement("")
+	if p.closed.Load() {
+		s.err = ErrStatementClosed
+		return s
+	}
+	stmt, err := p.tmpl.render(params)
+	s.stmt = stmt
+	if s.err == nil {
+		s.err = err
+	}
+	return s
+}
+
+// Execute executes the statement with the placeholders replaced by params and
+// waits for its completion.
+func (p *PreparedStatement) Execute(ctx context.Context, params ...any) (*ResultSet, error) {
+	return p.Statement(params...).Execute(ctx)
+}
+
+// Close releases the prepared statement. Subsequent executions fail with
+// ErrStatementClosed.
+//
+// Close is a no-op on the server until ScopeDB prepares statements itself.
+func (p *PreparedStatement) Close(ctx context.Context) error {
+	p.closed.Store(true)
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreparedStatement(t *testing.T) {
+	t.Parallel()
+
+	var stmts []string
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		stmts = append(stmts, stmt)
+		return []resultSetField{{Name: "v", DataType: "int"}}, [][]*string{{ptr("1")}}
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	ctx := context.Background()
+
+	p, err := c.Prepare(ctx, "FROM t WHERE name = ? AND note != '?' -- {}\nAND id IN {} SELECT v")
+	require.NoError(t, err)
+	require.Equal(t, 2, p.NumParams())
+
+	rs, err := p.Execute(ctx, "it's", []int{1, 2})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, rs.TotalRows)
+	_, err = p.Execute(ctx, nil, []int{3})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"FROM t WHERE name = 'it\\'s' AND note != '?' -- {}\nAND id IN (1, 2) SELECT v",
+		"FROM t WHERE name = NULL AND note != '?' -- {}\nAND id IN (3) SELECT v",
+	}, stmts)
+
+	_, err = p.Execute(ctx, "x")
+	require.EqualError(t, err, "missing argument for placeholder 1")
+	_, err = p.Execute(ctx, "x", 1, 2)
+	require.EqualError(t, err, "expected 2 arguments, got 3")
+
+	require.NoError(t, p.Close(ctx))
+	_, err = p.Execute(ctx, "x", 1)
+	require.ErrorIs(t, err, ErrStatementClosed)
+	require.Len(t, stmts, 2)
+}
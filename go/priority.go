@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+)
+
+// Priority is the scheduling priority of a statement or an ingest.
+type Priority string
+
+const (
+	// PriorityLow is for background work such as backfills.
+	PriorityLow Priority = "low"
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = "normal"
+	// PriorityHigh is for latency-sensitive work such as interactive dashboards.
+	PriorityHigh Priority = "high"
+)
+
+// SupportsPriority reports whether ScopeDB accepts the priority of statements.
+//
+// It submits a trivial statement with PriorityLow. Servers that reject the
+// priority are detected, but servers that silently ignore it cannot be told
+// apart from servers that honor it. The result is remembered by the client,
+// which stops sending priorities once a server has rejected them.
+func (c *Client) SupportsPriority(ctx context.Context) (bool, error) {
+	if c.priorityUnsupported.Load() {
+		return false, nil
+	}
+	s := c.Statement("VALUES (1)")
+	s.Priority = PriorityLow
+	if _, err := s.Execute(ctx); err != nil {
+		return false, err
+	}
+	return !c.priorityUnsupported.Load(), nil
+}
+
+// withPriorityFallback calls send with the given priority. If the server
+// rejects the priority, the client remembers it and retries without one, so
+// that an unsupported priority never fails the request.
+func withPriorityFallback[T any](c *Client, priority Priority, send func(Priority) (T, error)) (T, error) {
+	if priority != "" && c.priorityUnsupported.Load() {
+		priority = ""
+	}
+	resp, err := send(priority)
+	if err != nil && priority != "" && isPriorityRejected(err) {
+		c.priorityUnsupported.Store(true)
+		return send("")
+	}
+	return resp, err
+}
+
+// priorityRejection matches the message of a request body with an unknown
+// priority field, e.g., "unknown field `priority`, expected one of ...".
+var priorityRejection = regexp.MustCompile("\\bunknown field `priority`")
+
+// isPriorityRejected reports whether err is the response to a request whose
+// priority field the server does not know.
+//
+// Only a 400 or 422 response about the field matches: a statement that fails
+// may echo its text, which may mention a priority column.
+func isPriorityRejected(err error) bool {
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Err == nil {
+		return false
+	}
+	if reqErr.StatusCode != http.StatusBadRequest && reqErr.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	return priorityRejection.MatchString(reqErr.Err.Error())
+}
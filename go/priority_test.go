@@ -0,0 +1,151 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	supported, err := c.SupportsPriority(ctx)
+	require.NoError(t, err)
+	require.True(t, supported)
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.Priority = PriorityHigh
+	cable.BatchInterval = time.Millisecond
+	require.NoError(t, cable.Start(ctx))
+	require.NoError(t, <-cable.Send(1))
+	cable.Close()
+
+	recorded := requests()
+	require.Equal(t, "low", recorded[0].Body["priority"])
+	require.Equal(t, "high", recorded[len(recorded)-1].Body["priority"])
+}
+
+func TestPriorityRejected(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, err := decodeCompressedRequestBody(r)
+			require.NoError(t, err)
+			mu.Lock()
+			bodies = append(bodies, string(body))
+			mu.Unlock()
+			if strings.Contains(string(body), `"priority"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]any{"message": "unknown field `priority`"})
+				return
+			}
+		}
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	s := c.Statement("VALUES (1)")
+	s.Priority = PriorityLow
+	_, err := s.Execute(ctx)
+	require.NoError(t, err)
+
+	supported, err := c.SupportsPriority(ctx)
+	require.NoError(t, err)
+	require.False(t, supported)
+
+	_, err = s.Execute(ctx)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, bodies, 3)
+	require.Contains(t, bodies[0], `"priority":"low"`)
+	require.NotContains(t, bodies[1], "priority")
+	require.NotContains(t, bodies[2], "priority")
+}
+
+func TestIsPriorityRejected(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		status   int
+		err      error
+		expected bool
+	}{
+		{http.StatusBadRequest, &Error{Message: "unknown field `priority`, expected one of `statement`, `format`"}, true},
+		{http.StatusUnprocessableEntity, errors.New("Failed to deserialize the JSON body into the target type: unknown field `priority`, expected `statement`"), true},
+		// statement errors that echo a priority column
+		{http.StatusBadRequest, &Error{Message: "column priority not found: SELECT priority FROM t"}, false},
+		{http.StatusBadRequest, &Error{Message: "syntax error at line 1: FROM t WHERE priority = 'high'"}, false},
+		{http.StatusInternalServerError, &Error{Message: "unknown field `priority`"}, false},
+	} {
+		err := &RequestError{StatusCode: tc.status, Err: tc.err}
+		require.Equal(t, tc.expected, isPriorityRejected(err), "%d %v", tc.status, tc.err)
+	}
+	require.False(t, isPriorityRejected(&Error{Message: "unknown field `priority`"}))
+}
+
+func TestPriorityStatementErrorKeepsPriority(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := decodeCompressedRequestBody(r)
+		require.NoError(t, err)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": "column priority not found"})
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	s := c.Statement("SELECT priority FROM t")
+	s.Priority = PriorityHigh
+	_, err := s.Execute(context.Background())
+	require.ErrorContains(t, err, "column priority not found")
+	require.False(t, c.priorityUnsupported.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, bodies, 1)
+	require.Contains(t, bodies[0], `"priority":"high"`)
+}
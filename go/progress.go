@@ -0,0 +1,96 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Elapsed returns the time since the statement started, or zero if it has
+// not started yet.
+func (p *StatementProgress) Elapsed() time.Duration {
+	return time.Duration(max(p.NanosFromStarted, 0))
+}
+
+// ETA estimates the remaining time from the elapsed time and TotalPercentage.
+//
+// It returns -1 if the remaining time is unknown, i.e., the statement has not
+// started or has made no progress yet, and zero if the statement is complete.
+func (p *StatementProgress) ETA() time.Duration {
+	if p.TotalPercentage >= 100 {
+		return 0
+	}
+	elapsed := p.Elapsed()
+	if elapsed == 0 || p.TotalPercentage <= 0 {
+		return -1
+	}
+	remaining := float64(elapsed) * (100 - p.TotalPercentage) / p.TotalPercentage
+	if remaining > math.MaxInt64 {
+		return -1
+	}
+	return time.Duration(remaining)
+}
+
+// RowsPerSecond returns the average number of rows scanned per second, or
+// zero if the statement has not started yet.
+func (p *StatementProgress) RowsPerSecond() float64 {
+	return perSecond(p.ScannedRows, p.Elapsed())
+}
+
+// BytesPerSecond returns the average number of uncompressed bytes scanned per
+// second, or zero if the statement has not started yet.
+func (p *StatementProgress) BytesPerSecond() float64 {
+	return perSecond(p.ScannedUncompressedBytes, p.Elapsed())
+}
+
+func perSecond(n int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / elapsed.Seconds()
+}
+
+// String returns a compact summary of the progress, e.g.,
+// "42.0% | 12.3M rows | 890 MB/s | ETA 2m10s".
+func (p *StatementProgress) String() string {
+	eta := "?"
+	if d := p.ETA(); d >= 0 {
+		eta = d.Round(time.Second).String()
+	}
+	return fmt.Sprintf("%.1f%% | %s rows | %sB/s | ETA %s",
+		p.TotalPercentage,
+		formatSI(float64(p.ScannedRows), ""),
+		formatSI(p.BytesPerSecond(), " "),
+		eta)
+}
+
+// formatSI formats n to three significant digits with a decimal SI suffix,
+// e.g., "12.3M", with sep between the number and the suffix.
+func formatSI(n float64, sep string) string {
+	const units = "KMGTPE"
+	if n < 1000 {
+		return fmt.Sprintf("%.0f%s", n, sep)
+	}
+	unit := -1
+	for n >= 999.5 && unit < len(units)-1 {
+		n /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.3g%s%c", n, sep, units[unit])
+}
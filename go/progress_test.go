@@ -0,0 +1,54 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementProgressHelpers(t *testing.T) {
+	t.Parallel()
+
+	p := &StatementProgress{
+		TotalPercentage:          40,
+		NanosFromStarted:         int64(10 * time.Second),
+		ScannedRows:              12_345_678,
+		ScannedUncompressedBytes: 8_900_000_000,
+	}
+	require.Equal(t, 10*time.Second, p.Elapsed())
+	require.Equal(t, 15*time.Second, p.ETA())
+	require.InDelta(t, 1_234_567.8, p.RowsPerSecond(), 1e-6)
+	require.InDelta(t, 890_000_000, p.BytesPerSecond(), 1e-6)
+	require.Equal(t, "40.0% | 12.3M rows | 890 MB/s | ETA 15s", p.String())
+
+	notStarted := &StatementProgress{}
+	require.Zero(t, notStarted.Elapsed())
+	require.Equal(t, time.Duration(-1), notStarted.ETA())
+	require.Zero(t, notStarted.RowsPerSecond())
+	require.Zero(t, notStarted.BytesPerSecond())
+	require.Equal(t, "0.0% | 0 rows | 0 B/s | ETA ?", notStarted.String())
+
+	noEstimate := &StatementProgress{NanosFromStarted: int64(time.Second), ScannedRows: 999}
+	require.Equal(t, time.Duration(-1), noEstimate.ETA())
+	require.Equal(t, "0.0% | 999 rows | 0 B/s | ETA ?", noEstimate.String())
+
+	done := &StatementProgress{TotalPercentage: 100, NanosFromStarted: int64(time.Minute)}
+	require.Zero(t, done.ETA())
+}
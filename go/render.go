@@ -0,0 +1,293 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultRenderColumnWidth = 40
+	defaultRenderRows        = 100
+)
+
+// RenderOptions configures ResultSet.Render.
+type RenderOptions struct {
+	// MaxColumnWidth is the maximum display width of a cell in terminal
+	// columns, where East Asian wide characters take two columns; longer
+	// values are truncated with an ellipsis.
+	//
+	// If zero, 40 is used. A negative value disables truncation.
+	MaxColumnWidth int
+	// MaxRows is the maximum number of rows to render; the number of elided
+	// rows is reported in a footer.
+	//
+	// If zero, 100 is used. A negative value renders all rows.
+	MaxRows int
+	// NullString is rendered for NULL cells. The default is "NULL".
+	NullString string
+}
+
+// Render writes the result set to w as an aligned ASCII table for debugging
+// and interactive display.
+//
+// Cells are rendered as returned by ScopeDB; control characters such as
+// newlines are escaped so that each row occupies a single line.
+//
+// This method is only valid if the result set is of the JSON format.
+func (rs *ResultSet) Render(w io.Writer, opts RenderOptions) error {
+	if rs.Format != ResultFormatJSON {
+		return fmt.Errorf("unexpected result set format: %s", rs.Format)
+	}
+
+	maxWidth := opts.MaxColumnWidth
+	if maxWidth == 0 {
+		maxWidth = defaultRenderColumnWidth
+	}
+	maxRows := opts.MaxRows
+	if maxRows == 0 {
+		maxRows = defaultRenderRows
+	}
+	nullString := opts.NullString
+	if nullString == "" {
+		nullString = "NULL"
+	}
+
+	header := make([]string, len(rs.Schema))
+	widths := make([]int, len(rs.Schema))
+	for i, fs := range rs.Schema {
+		header[i] = renderCell(fs.Name, maxWidth)
+		widths[i] = displayWidth(header[i])
+	}
+
+	var cells [][]string
+	var elided int
+	if err := rs.scanRows(func(row []*string) error {
+		if maxRows >= 0 && len(cells) >= maxRows {
+			elided++
+			return nil
+		}
+		line := make([]string, len(row))
+		for i, v := range row {
+			if v == nil {
+				line[i] = renderCell(nullString, maxWidth)
+			} else {
+				line[i] = renderCell(*v, maxWidth)
+			}
+			widths[i] = max(widths[i], displayWidth(line[i]))
+		}
+		cells = append(cells, line)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	writeSeparator := func() {
+		b.WriteByte('+')
+		for _, width := range widths {
+			b.WriteString(strings.Repeat("-", width+2))
+			b.WriteByte('+')
+		}
+		b.WriteByte('\n')
+	}
+	writeLine := func(line []string) {
+		b.WriteByte('|')
+		for i, cell := range line {
+			b.WriteByte(' ')
+			b.WriteString(cell)
+			b.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)))
+			b.WriteString(" |")
+		}
+		b.WriteByte('\n')
+	}
+
+	writeSeparator()
+	writeLine(header)
+	writeSeparator()
+	for _, line := range cells {
+		writeLine(line)
+	}
+	if len(cells) > 0 {
+		writeSeparator()
+	}
+	switch {
+	case elided == 1:
+		b.WriteString("… 1 more row\n")
+	case elided > 1:
+		b.WriteString("… " + formatThousands(elided) + " more rows\n")
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// String renders the result set as an aligned ASCII table with the default RenderOptions.
+func (rs *ResultSet) String() string {
+	var b strings.Builder
+	if err := rs.Render(&b, RenderOptions{}); err != nil {
+		return fmt.Sprintf("<invalid result set: %v>", err)
+	}
+	return b.String()
+}
+
+// renderCell escapes control characters in s and truncates it to a display
+// width of maxWidth.
+func renderCell(s string, maxWidth int) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	cell := b.String()
+	if maxWidth < 0 || displayWidth(cell) <= maxWidth {
+		return cell
+	}
+	if maxWidth <= 1 {
+		return "…"
+	}
+	// Keep the runes that fit before the ellipsis. A wide rune that would
+	// straddle the limit is dropped, leaving the cell one column narrower.
+	var width int
+	for i, c := range cell {
+		width += runeWidth(c)
+		if width > maxWidth-1 {
+			return cell[:i] + "…"
+		}
+	}
+	return cell
+}
+
+// wideRunes are the East Asian Wide and Fullwidth runes, which terminals
+// display in two columns: CJK ideographs and symbols, Hangul, fullwidth
+// forms, and emoji.
+var wideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232a, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23ec, Stride: 1},
+		{Lo: 0x23f0, Hi: 0x23f0, Stride: 1},
+		{Lo: 0x23f3, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x25fd, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x267f, Hi: 0x267f, Stride: 1},
+		{Lo: 0x2693, Hi: 0x2693, Stride: 1},
+		{Lo: 0x26a1, Hi: 0x26a1, Stride: 1},
+		{Lo: 0x26aa, Hi: 0x26ab, Stride: 1},
+		{Lo: 0x26bd, Hi: 0x26be, Stride: 1},
+		{Lo: 0x26c4, Hi: 0x26c5, Stride: 1},
+		{Lo: 0x26ce, Hi: 0x26ce, Stride: 1},
+		{Lo: 0x26d4, Hi: 0x26d4, Stride: 1},
+		{Lo: 0x26ea, Hi: 0x26ea, Stride: 1},
+		{Lo: 0x26f2, Hi: 0x26f3, Stride: 1},
+		{Lo: 0x26f5, Hi: 0x26f5, Stride: 1},
+		{Lo: 0x26fa, Hi: 0x26fa, Stride: 1},
+		{Lo: 0x26fd, Hi: 0x26fd, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270a, Hi: 0x270b, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274c, Stride: 1},
+		{Lo: 0x274e, Hi: 0x274e, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27b0, Hi: 0x27b0, Stride: 1},
+		{Lo: 0x27bf, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2b55, Hi: 0x2b55, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xa960, Hi: 0xa97f, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe10, Hi: 0xfe19, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe6f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16fe0, Hi: 0x16fe4, Stride: 1},
+		{Lo: 0x17000, Hi: 0x18cff, Stride: 1},
+		{Lo: 0x1b000, Hi: 0x1b2ff, Stride: 1},
+		{Lo: 0x1f004, Hi: 0x1f004, Stride: 1},
+		{Lo: 0x1f0cf, Hi: 0x1f0cf, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f200, Hi: 0x1f251, Stride: 1},
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x1fa70, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x2fffd, Stride: 1},
+		{Lo: 0x30000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// runeWidth returns the number of terminal columns that r takes: two for
+// East Asian wide runes, zero for combining marks and format characters such
+// as zero-width joiners, and one otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(wideRunes, r):
+		return 2
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal columns that s takes.
+func displayWidth(s string) int {
+	var width int
+	for _, c := range s {
+		width += runeWidth(c)
+	}
+	return width
+}
+
+// formatThousands formats n with comma thousands separators, e.g., 9,900.
+func formatThousands(n int) string {
+	s := strconv.Itoa(n)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultSetRender(t *testing.T) {
+	t.Parallel()
+
+	rows, err := json.Marshal([][]*string{
+		{ptr("1970-01-01T00:00:00Z"), ptr("multi\nline"), ptr(`{"k":"` + strings.Repeat("v", 64) + `"}`)},
+		{nil, ptr("数据库"), ptr("27")},
+	})
+	require.NoError(t, err)
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema: Schema{
+			newFieldSchema("ts", "timestamp"),
+			newFieldSchema("name", "string"),
+			newFieldSchema("var", "any"),
+		},
+		Format: ResultFormatJSON,
+		rows:   rows,
+	}
+	snaps.MatchSnapshot(t, rs.String())
+
+	var b strings.Builder
+	require.NoError(t, rs.Render(&b, RenderOptions{MaxColumnWidth: 8, MaxRows: 1, NullString: "-"}))
+	snaps.MatchSnapshot(t, b.String())
+}
+
+func TestRenderWideCharacters(t *testing.T) {
+	t.Parallel()
+
+	rows, err := json.Marshal([][]*string{
+		{ptr("数据库"), ptr("ok")},
+		{ptr("ｆｕｌｌ"), ptr("🚀")},
+		{ptr("e\u0301"), ptr("한국어")},
+	})
+	require.NoError(t, err)
+
+	rs := &ResultSet{
+		TotalRows: 3,
+		Schema: Schema{
+			newFieldSchema("name", "string"),
+			newFieldSchema("v", "string"),
+		},
+		Format: ResultFormatJSON,
+		rows:   rows,
+	}
+	require.Equal(t, ""+
+		"+----------+--------+\n"+
+		"| name     | v      |\n"+
+		"+----------+--------+\n"+
+		"| 数据库   | ok     |\n"+
+		"| ｆｕｌｌ | 🚀     |\n"+
+		"| e\u0301        | 한국어 |\n"+
+		"+----------+--------+\n", rs.String())
+}
+
+func TestRenderCellWidth(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 6, displayWidth("数据库"))
+	require.Equal(t, 1, displayWidth("e\u0301"))
+	require.Equal(t, 2, displayWidth("👍"))
+	require.Equal(t, "数据…", renderCell("数据库", 5))
+	require.Equal(t, "数…", renderCell("数据库", 4))
+	require.Equal(t, "数据库", renderCell("数据库", 6))
+	require.Equal(t, "ab…", renderCell("abcd", 3))
+}
+
+func TestFormatThousands(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "0", formatThousands(0))
+	require.Equal(t, "999", formatThousands(999))
+	require.Equal(t, "9,900", formatThousands(9900))
+	require.Equal(t, "1,234,567", formatThousands(1234567))
+}
@@ -17,10 +17,15 @@
 package scopedb
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -35,8 +40,19 @@ type ResultSet struct {
 	Schema Schema
 	// Format is the result format of the result set.
 	Format ResultFormat
+	// Offset is the index of the first row of this result set in the whole
+	// statement result. It is nonzero only for pages fetched with FetchPage.
+	Offset uint64
+	// Truncated is true if the result set was truncated to Statement.MaxResultRows.
+	Truncated bool
+	// NaNAsNull makes ToValues, and the methods built on it, return nil instead
+	// of math.NaN() for NaN cells of float columns.
+	NaNAsNull bool
 
 	rows json.RawMessage
+	// limit is the page size that rows was fetched with; zero if rows holds
+	// every row from Offset on.
+	limit uint64
 }
 
 // ToValues reads the result set and returns the rows as a 2D array of values,
@@ -48,68 +64,414 @@ func (rs *ResultSet) ToValues() ([][]Value, error) {
 		return nil, fmt.Errorf("unexpected result set format: %s", rs.Format)
 	}
 
-	var rows [][]*string
-	if err := json.Unmarshal(rs.rows, &rows); err != nil {
+	// The rows are decoded token by token and each cell is converted in
+	// place, and the rows share one backing array sized by numRows.
+	dec := json.NewDecoder(bytes.NewReader(rs.rows))
+	tok, err := dec.Token()
+	if err != nil {
 		return nil, err
 	}
-
-	convertValue := func(v string, typ DataType) (Value, error) {
-		switch typ {
-		case StringDataType:
-			return v, nil
-		case IntDataType:
-			return strconv.ParseInt(v, 10, 64)
-		case UIntDataType:
-			return strconv.ParseUint(v, 10, 64)
-		case FloatDataType:
-			return strconv.ParseFloat(v, 64)
-		case BooleanDataType:
-			return strconv.ParseBool(v)
-		case TimestampDataType:
-			return time.Parse(time.RFC3339Nano, v)
-		case IntervalDataType:
-			return time.ParseDuration(v)
-		case ArrayDataType, ObjectDataType, AnyDataType:
-			// represent as JSON string
-			return v, nil
-		default:
-			return nil, fmt.Errorf("unrecognized type: %s", typ)
-		}
+	if tok == nil {
+		// null rows payload
+		return [][]Value{}, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("unexpected rows payload: %v", tok)
 	}
 
-	var valueLists [][]Value
-	for _, r := range rows {
-		if len(r) != len(rs.Schema) {
-			return nil, errors.New("schema length does not match record length")
+	numCols := len(rs.Schema)
+	numRows := rs.numRowsHint(numCols)
+	valueLists := make([][]Value, 0, numRows)
+	values := make([]Value, 0, numRows*uint64(numCols))
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == nil {
+			// null row
+			if numCols != 0 {
+				return nil, errors.New("schema length does not match record length")
+			}
+			valueLists = append(valueLists, nil)
+			continue
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("unexpected row: %v", tok)
 		}
 
-		var values []Value
-		for i, v := range r {
-			fs := rs.Schema[i]
-			if v == nil {
+		start := len(values)
+		for dec.More() {
+			i := len(values) - start
+			if i >= numCols {
+				return nil, errors.New("schema length does not match record length")
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch v := tok.(type) {
+			case nil:
 				values = append(values, nil)
-			} else {
-				val, err := convertValue(*v, fs.Type)
+			case string:
+				val, err := convertValue(v, rs.Schema[i].Type)
 				if err != nil {
 					return nil, err
 				}
+				if f, ok := val.(float64); ok && rs.NaNAsNull && math.IsNaN(f) {
+					val = nil
+				}
 				values = append(values, val)
+			default:
+				return nil, fmt.Errorf("unexpected cell: %v", tok)
 			}
 		}
-		valueLists = append(valueLists, values)
+		if len(values)-start != numCols {
+			return nil, errors.New("schema length does not match record length")
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		valueLists = append(valueLists, values[start:len(values):len(values)])
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
 	}
 	return valueLists, nil
 }
 
+// parseFloat parses the textual form of a float cell. Besides decimal and
+// exponent notation, ScopeDB renders NaN and infinities as "NaN", "inf",
+// "Infinity", with an optional sign, which JSON numbers cannot represent.
+func parseFloat(v string) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err == nil {
+		return f, nil
+	}
+	// strconv.ParseFloat rejects a signed NaN.
+	if len(v) > 1 && (v[0] == '-' || v[0] == '+') && strings.EqualFold(v[1:], "nan") {
+		return math.NaN(), nil
+	}
+	return 0, err
+}
+
+// maxPreallocatedRows caps the rows that ToValues allocates for up front, so
+// that a bogus TotalRows cannot exhaust memory.
+const maxPreallocatedRows = 1 << 20
+
+// numRowsHint returns an upper bound of the rows in the rows payload, for
+// preallocation: the rows left from Offset, at most the page limit, and at
+// most what the payload can hold given that a row of numCols cells takes at
+// least 2*(numCols+1) bytes, e.g., `[""],` for one cell.
+func (rs *ResultSet) numRowsHint(numCols int) uint64 {
+	n := rs.TotalRows - min(rs.Offset, rs.TotalRows)
+	if rs.limit > 0 {
+		n = min(n, rs.limit)
+	}
+	n = min(n, uint64(len(rs.rows)/(2*(numCols+1))))
+	return min(n, maxPreallocatedRows)
+}
+
+// convertValue converts the JSON string v of a cell to the Value of typ.
+func convertValue(v string, typ DataType) (Value, error) {
+	switch typ {
+	case StringDataType:
+		return v, nil
+	case IntDataType:
+		return strconv.ParseInt(v, 10, 64)
+	case UIntDataType:
+		return strconv.ParseUint(v, 10, 64)
+	case FloatDataType:
+		return parseFloat(v)
+	case BooleanDataType:
+		return strconv.ParseBool(v)
+	case TimestampDataType:
+		return time.Parse(time.RFC3339Nano, v)
+	case IntervalDataType:
+		return time.ParseDuration(v)
+	case BinaryDataType:
+		return hex.DecodeString(v)
+	case DecimalDataType:
+		r, ok := new(big.Rat).SetString(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid decimal value: %q", v)
+		}
+		return r, nil
+	case ArrayDataType, ObjectDataType, AnyDataType:
+		// represent as JSON string
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unrecognized type: %s", typ)
+	}
+}
+
+// numRows returns the number of rows in the rows payload.
+func (rs *ResultSet) numRows() (int, error) {
+	n := 0
+	err := rs.scanRows(func([]*string) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// RawRows returns the raw rows payload as returned by ScopeDB.
+func (rs *ResultSet) RawRows() json.RawMessage {
+	return rs.rows
+}
+
+// resultSetSerdeVersion is the version of the serialized form of ResultSet.
+//
+// Bump it whenever the serialized form changes incompatibly.
+const resultSetSerdeVersion = 1
+
+type resultSetSerde struct {
+	Version   int               `json:"version"`
+	TotalRows uint64            `json:"total_rows"`
+	Fields    []*resultSetField `json:"fields"`
+	Format    ResultFormat      `json:"format"`
+	Offset    uint64            `json:"offset,omitempty"`
+	Truncated bool              `json:"truncated,omitempty"`
+	NaNAsNull bool              `json:"nan_as_null,omitempty"`
+	Limit     uint64            `json:"limit,omitempty"`
+	Rows      json.RawMessage   `json:"rows"`
+}
+
+// NewResultSetFromJSON creates a ResultSet from the data produced by ResultSet.MarshalJSON.
+func NewResultSetFromJSON(data []byte) (*ResultSet, error) {
+	var rs ResultSet
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// The serialized form is versioned and round-trips the schema, format, total
+// rows, offset, the Truncated and NaNAsNull flags, and the raw rows payload.
+func (rs *ResultSet) MarshalJSON() ([]byte, error) {
+	fields := make([]*resultSetField, len(rs.Schema))
+	for i, fs := range rs.Schema {
+		dataType := string(fs.Type)
+		if fs.TypeInfo != nil {
+			dataType = fs.TypeInfo.Raw
+		}
+		fields[i] = &resultSetField{Name: fs.Name, DataType: dataType, Nullable: fs.Nullable}
+	}
+
+	rows := rs.rows
+	if rows == nil {
+		rows = json.RawMessage("[]")
+	}
+
+	return json.Marshal(&resultSetSerde{
+		Version:   resultSetSerdeVersion,
+		TotalRows: rs.TotalRows,
+		Fields:    fields,
+		Format:    rs.Format,
+		Offset:    rs.Offset,
+		Truncated: rs.Truncated,
+		NaNAsNull: rs.NaNAsNull,
+		Limit:     rs.limit,
+		Rows:      rows,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (rs *ResultSet) UnmarshalJSON(data []byte) error {
+	var serde resultSetSerde
+	if err := json.Unmarshal(data, &serde); err != nil {
+		return err
+	}
+	if serde.Version != resultSetSerdeVersion {
+		return fmt.Errorf("unsupported serialized result set version: %d", serde.Version)
+	}
+
+	schema := make(Schema, len(serde.Fields))
+	for i, field := range serde.Fields {
+		schema[i] = newFieldSchema(field.Name, field.DataType)
+		schema[i].Nullable = field.Nullable
+	}
+
+	*rs = ResultSet{
+		TotalRows: serde.TotalRows,
+		Schema:    schema,
+		Format:    serde.Format,
+		Offset:    serde.Offset,
+		Truncated: serde.Truncated,
+		NaNAsNull: serde.NaNAsNull,
+		rows:      serde.Rows,
+		limit:     serde.Limit,
+	}
+	return nil
+}
+
+// scanRows decodes the JSON rows one at a time and calls fn with each row.
+//
+// The slice passed to fn is reused between calls.
+func (rs *ResultSet) scanRows(fn func(row []*string) error) error {
+	dec := json.NewDecoder(bytes.NewReader(rs.rows))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		// null rows payload
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("unexpected rows payload: %v", tok)
+	}
+
+	var row []*string
+	for dec.More() {
+		row = row[:0]
+		if err := dec.Decode(&row); err != nil {
+			return err
+		}
+		if len(row) != len(rs.Schema) {
+			return errors.New("schema length does not match record length")
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// ColumnIndex returns the index of the column with the given name.
+//
+// It returns false if no column or more than one column matches the name.
+func (rs *ResultSet) ColumnIndex(name string, opts ...ColumnOption) (int, bool) {
+	i, err := rs.Schema.FieldIndex(name, opts...)
+	return i, err == nil
+}
+
+// Column reads the result set and returns the converted values of the column
+// with the given name.
+//
+// This method is only valid if the result set is of the JSON format.
+func (rs *ResultSet) Column(name string, opts ...ColumnOption) ([]Value, error) {
+	i, err := rs.Schema.FieldIndex(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := rs.ToValues()
+	if err != nil {
+		return nil, err
+	}
+	column := make([]Value, len(rows))
+	for j, row := range rows {
+		column[j] = row[i]
+	}
+	return column, nil
+}
+
 // Schema describes the fields in a table or query result.
 type Schema []*FieldSchema
 
+// ColumnOption configures how a column is looked up by name.
+type ColumnOption func(*columnOptions)
+
+type columnOptions struct {
+	ignoreCase bool
+}
+
+// IgnoreCase matches column names case-insensitively.
+func IgnoreCase() ColumnOption {
+	return func(o *columnOptions) {
+		o.ignoreCase = true
+	}
+}
+
+// FieldIndex returns the index of the field with the given name.
+//
+// Names are matched exactly unless IgnoreCase is given, in which case an exact
+// match still takes precedence over case-insensitive ones. ErrColumnNotFound is
+// returned if no field matches, and ErrAmbiguousColumn if more than one does.
+func (s Schema) FieldIndex(name string, opts ...ColumnOption) (int, error) {
+	var o columnOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	index, err := s.matchField(name, func(fs *FieldSchema) bool { return fs.Name == name })
+	if index < 0 && err == nil && o.ignoreCase {
+		index, err = s.matchField(name, func(fs *FieldSchema) bool { return strings.EqualFold(fs.Name, name) })
+	}
+	if err != nil {
+		return -1, err
+	}
+	if index < 0 {
+		return -1, fmt.Errorf("%w: %q", ErrColumnNotFound, name)
+	}
+	return index, nil
+}
+
+// matchField returns the index of the only field that matches, or -1 if none does.
+func (s Schema) matchField(name string, match func(*FieldSchema) bool) (int, error) {
+	index := -1
+	for i, fs := range s {
+		if !match(fs) {
+			continue
+		}
+		if index >= 0 {
+			return -1, fmt.Errorf("%w: %q", ErrAmbiguousColumn, name)
+		}
+		index = i
+	}
+	return index, nil
+}
+
 // FieldSchema describes a single field.
 type FieldSchema struct {
 	// Name is the field name.
 	Name string
 	// Type is the field data type.
+	//
+	// For parameterized types, Type is the base type; e.g., ArrayDataType for
+	// "array(int)". See TypeInfo for the full structure.
 	Type DataType
+	// Precision is the total number of digits of a decimal field.
+	//
+	// This is only set when Type is DecimalDataType.
+	Precision int
+	// Scale is the number of digits after the decimal point of a decimal field.
+	//
+	// This is only set when Type is DecimalDataType.
+	Scale int
+	// TypeInfo is the parsed representation of the data type string, including
+	// type parameters and nested types.
+	TypeInfo *TypeInfo
+	// Nullable is true if the field may hold NULL values. It is false when
+	// ScopeDB does not report nullability.
+	Nullable bool
+}
+
+// newFieldSchema creates a FieldSchema from the field name and the data type
+// string reported by ScopeDB, e.g., "int" or "decimal(38,10)".
+func newFieldSchema(name, dataType string) *FieldSchema {
+	info := ParseTypeInfo(dataType)
+	fs := &FieldSchema{Name: name, Type: info.Kind, TypeInfo: info}
+	if info.Kind != DecimalDataType {
+		return fs
+	}
+
+	var err error
+	if len(info.Params) > 0 {
+		if fs.Precision, err = strconv.Atoi(info.Params[0]); err != nil {
+			return &FieldSchema{Name: name, Type: DataType(dataType), TypeInfo: info}
+		}
+	}
+	if len(info.Params) > 1 {
+		if fs.Scale, err = strconv.Atoi(info.Params[1]); err != nil {
+			return &FieldSchema{Name: name, Type: DataType(dataType), TypeInfo: info}
+		}
+	}
+	return fs
 }
 
 // DataType is the type of field.
@@ -136,4 +498,72 @@ const (
 	ObjectDataType DataType = "object"
 	// AnyDataType indicates the data is of any data type.
 	AnyDataType DataType = "any"
+	// DecimalDataType indicates the data is of decimal data type.
+	//
+	// Decimal values are converted to *big.Rat to avoid precision loss.
+	DecimalDataType DataType = "decimal"
+	// BinaryDataType indicates the data is of binary data type.
+	//
+	// Binary values are transferred as hex strings and converted to []byte.
+	BinaryDataType DataType = "binary"
 )
+
+// Binary is a byte slice that is marshaled as a hex string in JSON.
+//
+// The encoding/json package marshals []byte as a base64 string by default. Use
+// Binary for record fields sent via DataCable so that the transforms can cast
+// them to binary values, e.g., $0["fingerprint"]::binary.
+type Binary []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b Binary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Binary) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*b = bs
+	return nil
+}
+
+// Timestamp is a time.Time that is marshaled in JSON as the literal form that
+// ScopeDB casts to a timestamp, i.e., an RFC 3339 string in UTC with up to
+// nanosecond precision, e.g., "2024-01-01T00:00:00.123456Z".
+//
+// The encoding/json package marshals time.Time with the local offset of the
+// value, and epoch integers are ambiguous about their unit. Use Timestamp for
+// record fields sent via DataCable so that the transforms can cast them to
+// timestamp values, e.g., $0["ts"]::timestamp.
+type Timestamp time.Time
+
+// MarshalJSON implements json.Marshaler.
+func (ts Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(ts).UTC().Format(time.RFC3339Nano))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ts *Timestamp) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+	*ts = Timestamp(t)
+	return nil
+}
+
+// String returns the timestamp in the form it is marshaled.
+func (ts Timestamp) String() string {
+	return time.Time(ts).UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,468 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"math/big"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFieldSchemaParsesDecimal(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		dataType  string
+		typ       DataType
+		precision int
+		scale     int
+	}{
+		{"decimal(38,10)", DecimalDataType, 38, 10},
+		{"decimal(18)", DecimalDataType, 18, 0},
+		{"decimal", DecimalDataType, 0, 0},
+		{"int", IntDataType, 0, 0},
+		{"decimal(x,y)", "decimal(x,y)", 0, 0},
+		{"decimal(38,10,2)", "decimal(38,10,2)", 0, 0},
+	} {
+		fs := newFieldSchema("d", tc.dataType)
+		require.Equal(t, tc.typ, fs.Type, tc.dataType)
+		require.Equal(t, tc.precision, fs.Precision, tc.dataType)
+		require.Equal(t, tc.scale, fs.Scale, tc.dataType)
+		require.Equal(t, tc.dataType, fs.TypeInfo.String(), tc.dataType)
+	}
+}
+
+func TestResultSetToValuesDecimal(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema:    Schema{newFieldSchema("d", "decimal(38,10)")},
+		Format:    ResultFormatJSON,
+		rows:      json.RawMessage(`[["12345678901234567890.0123456789"],[null]]`),
+	}
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+
+	expected, ok := new(big.Rat).SetString("12345678901234567890.0123456789")
+	require.True(t, ok)
+	require.Equal(t, 0, expected.Cmp(values[0][0].(*big.Rat)))
+	require.Nil(t, values[1][0])
+
+	rs.rows = json.RawMessage(`[["not-a-number"]]`)
+	_, err = rs.ToValues()
+	require.ErrorContains(t, err, `invalid decimal value: "not-a-number"`)
+}
+
+func TestResultSetToValuesBinary(t *testing.T) {
+	t.Parallel()
+
+	large := bytes.Repeat([]byte{0x00, 0x7f, 0xff, 0x42}, 2048)
+	rows, err := json.Marshal([][]*string{
+		{ptr("")},
+		{ptr(hex.EncodeToString(large))},
+		{nil},
+	})
+	require.NoError(t, err)
+
+	rs := &ResultSet{
+		TotalRows: 3,
+		Schema:    Schema{newFieldSchema("b", "binary")},
+		Format:    ResultFormatJSON,
+		rows:      rows,
+	}
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, [][]Value{{[]byte{}}, {large}, {nil}}, values)
+}
+
+func TestBinaryJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, b := range []Binary{{}, Binary(bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 1024))} {
+		data, err := json.Marshal(b)
+		require.NoError(t, err)
+		require.JSONEq(t, strconv.Quote(hex.EncodeToString(b)), string(data))
+
+		var actual Binary
+		require.NoError(t, json.Unmarshal(data, &actual))
+		require.Equal(t, b, actual)
+	}
+}
+
+func TestTimestampJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ts := Timestamp(time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.FixedZone("UTC+8", 8*3600)))
+	data, err := json.Marshal(map[string]any{"ts": ts})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ts":"2024-01-01T19:04:05.123456Z"}`, string(data))
+
+	var actual struct{ TS Timestamp }
+	require.NoError(t, json.Unmarshal(data, &actual))
+	require.True(t, time.Time(ts).Equal(time.Time(actual.TS)))
+	require.Equal(t, "2024-01-01T19:04:05.123456Z", actual.TS.String())
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestResultSetColumnByName(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema: Schema{
+			newFieldSchema("ts", "timestamp"),
+			newFieldSchema("Name", "string"),
+			newFieldSchema("v", "int"),
+			newFieldSchema("V", "int"),
+		},
+		Format: ResultFormatJSON,
+		rows:   json.RawMessage(`[["1970-01-01T00:00:00Z","a","1","2"],["1970-01-01T00:00:01Z",null,"3","4"]]`),
+	}
+
+	i, ok := rs.ColumnIndex("Name")
+	require.True(t, ok)
+	require.Equal(t, 1, i)
+	_, ok = rs.ColumnIndex("name")
+	require.False(t, ok)
+	i, ok = rs.ColumnIndex("name", IgnoreCase())
+	require.True(t, ok)
+	require.Equal(t, 1, i)
+
+	column, err := rs.Column("Name")
+	require.NoError(t, err)
+	require.Equal(t, []Value{"a", nil}, column)
+
+	column, err = rs.Column("v")
+	require.NoError(t, err)
+	require.Equal(t, []Value{int64(1), int64(3)}, column)
+
+	column, err = rs.Column("V", IgnoreCase())
+	require.NoError(t, err)
+	require.Equal(t, []Value{int64(2), int64(4)}, column)
+	_, err = rs.Column("missing")
+	require.ErrorIs(t, err, ErrColumnNotFound)
+
+	rows, err := rs.Rows()
+	require.NoError(t, err)
+	_, err = rows.Get("v")
+	require.Error(t, err)
+
+	var names []Value
+	for rows.Next() {
+		name, err := rows.Get("name", IgnoreCase())
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	require.Equal(t, []Value{"a", nil}, names)
+	require.False(t, rows.Next())
+}
+
+func TestSchemaFieldIndexPrefersExactMatch(t *testing.T) {
+	t.Parallel()
+
+	schema := Schema{
+		newFieldSchema("v", "int"),
+		newFieldSchema("V", "int"),
+		newFieldSchema("Ab", "int"),
+		newFieldSchema("aB", "int"),
+		newFieldSchema("x", "int"),
+		newFieldSchema("x", "int"),
+	}
+
+	i, err := schema.FieldIndex("v", IgnoreCase())
+	require.NoError(t, err)
+	require.Equal(t, 0, i)
+	i, err = schema.FieldIndex("V", IgnoreCase())
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	i, err = schema.FieldIndex("aB", IgnoreCase())
+	require.NoError(t, err)
+	require.Equal(t, 3, i)
+
+	_, err = schema.FieldIndex("ab", IgnoreCase())
+	require.ErrorIs(t, err, ErrAmbiguousColumn)
+	_, err = schema.FieldIndex("x", IgnoreCase())
+	require.ErrorIs(t, err, ErrAmbiguousColumn)
+	_, err = schema.FieldIndex("ab")
+	require.ErrorIs(t, err, ErrColumnNotFound)
+}
+
+func TestResultSetJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema: Schema{
+			newFieldSchema("d", "decimal(38,10)"),
+			newFieldSchema("a", "array(int)"),
+			newFieldSchema("s", "string"),
+		},
+		Format: ResultFormatJSON,
+		rows:   json.RawMessage(`[["1.5","[1,2]","x"],[null,null,null]]`),
+	}
+
+	data, err := json.Marshal(rs)
+	require.NoError(t, err)
+
+	restored, err := NewResultSetFromJSON(data)
+	require.NoError(t, err)
+	require.Equal(t, rs, restored)
+	require.JSONEq(t, string(rs.RawRows()), string(restored.RawRows()))
+
+	expected, err := rs.ToValues()
+	require.NoError(t, err)
+	actual, err := restored.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	_, err = NewResultSetFromJSON([]byte(`{"version":42}`))
+	require.EqualError(t, err, "unsupported serialized result set version: 42")
+}
+
+func TestResultSetJSONRoundTripFlags(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 3,
+		Schema:    Schema{newFieldSchema("f", "float")},
+		Format:    ResultFormatJSON,
+		Offset:    1,
+		Truncated: true,
+		NaNAsNull: true,
+		rows:      json.RawMessage(`[["NaN"]]`),
+	}
+
+	data, err := json.Marshal(rs)
+	require.NoError(t, err)
+	restored, err := NewResultSetFromJSON(data)
+	require.NoError(t, err)
+	require.Equal(t, rs, restored)
+
+	values, err := restored.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, [][]Value{{nil}}, values)
+}
+
+func TestResultSetFieldDataTypeKey(t *testing.T) {
+	t.Parallel()
+
+	// The server encodes field metadata with snake_case keys.
+	var rs resultSet
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"fields": [{"name": "n", "data_type": "int"}], "num_rows": 1},
+		"format": "json",
+		"rows": [["1"]]
+	}`), &rs))
+	require.Equal(t, Schema{newFieldSchema("n", "int")}, rs.toResultSet().Schema)
+
+	data, err := json.Marshal(rs.toResultSet())
+	require.NoError(t, err)
+	var serde struct {
+		Fields []map[string]string `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(data, &serde))
+	require.Equal(t, []map[string]string{{"name": "n", "data_type": "int"}}, serde.Fields)
+}
+
+// newWideResultSet returns a result set of rows rows and 20 columns of mixed
+// types, where every other cell is null.
+func newWideResultSet(rows int) *ResultSet {
+	types := []DataType{StringDataType, IntDataType, FloatDataType, BooleanDataType, TimestampDataType}
+	cells := []string{`"value"`, `"42"`, `"3.14"`, `"true"`, `"2026-01-01T00:00:00Z"`}
+
+	var schema Schema
+	for i := range 20 {
+		schema = append(schema, newFieldSchema("c"+strconv.Itoa(i), string(types[i%len(types)])))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for r := range rows {
+		if r > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('[')
+		for c := range schema {
+			if c > 0 {
+				buf.WriteByte(',')
+			}
+			if (r+c)%2 == 0 {
+				buf.WriteString("null")
+			} else {
+				buf.WriteString(cells[c%len(cells)])
+			}
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteByte(']')
+
+	return &ResultSet{
+		TotalRows: uint64(rows),
+		Schema:    schema,
+		Format:    ResultFormatJSON,
+		rows:      buf.Bytes(),
+	}
+}
+
+func BenchmarkResultSetToValues(b *testing.B) {
+	rs := newWideResultSet(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := rs.ToValues(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestResultSetToValuesPreallocatesPage(t *testing.T) {
+	// Not parallel, so that other tests do not add to the measured allocations.
+
+	schema := Schema{newFieldSchema("a", "int"), newFieldSchema("b", "string")}
+	rows := json.RawMessage(`[["1","x"],["2","y"]]`)
+
+	for _, rs := range []*ResultSet{
+		// A page of a large result.
+		{TotalRows: 1 << 30, Schema: schema, Format: ResultFormatJSON, Offset: 10, rows: rows, limit: 2},
+		// A truncated result, or any payload much shorter than TotalRows.
+		{TotalRows: 1 << 30, Schema: schema, Format: ResultFormatJSON, Truncated: true, rows: rows},
+	} {
+		values, err := rs.ToValues()
+		require.NoError(t, err)
+		require.Equal(t, [][]Value{{int64(1), "x"}, {int64(2), "y"}}, values)
+		require.LessOrEqual(t, cap(values), 3)
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err = rs.ToValues()
+		runtime.ReadMemStats(&after)
+		require.NoError(t, err)
+		require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64<<10))
+	}
+}
+
+func TestResultSetToValuesMalformed(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema:    Schema{newFieldSchema("i", "int"), newFieldSchema("s", "string")},
+		Format:    ResultFormatJSON,
+	}
+	for _, tc := range []struct {
+		rows    string
+		message string
+	}{
+		{rows: `[["1"]]`, message: "schema length does not match record length"},
+		{rows: `[["1","a","b"]]`, message: "schema length does not match record length"},
+		{rows: `[null]`, message: "schema length does not match record length"},
+		{rows: `[[1,"a"]]`, message: "unexpected cell: 1"},
+		{rows: `[{"i":"1"}]`, message: "unexpected row: {"},
+		{rows: `{}`, message: "unexpected rows payload: {"},
+		{rows: `[["x","a"]]`, message: `strconv.ParseInt: parsing "x": invalid syntax`},
+		{rows: `[["1","a"]`, message: "unexpected end of JSON input"},
+	} {
+		rs.rows = json.RawMessage(tc.rows)
+		_, err := rs.ToValues()
+		require.ErrorContains(t, err, tc.message, tc.rows)
+	}
+
+	rs.rows = json.RawMessage(`null`)
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	rs.rows = json.RawMessage(`[["1",null],[null,"a"]]`)
+	values, err = rs.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, [][]Value{{int64(1), nil}, {nil, "a"}}, values)
+	values[0] = append(values[0], "extra")
+	require.Equal(t, []Value{nil, "a"}, values[1])
+}
+
+func TestResultSetToValuesWide(t *testing.T) {
+	t.Parallel()
+
+	rs := newWideResultSet(100)
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Len(t, values, 100)
+
+	var rows [][]*string
+	require.NoError(t, json.Unmarshal(rs.rows, &rows))
+	for r, row := range rows {
+		require.Len(t, values[r], len(row))
+		for c, cell := range row {
+			if cell == nil {
+				require.Nil(t, values[r][c])
+				continue
+			}
+			expected, err := convertValue(*cell, rs.Schema[c].Type)
+			require.NoError(t, err)
+			require.Equal(t, expected, values[r][c])
+		}
+	}
+}
+
+func TestResultSetToValuesFloat(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 10,
+		Schema:    Schema{newFieldSchema("f", "float")},
+		Format:    ResultFormatJSON,
+		rows: json.RawMessage(`[["1.5"],["-2.5e-3"],["6.02E23"],["-0"],["Infinity"],["-Infinity"],["inf"],` +
+			`["NaN"],["-NaN"],[null]]`),
+	}
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Len(t, values, 10)
+	require.Equal(t, 1.5, values[0][0])
+	require.Equal(t, -2.5e-3, values[1][0])
+	require.Equal(t, 6.02e23, values[2][0])
+	require.Equal(t, 0.0, values[3][0])
+	require.True(t, math.Signbit(values[3][0].(float64)))
+	require.Equal(t, math.Inf(1), values[4][0])
+	require.Equal(t, math.Inf(-1), values[5][0])
+	require.Equal(t, math.Inf(1), values[6][0])
+	require.True(t, math.IsNaN(values[7][0].(float64)))
+	require.True(t, math.IsNaN(values[8][0].(float64)))
+	require.Nil(t, values[9][0])
+
+	rs.NaNAsNull = true
+	values, err = rs.ToValues()
+	require.NoError(t, err)
+	require.Nil(t, values[7][0])
+	require.Nil(t, values[8][0])
+	require.Equal(t, math.Inf(1), values[4][0])
+
+	rs.rows = json.RawMessage(`[["NaNa"]]`)
+	_, err = rs.ToValues()
+	require.ErrorContains(t, err, `parsing "NaNa": invalid syntax`)
+}
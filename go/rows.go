@@ -0,0 +1,177 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Rows is an iterator over the converted rows of a ResultSet.
+//
+// Use Next to advance to the next row before reading the first row:
+//
+//	rows, err := result.Rows()
+//	if err != nil {
+//		return err
+//	}
+//	for rows.Next() {
+//		ts, err := rows.Get("ts")
+//		...
+//	}
+type Rows struct {
+	schema Schema
+	values [][]Value
+	pos    int
+}
+
+// Rows reads the result set and returns an iterator over its rows.
+//
+// This method is only valid if the result set is of the JSON format.
+func (rs *ResultSet) Rows() (*Rows, error) {
+	values, err := rs.ToValues()
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{
+		schema: rs.Schema,
+		values: values,
+		pos:    -1,
+	}, nil
+}
+
+// Next advances to the next row and reports whether there is one.
+func (r *Rows) Next() bool {
+	if r.pos < len(r.values) {
+		r.pos++
+	}
+	return r.pos < len(r.values)
+}
+
+// Schema returns the schema of the rows.
+func (r *Rows) Schema() Schema {
+	return r.schema
+}
+
+// Values returns the values of the current row.
+func (r *Rows) Values() []Value {
+	if r.pos < 0 || r.pos >= len(r.values) {
+		return nil
+	}
+	return r.values[r.pos]
+}
+
+// Get returns the value of the column with the given name in the current row.
+func (r *Rows) Get(name string, opts ...ColumnOption) (Value, error) {
+	i, err := r.schema.FieldIndex(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	values := r.Values()
+	if values == nil {
+		return nil, errors.New("no current row")
+	}
+	return values[i], nil
+}
+
+// Scan converts the values of the current row into dest, which must be
+// pointers with one pointer per column.
+//
+// Values are converted as ResultSet.ToStructs does. A NULL value sets the
+// destination to its zero value; scan into a pointer to a pointer or to a
+// Null to tell NULL values apart.
+func (r *Rows) Scan(dest ...any) error {
+	values := r.Values()
+	if values == nil {
+		return errors.New("no current row")
+	}
+	return scanValues(r.schema, values, dest)
+}
+
+func scanValues(schema Schema, values []Value, dest []any) error {
+	if len(dest) != len(values) {
+		return fmt.Errorf("expected %d destination arguments in Scan, got %d", len(values), len(dest))
+	}
+	for i, d := range dest {
+		dv := reflect.ValueOf(d)
+		if dv.Kind() != reflect.Pointer || dv.IsNil() {
+			return fmt.Errorf("destination %d must be a non-nil pointer, got %T", i, d)
+		}
+		if err := convertAssign(dv.Elem(), values[i], schema[i]); err != nil {
+			return fmt.Errorf("column %d (%q): %w", i, schema[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// Row is the result of Statement.QueryRow.
+type Row struct {
+	err    error
+	schema Schema
+	values []Value
+}
+
+// QueryRow executes the statement and returns its first row.
+//
+// Errors are deferred until Row.Scan is called.
+func (s *Statement) QueryRow(ctx context.Context) *Row {
+	rs, err := s.Execute(ctx)
+	if err != nil {
+		return &Row{err: err}
+	}
+	values, err := rs.ToValues()
+	if err != nil {
+		return &Row{err: err}
+	}
+	if len(values) == 0 {
+		return &Row{err: ErrNoRows}
+	}
+	return &Row{schema: rs.Schema, values: values[0]}
+}
+
+// Err returns the error, if any, that was encountered while executing the statement.
+func (r *Row) Err() error {
+	return r.err
+}
+
+// Scan converts the values of the row into dest as Rows.Scan does.
+//
+// If the result set has no rows, Scan returns ErrNoRows.
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return scanValues(r.schema, r.values, dest)
+}
+
+// QueryValue executes the statement and returns the single value of its first row.
+//
+// It returns ErrNoRows if the result set has no rows, and an error if the
+// result set has more than one column.
+func (c *Client) QueryValue(ctx context.Context, stmt string) (Value, error) {
+	row := c.Statement(stmt).QueryRow(ctx)
+	if row.err != nil {
+		return nil, row.err
+	}
+	var v Value
+	if err := row.Scan(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
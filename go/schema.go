@@ -0,0 +1,209 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaChangeKind is the kind of a SchemaChange.
+type SchemaChangeKind int
+
+const (
+	// ColumnAdded means the column exists only in the other schema.
+	ColumnAdded SchemaChangeKind = iota + 1
+	// ColumnRemoved means the column exists only in the original schema.
+	ColumnRemoved
+	// ColumnTypeChanged means the column has different types in the two schemas.
+	ColumnTypeChanged
+	// ColumnReordered means the column has a different position relative to
+	// the other columns present in both schemas.
+	ColumnReordered
+)
+
+func (k SchemaChangeKind) String() string {
+	switch k {
+	case ColumnAdded:
+		return "add"
+	case ColumnRemoved:
+		return "remove"
+	case ColumnTypeChanged:
+		return "change type"
+	case ColumnReordered:
+		return "reorder"
+	default:
+		return fmt.Sprintf("SchemaChangeKind(%d)", int(k))
+	}
+}
+
+// SchemaChange describes a difference of a single column between two schemas.
+type SchemaChange struct {
+	// Kind is the kind of the change.
+	Kind SchemaChangeKind
+	// Column is the column name.
+	Column string
+	// OldType is the column type in the original schema, or empty for ColumnAdded.
+	OldType DataType
+	// NewType is the column type in the other schema, or empty for ColumnRemoved.
+	NewType DataType
+	// OldIndex is the column position in the original schema, or -1 for ColumnAdded.
+	OldIndex int
+	// NewIndex is the column position in the other schema, or -1 for ColumnRemoved.
+	NewIndex int
+}
+
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case ColumnAdded:
+		return fmt.Sprintf("add column %s %s", QuoteIdent(c.Column), c.NewType)
+	case ColumnRemoved:
+		return fmt.Sprintf("remove column %s %s", QuoteIdent(c.Column), c.OldType)
+	case ColumnTypeChanged:
+		return fmt.Sprintf("change type of column %s from %s to %s", QuoteIdent(c.Column), c.OldType, c.NewType)
+	case ColumnReordered:
+		return fmt.Sprintf("move column %s from position %d to %d", QuoteIdent(c.Column), c.OldIndex, c.NewIndex)
+	default:
+		return fmt.Sprintf("%s column %s", c.Kind, QuoteIdent(c.Column))
+	}
+}
+
+// Equal reports whether the two schemas have the same column names and types
+// in the same order.
+//
+// Types are compared by structure, so "decimal(10, 2)" equals "decimal(10,2)".
+func (s Schema) Equal(other Schema) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for i := range s {
+		if s[i].Name != other[i].Name || canonicalType(s[i]) != canonicalType(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff returns the changes that turn s into other: columns removed from s,
+// columns added or with a changed type in other, and columns whose relative
+// order differs. Columns are matched by exact name.
+//
+// Diff returns nil if the schemas are Equal.
+func (s Schema) Diff(other Schema) []SchemaChange {
+	oldIndexes := schemaIndexes(s)
+	newIndexes := schemaIndexes(other)
+
+	var changes []SchemaChange
+	var oldCommon, newCommon []string
+	for i, fs := range s {
+		if oldIndexes[fs.Name] != i {
+			continue
+		}
+		if _, ok := newIndexes[fs.Name]; !ok {
+			changes = append(changes, SchemaChange{
+				Kind: ColumnRemoved, Column: fs.Name,
+				OldType: fs.dataType(), OldIndex: i, NewIndex: -1,
+			})
+			continue
+		}
+		oldCommon = append(oldCommon, fs.Name)
+	}
+	for j, fs := range other {
+		if newIndexes[fs.Name] != j {
+			continue
+		}
+		i, ok := oldIndexes[fs.Name]
+		if !ok {
+			changes = append(changes, SchemaChange{
+				Kind: ColumnAdded, Column: fs.Name,
+				NewType: fs.dataType(), OldIndex: -1, NewIndex: j,
+			})
+			continue
+		}
+		newCommon = append(newCommon, fs.Name)
+		if canonicalType(s[i]) != canonicalType(fs) {
+			changes = append(changes, SchemaChange{
+				Kind: ColumnTypeChanged, Column: fs.Name,
+				OldType: s[i].dataType(), NewType: fs.dataType(), OldIndex: i, NewIndex: j,
+			})
+		}
+	}
+	for k, name := range newCommon {
+		if oldCommon[k] == name {
+			continue
+		}
+		i, j := oldIndexes[name], newIndexes[name]
+		changes = append(changes, SchemaChange{
+			Kind: ColumnReordered, Column: name,
+			OldType: s[i].dataType(), NewType: other[j].dataType(), OldIndex: i, NewIndex: j,
+		})
+	}
+	return changes
+}
+
+// String returns the schema as a parenthesized list of quoted column names
+// and types, e.g., "(`ts` timestamp, `v` any)".
+func (s Schema) String() string {
+	defs := make([]string, len(s))
+	for i, fs := range s {
+		defs[i] = QuoteIdent(fs.Name) + " " + string(fs.dataType())
+	}
+	return "(" + strings.Join(defs, ", ") + ")"
+}
+
+// schemaIndexes maps each column name to the position of its first occurrence.
+func schemaIndexes(s Schema) map[string]int {
+	indexes := make(map[string]int, len(s))
+	for i := len(s) - 1; i >= 0; i-- {
+		indexes[s[i].Name] = i
+	}
+	return indexes
+}
+
+// dataType returns the full data type string of the field, e.g., "array(int)".
+func (fs *FieldSchema) dataType() DataType {
+	if fs.TypeInfo != nil {
+		return DataType(fs.TypeInfo.Raw)
+	}
+	return fs.Type
+}
+
+// canonicalType returns the data type of the field with whitespace normalized.
+func canonicalType(fs *FieldSchema) string {
+	info := fs.TypeInfo
+	if info == nil {
+		info = ParseTypeInfo(string(fs.Type))
+	}
+	return canonicalTypeInfo(info)
+}
+
+func canonicalTypeInfo(info *TypeInfo) string {
+	switch {
+	case info.Elem != nil:
+		return string(info.Kind) + "(" + canonicalTypeInfo(info.Elem) + ")"
+	case info.Fields != nil:
+		fields := make([]string, len(info.Fields))
+		for i, field := range info.Fields {
+			fields[i] = field.Name + " " + canonicalType(field)
+		}
+		return string(info.Kind) + "(" + strings.Join(fields, ",") + ")"
+	case info.Params != nil:
+		return string(info.Kind) + "(" + strings.Join(info.Params, ",") + ")"
+	default:
+		return string(info.Kind)
+	}
+}
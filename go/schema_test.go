@@ -0,0 +1,58 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	t.Parallel()
+
+	schemaOf := func(columns ...string) Schema {
+		var s Schema
+		for i := 0; i < len(columns); i += 2 {
+			s = append(s, newFieldSchema(columns[i], columns[i+1]))
+		}
+		return s
+	}
+
+	a := schemaOf("ts", "timestamp", "d", "decimal(10, 2)", "o", "object(a int, b array(string))")
+	require.True(t, a.Equal(schemaOf("ts", "timestamp", "d", "decimal(10,2)", "o", "object(a int,b array( string ))")))
+	require.Nil(t, a.Diff(a))
+	require.Equal(t, "(`ts` timestamp, `d` decimal(10, 2), `o` object(a int, b array(string)))", a.String())
+
+	b := schemaOf("o", "object(a int, b array(string))", "ts", "timestamp", "d", "decimal(38,10)", "v", "any")
+	require.False(t, a.Equal(b))
+	changes := a.Diff(b)
+	require.Equal(t, []SchemaChange{
+		{Kind: ColumnTypeChanged, Column: "d", OldType: "decimal(10, 2)", NewType: "decimal(38,10)", OldIndex: 1, NewIndex: 2},
+		{Kind: ColumnAdded, Column: "v", NewType: "any", OldIndex: -1, NewIndex: 3},
+		{Kind: ColumnReordered, Column: "o", OldType: "object(a int, b array(string))", NewType: "object(a int, b array(string))", OldIndex: 2, NewIndex: 0},
+		{Kind: ColumnReordered, Column: "ts", OldType: "timestamp", NewType: "timestamp", OldIndex: 0, NewIndex: 1},
+		{Kind: ColumnReordered, Column: "d", OldType: "decimal(10, 2)", NewType: "decimal(38,10)", OldIndex: 1, NewIndex: 2},
+	}, changes)
+	require.Equal(t, "change type of column `d` from decimal(10, 2) to decimal(38,10)", changes[0].String())
+	require.Equal(t, "add column `v` any", changes[1].String())
+	require.Equal(t, "move column `o` from position 2 to 0", changes[2].String())
+
+	require.Equal(t, []SchemaChange{
+		{Kind: ColumnRemoved, Column: "d", OldType: "decimal(10, 2)", OldIndex: 1, NewIndex: -1},
+	}, a.Diff(schemaOf("ts", "timestamp", "o", "object(a int, b array(string))")))
+}
@@ -0,0 +1,343 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedbtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	scopedb "github.com/scopedb/scopedb-sdk/go"
+)
+
+// RecordMode is the mode of a Recorder.
+type RecordMode string
+
+const (
+	// RecordModeReplay serves requests from the fixture file and fails the
+	// test on requests that match no recorded exchange.
+	RecordModeReplay RecordMode = "replay"
+	// RecordModeRecord forwards requests to the server and writes the
+	// exchanges to the fixture file when the test finishes.
+	RecordModeRecord RecordMode = "record"
+)
+
+// FixturesEnv is the environment variable that sets the default RecordMode,
+// e.g., SCOPEDB_FIXTURES=record to update the fixtures like UPDATE_SNAPS
+// updates go-snaps snapshots.
+const FixturesEnv = "SCOPEDB_FIXTURES"
+
+// redactedHeaders are the headers that are never written to fixtures.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// RecorderOptions configures a Recorder.
+type RecorderOptions struct {
+	// Mode is the mode of the recorder. The default is the value of
+	// FixturesEnv, or RecordModeReplay if it is unset.
+	Mode RecordMode
+	// Name is the name of the fixture file, without the extension. The default
+	// is the name of the test.
+	Name string
+	// Dir is the directory of the fixture files. The default is "__fixtures__"
+	// in the working directory, i.e., the directory of the test package.
+	Dir string
+	// Transport is the transport that requests are forwarded to in record
+	// mode. The default is http.DefaultTransport.
+	Transport http.RoundTripper
+	// RedactHeaders are the headers to redact in addition to Authorization,
+	// Cookie, Set-Cookie, and Proxy-Authorization.
+	RedactHeaders []string
+}
+
+// Recorder is an http.RoundTripper that records the HTTP exchanges of a test
+// to a fixture file, or replays them from it. Use it as Config.Transport.
+//
+// Exchanges are matched by method, path, query, and request body, where
+// bodies are decompressed and JSON bodies are compared after normalizing
+// their formatting and key order. Each recorded exchange is replayed once, in
+// the recorded order among the exchanges that match.
+type Recorder struct {
+	t         testing.TB
+	mode      RecordMode
+	path      string
+	transport http.RoundTripper
+	redact    []string
+
+	mu        sync.Mutex
+	exchanges []*Exchange
+	used      []bool
+}
+
+// Exchange is a recorded request and response pair.
+type Exchange struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request in a fixture file.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response in a fixture file.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+var unsafeFixtureChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// NewRecorder creates a Recorder for the fixture file of t. In replay mode,
+// the fixture file must exist.
+func NewRecorder(t testing.TB, opts RecorderOptions) *Recorder {
+	t.Helper()
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = RecordMode(os.Getenv(FixturesEnv))
+	}
+	if mode == "" {
+		mode = RecordModeReplay
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "__fixtures__"
+	}
+	name := opts.Name
+	if name == "" {
+		name = t.Name()
+	}
+	transport := opts.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	r := &Recorder{
+		t:         t,
+		mode:      mode,
+		path:      filepath.Join(dir, unsafeFixtureChars.ReplaceAllString(name, "_")+".json"),
+		transport: transport,
+		redact:    append(slices.Clone(redactedHeaders), opts.RedactHeaders...),
+	}
+
+	switch mode {
+	case RecordModeRecord:
+		t.Cleanup(func() {
+			if err := r.save(); err != nil {
+				t.Errorf("scopedbtest: save fixtures: %v", err)
+			}
+		})
+	case RecordModeReplay:
+		data, err := os.ReadFile(r.path)
+		if err != nil {
+			t.Fatalf("scopedbtest: load fixtures: %v (set %s=record to record them)", err, FixturesEnv)
+		}
+		if err := json.Unmarshal(data, &r.exchanges); err != nil {
+			t.Fatalf("scopedbtest: load fixtures %s: %v", r.path, err)
+		}
+		r.used = make([]bool, len(r.exchanges))
+	default:
+		t.Fatalf("scopedbtest: unknown record mode %q", mode)
+	}
+	return r
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() RecordMode {
+	return r.mode
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := r.recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == RecordModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(recorded.rawBody))
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, &Exchange{
+		Request: recorded.RecordedRequest,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+			Body:       string(body),
+		},
+	})
+	return resp, nil
+}
+
+type requestRecord struct {
+	RecordedRequest
+	rawBody []byte
+}
+
+func (r *Recorder) recordRequest(req *http.Request) (*requestRecord, error) {
+	var raw []byte
+	if req.Body != nil {
+		var err error
+		raw, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	body, err := decompress(req.Header.Get("Content-Encoding"), raw)
+	if err != nil {
+		return nil, err
+	}
+
+	header := r.redactHeader(req.Header)
+	// The compressed length varies with the compressor version.
+	header.Del("X-Scopedb-Uncompressed-Content-Length")
+	return &requestRecord{
+		RecordedRequest: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.RawQuery,
+			Header: header,
+			Body:   normalizeBody(body),
+		},
+		rawBody: raw,
+	}, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded *requestRecord) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, exchange := range r.exchanges {
+		if r.used[i] || !matches(&exchange.Request, &recorded.RecordedRequest) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", exchange.Response.StatusCode, http.StatusText(exchange.Response.StatusCode)),
+			StatusCode:    exchange.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        exchange.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(exchange.Response.Body)),
+			ContentLength: int64(len(exchange.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	r.t.Errorf("scopedbtest: no fixture for %s %s %s", recorded.Method, recorded.Path, recorded.Body)
+	return nil, fmt.Errorf("scopedbtest: no fixture for %s %s", recorded.Method, recorded.Path)
+}
+
+func matches(a, b *RecordedRequest) bool {
+	return a.Method == b.Method && a.Path == b.Path && a.Query == b.Query && a.Body == b.Body
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for _, key := range r.redact {
+		if header.Get(key) != "" {
+			header.Set(key, "REDACTED")
+		}
+	}
+	return header
+}
+
+func (r *Recorder) save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r.exchanges, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// normalizeBody reformats a JSON body with sorted keys, so that equivalent
+// bodies compare equal. Other bodies are returned as is.
+func normalizeBody(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(normalized)
+}
+
+func decompress(encoding string, body []byte) ([]byte, error) {
+	switch scopedb.Compression(encoding) {
+	case "":
+		return body, nil
+	case scopedb.CompressionZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	case scopedb.CompressionGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		return io.ReadAll(gr)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	scopedb "github.com/scopedb/scopedb-sdk/go"
+	"github.com/stretchr/testify/require"
+)
+
+// errorRecorder records the errors reported by a Recorder.
+type errorRecorder struct {
+	testing.TB
+	errors []string
+}
+
+func (e *errorRecorder) Errorf(format string, args ...any) {
+	e.errors = append(e.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	srv.Handle("SELECT 1", Response{
+		Result: &Result{Fields: []Field{{Name: "v", DataType: "int"}}, Rows: [][]any{{1}}},
+	})
+	dir := t.TempDir()
+	execute := func(t *testing.T, rec *Recorder, stmt string) ([][]scopedb.Value, error) {
+		c := scopedb.NewClient(&scopedb.Config{Endpoint: srv.URL, APIKey: "secret", Transport: rec})
+		defer c.Close()
+		rs, err := c.Execute(context.Background(), stmt)
+		if err != nil {
+			return nil, err
+		}
+		return rs.ToValues()
+	}
+
+	t.Run("record", func(t *testing.T) {
+		rec := NewRecorder(t, RecorderOptions{Mode: RecordModeRecord, Dir: dir, Name: "select"})
+		values, err := execute(t, rec, "SELECT 1")
+		require.NoError(t, err)
+		require.Equal(t, [][]scopedb.Value{{int64(1)}}, values)
+	})
+
+	data, err := os.ReadFile(filepath.Join(dir, "select.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+	require.Contains(t, string(data), `"REDACTED"`)
+	require.Contains(t, string(data), `"body": "{\"format\":\"json\",\"statement\":\"SELECT 1\"}"`)
+
+	srv.Close()
+	t.Run("replay", func(t *testing.T) {
+		rec := NewRecorder(t, RecorderOptions{Mode: RecordModeReplay, Dir: dir, Name: "select"})
+		values, err := execute(t, rec, "SELECT 1")
+		require.NoError(t, err)
+		require.Equal(t, [][]scopedb.Value{{int64(1)}}, values)
+
+		tb := &errorRecorder{TB: t}
+		rec = NewRecorder(tb, RecorderOptions{Mode: RecordModeReplay, Dir: dir, Name: "select"})
+		_, err = execute(t, rec, "SELECT 2")
+		require.ErrorContains(t, err, "scopedbtest: no fixture for POST /v1/statements")
+		require.Len(t, tb.errors, 1)
+	})
+}
@@ -0,0 +1,431 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package scopedbtest provides an in-process fake ScopeDB server for testing
+// code that uses the scopedb package without a live cluster.
+//
+// The fake implements the statement and ingest endpoints over HTTP. It does
+// not execute ScopeQL: statements are answered with canned responses
+// registered with Server.Handle, and ingested rows are recorded as is.
+//
+//	srv := scopedbtest.NewServer()
+//	defer srv.Close()
+//	srv.Handle("SELECT 1", scopedbtest.Response{
+//		Result: &scopedbtest.Result{
+//			Fields: []scopedbtest.Field{{Name: "v", DataType: "int"}},
+//			Rows:   [][]any{{1}},
+//		},
+//	})
+//	client := scopedb.NewClient(&scopedb.Config{Endpoint: srv.URL})
+package scopedbtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	scopedb "github.com/scopedb/scopedb-sdk/go"
+)
+
+// Field is a column of a canned result set.
+type Field struct {
+	Name     string
+	DataType string
+	// Nullable is reported as the nullable flag of the field.
+	Nullable bool
+}
+
+// Result is a canned result set.
+//
+// Values are encoded the way ScopeDB encodes them in JSON results: nil is
+// null, strings are sent as is, time.Time is formatted in RFC 3339, and other
+// values are formatted with fmt.Sprint.
+type Result struct {
+	Fields []Field
+	Rows   [][]any
+}
+
+// Response is the canned response to a statement.
+type Response struct {
+	// Statuses are the statuses reported before the statement completes: the
+	// first one in the submit response, and each following one in a fetch
+	// response. Empty means the statement completes on submit.
+	Statuses []scopedb.StatementStatus
+	// Result is the result set of a finished statement. Nil means an empty result.
+	Result *Result
+	// Error, if set, fails the statement with the message.
+	Error string
+}
+
+// Ingest is an ingest request received by the server.
+type Ingest struct {
+	// Statement is the transforms of the ingest.
+	Statement string
+	// Type is the ingest type, e.g., "buffered" or "committed".
+	Type string
+	// Format is the format of the rows, e.g., "json".
+	Format string
+	// Rows are the rows of the ingest, one per element.
+	Rows []string
+}
+
+// Server is a fake ScopeDB server.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	handlers    []handler
+	statements  map[uuid.UUID]*statement
+	received    []string
+	ingests     []Ingest
+	latency     time.Duration
+	ingestError string
+}
+
+type handler struct {
+	match    func(stmt string) bool
+	response Response
+}
+
+type statement struct {
+	id       uuid.UUID
+	created  time.Time
+	statuses []scopedb.StatementStatus
+	response Response
+	// cancelled is set once the statement is cancelled.
+	cancelled bool
+}
+
+// NewServer starts a fake ScopeDB server. The caller should call Close when
+// finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{statements: make(map[uuid.UUID]*statement)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/statements", s.submit)
+	mux.HandleFunc("GET /v1/statements/{id}", s.fetch)
+	mux.HandleFunc("POST /v1/statements/{id}/cancel", s.cancel)
+	mux.HandleFunc("POST /v1/ingest", s.ingest)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		latency := s.latency
+		s.mu.Unlock()
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// Handle registers the response to the statements equal to stmt, ignoring
+// leading and trailing whitespace. Later registrations take precedence.
+func (s *Server) Handle(stmt string, resp Response) {
+	stmt = strings.TrimSpace(stmt)
+	s.HandleFunc(func(received string) bool {
+		return strings.TrimSpace(received) == stmt
+	}, resp)
+}
+
+// HandleFunc registers the response to the statements that match reports true
+// for. Later registrations take precedence.
+//
+// Statements that match no registration finish with an empty result.
+func (s *Server) HandleFunc(match func(stmt string) bool, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler{match: match, response: resp})
+}
+
+// SetLatency delays every response of the server by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// SetIngestError makes every ingest fail with message. An empty message makes
+// ingests succeed again.
+func (s *Server) SetIngestError(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ingestError = message
+}
+
+// Statements returns the statements received, in order.
+func (s *Server) Statements() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+// Ingests returns the ingest requests received, in order, including the failed ones.
+func (s *Server) Ingests() []Ingest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Ingest(nil), s.ingests...)
+}
+
+type statementRequest struct {
+	StatementID *uuid.UUID `json:"statement_id"`
+	Statement   string     `json:"statement"`
+	Format      string     `json:"format"`
+}
+
+type ingestRequest struct {
+	Data struct {
+		Format string `json:"format"`
+		Rows   string `json:"rows"`
+	} `json:"data"`
+	Type      string `json:"type"`
+	Statement string `json:"statement"`
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	var req statementRequest
+	if err := decodeRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Format != "" && req.Format != string(scopedb.ResultFormatJSON) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported result format: %s", req.Format))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, req.Statement)
+
+	stmt := &statement{id: uuid.New(), created: time.Now().UTC()}
+	if req.StatementID != nil {
+		stmt.id = *req.StatementID
+	}
+	for i := len(s.handlers) - 1; i >= 0; i-- {
+		if s.handlers[i].match(req.Statement) {
+			stmt.response = s.handlers[i].response
+			break
+		}
+	}
+	stmt.statuses = stmt.response.Statuses
+	s.statements[stmt.id] = stmt
+	writeJSON(w, http.StatusOK, stmt.next(nil))
+}
+
+func (s *Server) fetch(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stmt, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, stmt.next(page))
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stmt, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if len(stmt.statuses) > 0 {
+		stmt.statuses = nil
+		stmt.cancelled = true
+	}
+	resp := stmt.next(nil)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":     resp["status"],
+		"message":    resp["message"],
+		"created_at": stmt.created,
+	})
+}
+
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (*statement, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	stmt, ok := s.statements[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("statement %s not found", id))
+		return nil, false
+	}
+	return stmt, true
+}
+
+func (s *Server) ingest(w http.ResponseWriter, r *http.Request) {
+	var req ingestRequest
+	if err := decodeRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var rows []string
+	if req.Data.Rows != "" {
+		rows = strings.Split(req.Data.Rows, "\n")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ingests = append(s.ingests, Ingest{
+		Statement: req.Statement,
+		Type:      req.Type,
+		Format:    req.Data.Format,
+		Rows:      rows,
+	})
+	if s.ingestError != "" {
+		writeError(w, http.StatusInternalServerError, s.ingestError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"num_rows_inserted": len(rows)})
+}
+
+// next advances the statement to its next status and returns the response body.
+func (stmt *statement) next(page *page) map[string]any {
+	resp := map[string]any{
+		"statement_id": stmt.id,
+		"created_at":   stmt.created,
+		"progress":     map[string]any{},
+	}
+
+	if len(stmt.statuses) > 0 {
+		resp["status"] = stmt.statuses[0]
+		stmt.statuses = stmt.statuses[1:]
+		return resp
+	}
+
+	switch {
+	case stmt.cancelled:
+		resp["status"] = scopedb.StatementStatusCancelled
+		resp["message"] = "statement cancelled"
+	case stmt.response.Error != "":
+		resp["status"] = scopedb.StatementStatusFailed
+		resp["message"] = stmt.response.Error
+	default:
+		resp["status"] = scopedb.StatementStatusFinished
+		resp["progress"] = map[string]any{"total_percentage": 100}
+		resp["result_set"] = encodeResult(stmt.response.Result, page)
+	}
+	return resp
+}
+
+type page struct {
+	offset, limit uint64
+}
+
+func parsePage(r *http.Request) (*page, error) {
+	q := r.URL.Query()
+	if !q.Has("offset") && !q.Has("limit") {
+		return nil, nil
+	}
+	offset, err := strconv.ParseUint(q.Get("offset"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid offset: %w", err)
+	}
+	limit, err := strconv.ParseUint(q.Get("limit"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid limit: %w", err)
+	}
+	return &page{offset: offset, limit: limit}, nil
+}
+
+func encodeResult(result *Result, page *page) map[string]any {
+	if result == nil {
+		result = &Result{}
+	}
+
+	fields := make([]map[string]any, len(result.Fields))
+	for i, field := range result.Fields {
+		fields[i] = map[string]any{"name": field.Name, "data_type": field.DataType}
+		if field.Nullable {
+			fields[i]["nullable"] = true
+		}
+	}
+
+	rows := result.Rows
+	if page != nil {
+		start := min(page.offset, uint64(len(rows)))
+		end := min(start+page.limit, uint64(len(rows)))
+		rows = rows[start:end]
+	}
+	encoded := make([][]*string, len(rows))
+	for i, row := range rows {
+		encoded[i] = make([]*string, len(row))
+		for j, v := range row {
+			encoded[i][j] = encodeValue(v)
+		}
+	}
+
+	return map[string]any{
+		"metadata": map[string]any{"fields": fields, "num_rows": len(result.Rows)},
+		"format":   scopedb.ResultFormatJSON,
+		"rows":     encoded,
+	}
+}
+
+func encodeValue(v any) *string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		s = v
+	case time.Time:
+		s = v.UTC().Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprint(v)
+	}
+	return &s
+}
+
+func decodeRequest(r *http.Request, v any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	body, err := decompress(r.Header.Get("Content-Encoding"), data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"message": message})
+}
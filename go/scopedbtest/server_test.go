@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	scopedb "github.com/scopedb/scopedb-sdk/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerStatements(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+	srv.Handle("SELECT 1", Response{
+		Statuses: []scopedb.StatementStatus{scopedb.StatementStatusPending, scopedb.StatementStatusRunning},
+		Result: &Result{
+			Fields: []Field{{Name: "v", DataType: "int"}, {Name: "s", DataType: "string", Nullable: true}},
+			Rows:   [][]any{{1, "a"}, {2, nil}},
+		},
+	})
+	srv.Handle("SELECT boom", Response{Error: "boom"})
+
+	c := scopedb.NewClient(&scopedb.Config{Endpoint: srv.URL})
+	defer c.Close()
+	ctx := context.Background()
+
+	h, err := c.Statement("SELECT 1").Submit(ctx)
+	require.NoError(t, err)
+	require.Equal(t, scopedb.StatementStatusPending, *h.Status())
+	require.NoError(t, h.FetchOnce(ctx))
+	require.Equal(t, scopedb.StatementStatusRunning, *h.Status())
+	rs, err := h.Fetch(ctx)
+	require.NoError(t, err)
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, [][]scopedb.Value{{int64(1), "a"}, {int64(2), nil}}, values)
+	require.False(t, rs.Schema[0].Nullable)
+	require.True(t, rs.Schema[1].Nullable)
+
+	_, err = c.Statement("SELECT boom").Execute(ctx)
+	require.Equal(t, &scopedb.Error{Message: "boom"}, err)
+
+	rs, err = c.Statement("CREATE TABLE t (v int)").Execute(ctx)
+	require.NoError(t, err)
+	require.Zero(t, rs.TotalRows)
+
+	require.Equal(t, []string{"SELECT 1", "SELECT boom", "CREATE TABLE t (v int)"}, srv.Statements())
+}
+
+func TestServerCancel(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+	srv.HandleFunc(func(string) bool { return true }, Response{
+		Statuses: []scopedb.StatementStatus{scopedb.StatementStatusRunning, scopedb.StatementStatusRunning},
+	})
+
+	c := scopedb.NewClient(&scopedb.Config{Endpoint: srv.URL})
+	defer c.Close()
+	ctx := context.Background()
+
+	h, err := c.Statement("SELECT sleep(60)").Submit(ctx)
+	require.NoError(t, err)
+	status, err := h.Cancel(ctx)
+	require.NoError(t, err)
+	require.Equal(t, scopedb.StatementStatusCancelled, *status)
+}
+
+func TestServerIngest(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+	c := scopedb.NewClient(&scopedb.Config{Endpoint: srv.URL})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 0
+	require.NoError(t, cable.Start(context.Background()))
+	defer cable.Close()
+
+	require.NoError(t, <-cable.Send(map[string]int{"v": 1}))
+	srv.SetIngestError("table not found")
+	var serverErr *scopedb.Error
+	require.ErrorAs(t, <-cable.Send(map[string]int{"v": 2}), &serverErr)
+	require.Equal(t, "table not found", serverErr.Message)
+
+	require.Equal(t, []Ingest{
+		{Statement: "SELECT $0 INSERT INTO t (v)", Type: "buffered", Format: "json", Rows: []string{`{"v":1}`}},
+		{Statement: "SELECT $0 INSERT INTO t (v)", Type: "buffered", Format: "json", Rows: []string{`{"v":2}`}},
+	}, srv.Ingests())
+}
+
+func TestServerLatency(t *testing.T) {
+	t.Parallel()
+
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetLatency(time.Second)
+	c := scopedb.NewClient(&scopedb.Config{Endpoint: srv.URL})
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Statement("SELECT 1").Execute(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ScriptError is returned by Statement.ExecuteScript when a sub-statement fails.
+type ScriptError struct {
+	// Index is the zero-based index of the failed sub-statement.
+	Index int
+	// Statement is the text of the failed sub-statement.
+	Statement string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("statement %d failed: %v", e.Index, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteScript executes a script of multiple statements separated by
+// semicolons and returns the result set of each statement in order.
+//
+// The script is split at top-level semicolons; semicolons in string literals,
+// quoted identifiers, comments, and BEGIN ... END blocks do not split it. The
+// statements are executed one by one with the settings of s, except ID, which
+// is left empty so that ScopeDB generates a unique ID for each statement.
+//
+// When a statement fails, the result sets of the preceding statements are
+// returned along with a *ScriptError.
+func (s *Statement) ExecuteScript(ctx context.Context) ([]*ResultSet, error) {
+	stmts := SplitStatements(s.stmt)
+	results := make([]*ResultSet, 0, len(stmts))
+	for i, stmt := range stmts {
+		sub := *s
+		sub.stmt = stmt
+		sub.ID = nil
+		rs, err := sub.Execute(ctx)
+		if err != nil {
+			return results, &ScriptError{Index: i, Statement: stmt, Err: err}
+		}
+		results = append(results, rs)
+	}
+	return results, nil
+}
+
+// SplitStatements splits a script into statements at top-level semicolons.
+//
+// Semicolons in string literals, quoted identifiers, comments, and
+// BEGIN ... END blocks do not split the script. Empty statements are dropped.
+func SplitStatements(script string) []string {
+	var stmts []string
+	appendStmt := func(stmt string) {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	depth, start := 0, 0
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// skip the quoted literal or identifier
+			for i++; i < len(script) && script[i] != c; i++ {
+				if script[i] == '\\' {
+					i++
+				}
+			}
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+		case isIdentStart(c) && (i == 0 || !isIdentPart(script[i-1])):
+			j := i
+			for j < len(script) && isIdentPart(script[j]) {
+				j++
+			}
+			switch strings.ToUpper(script[i:j]) {
+			case "BEGIN", "CASE":
+				depth++
+			case "END":
+				depth = max(depth-1, 0)
+			}
+			i = j - 1
+		case c == ';' && depth == 0:
+			appendStmt(script[start:i])
+			start = i + 1
+		}
+	}
+	appendStmt(script[start:])
+	return stmts
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{
+		"CREATE TABLE t (s string)",
+		`INSERT INTO t VALUES ('a;b'), ("c;d")`,
+		"FROM `weird;table` -- comment; here\nSELECT *",
+		"/* block; comment */ SELECT CASE WHEN 1 = 1 THEN 'x' END",
+		"BEGIN\n\tDELETE FROM t;\n\tINSERT INTO t VALUES ('e\\';f');\nEND",
+		"DROP TABLE t",
+	}, SplitStatements(`
+		CREATE TABLE t (s string);
+		INSERT INTO t VALUES ('a;b'), ("c;d");
+		FROM `+"`weird;table`"+` -- comment; here
+SELECT *;
+		/* block; comment */ SELECT CASE WHEN 1 = 1 THEN 'x' END;;
+		BEGIN
+	DELETE FROM t;
+	INSERT INTO t VALUES ('e\';f');
+END;
+		DROP TABLE t
+	`))
+}
+
+func TestStatementExecuteScriptReportsFailedStatement(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newStatementTestServer(t, 1)
+	c := NewClient(&Config{Endpoint: server.URL, MaxResultRows: 1})
+	defer c.Close()
+
+	results, err := c.Statement("SELECT 1; SELECT 2").ExecuteScript(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	tooLarge, _ := newStatementTestServer(t, 2)
+	c = NewClient(&Config{Endpoint: tooLarge.URL, MaxResultRows: 1})
+	defer c.Close()
+
+	results, err = c.Statement("SELECT 1; SELECT 2").ExecuteScript(context.Background())
+	require.Empty(t, results)
+	var scriptErr *ScriptError
+	require.True(t, errors.As(err, &scriptErr))
+	require.Equal(t, 0, scriptErr.Index)
+	require.Equal(t, "SELECT 1", scriptErr.Statement)
+	require.ErrorIs(t, err, ErrResultTooLarge)
+}
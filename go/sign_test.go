@@ -0,0 +1,87 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func hmacSignature(key []byte, method, path, date string, body []byte) string {
+	digest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, key)
+	_, _ = io.WriteString(mac, method+"\n"+path+"\n"+date+"\n"+hex.EncodeToString(digest[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignRequest(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("gateway-key")
+	var verified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NotEmpty(t, r.Header.Get("X-Request-ID"))
+		want := hmacSignature(key, r.Method, r.URL.Path, r.Header.Get("X-Date"), body)
+		if r.Header.Get("X-Signature") != want {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		verified.Add(1)
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	for _, compression := range []Compression{CompressionZstd, CompressionGzip} {
+		c := NewClient(&Config{
+			Endpoint:    server.URL,
+			Compression: compression,
+			SignRequest: func(req *http.Request, body []byte) error {
+				require.NotEmpty(t, req.Header.Get("X-Request-ID"))
+				date := time.Now().UTC().Format(http.TimeFormat)
+				req.Header.Set("X-Date", date)
+				req.Header.Set("X-Signature", hmacSignature(key, req.Method, req.URL.Path, date, body))
+				return nil
+			},
+		})
+		_, err := c.Statement("VALUES (1)").Execute(ctx)
+		require.NoError(t, err)
+		require.NoError(t, c.Ingest(ctx, "INSERT INTO t", 1))
+	}
+	require.EqualValues(t, 4, verified.Load())
+
+	c := NewClient(&Config{
+		Endpoint:    server.URL,
+		SignRequest: func(*http.Request, []byte) error { return errors.New("key unavailable") },
+	})
+	_, err := c.Statement("VALUES (1)").Execute(ctx)
+	require.ErrorContains(t, err, "submit statement: sign request: key unavailable")
+	require.EqualValues(t, 4, verified.Load())
+}
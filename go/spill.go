@@ -0,0 +1,337 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spillSegmentSuffix         = ".spill"
+	defaultSpillRetryInterval  = time.Second
+	spillSegmentSequenceDigits = 20
+)
+
+// ErrSpillFull is returned for records that cannot be spilled because the
+// spill directory reached SpillOptions.MaxBytes.
+var ErrSpillFull = errors.New("spill directory full")
+
+// SpillOptions configures the disk spill buffer of a DataCable.
+//
+// With spilling enabled, batches that fail to be ingested, or that would
+// raise the bytes being ingested above Threshold, are appended to a log in Dir
+// instead, and their records are acknowledged once written. A background task
+// replays the spilled batches in the order they were spilled, retrying every
+// RetryInterval until ScopeDB accepts them, and removes them afterwards.
+// Batches left in Dir when the cable stops are replayed by the next cable
+// started with the same Dir.
+//
+// Spilled records are delivered at least once: a batch whose ingest failed
+// after ScopeDB received it may be ingested again on replay.
+type SpillOptions struct {
+	// Dir is the directory of the spill log. It is created if it does not exist,
+	// and must not be shared by cables running at the same time.
+	Dir string
+	// Threshold is the size in bytes of the batches being ingested above which
+	// new batches are spilled. Zero means only the failed batches are spilled.
+	Threshold uint64
+	// MaxBytes is the maximum size in bytes of the spill log. Batches that do
+	// not fit fail with ErrSpillFull. Zero means no limit.
+	MaxBytes uint64
+	// Ordered makes new batches go to the spill log while it is not empty, so
+	// that they are not ingested before the batches spilled earlier.
+	Ordered bool
+	// RetryInterval is the time to wait before replaying a spilled batch again
+	// after it fails. Zero means one second.
+	RetryInterval time.Duration
+}
+
+// spillLog is a directory of segment files, each holding one spilled batch.
+//
+// A segment is a sequence of length-prefixed JSON lines: the decimal length of
+// the record, a newline, the record, and a newline. Reading a segment stops at
+// the first malformed entry, so that a segment truncated by a crash yields the
+// records written completely before it.
+type spillLog struct {
+	dir      string
+	maxBytes uint64
+	// err is the error of opening the log. If set, every append fails with it.
+	err error
+	// notify receives a value whenever a segment is appended.
+	notify chan struct{}
+
+	mu       sync.Mutex
+	segments []spillSegment
+	size     uint64
+	nextSeq  uint64
+}
+
+type spillSegment struct {
+	seq  uint64
+	path string
+	size uint64
+}
+
+// openSpillLog opens the spill log in dir and loads its existing segments.
+func openSpillLog(dir string, maxBytes uint64) *spillLog {
+	l := &spillLog{dir: dir, maxBytes: maxBytes, notify: make(chan struct{}, 1)}
+	if err := l.load(); err != nil {
+		l.err = fmt.Errorf("open spill directory: %w", err)
+	}
+	return l
+}
+
+func (l *spillLog) load() error {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), spillSegmentSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		l.segments = append(l.segments, spillSegment{
+			seq:  seq,
+			path: filepath.Join(l.dir, entry.Name()),
+			size: uint64(info.Size()),
+		})
+		l.size += uint64(info.Size())
+		l.nextSeq = max(l.nextSeq, seq+1)
+	}
+	slices.SortFunc(l.segments, func(a, b spillSegment) int {
+		return compareUint64(a.seq, b.seq)
+	})
+	return nil
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// empty reports whether the log has no segments.
+func (l *spillLog) empty() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.segments) == 0
+}
+
+// append writes records as a new segment and syncs it to disk.
+func (l *spillLog) append(records []string) error {
+	if l.err != nil {
+		return l.err
+	}
+
+	var b strings.Builder
+	for _, record := range records {
+		b.WriteString(strconv.Itoa(len(record)))
+		b.WriteByte('\n')
+		b.WriteString(record)
+		b.WriteByte('\n')
+	}
+	size := uint64(b.Len())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxBytes > 0 && l.size+size > l.maxBytes {
+		return ErrSpillFull
+	}
+
+	seq := l.nextSeq
+	path := filepath.Join(l.dir, fmt.Sprintf("%0*d%s", spillSegmentSequenceDigits, seq, spillSegmentSuffix))
+	if err := writeFileSync(path, b.String()); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("spill batch: %w", err)
+	}
+	l.nextSeq++
+	l.segments = append(l.segments, spillSegment{seq: seq, path: path, size: size})
+	l.size += size
+
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func writeFileSync(path, data string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// oldest returns the oldest segment, if any.
+func (l *spillLog) oldest() (spillSegment, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.segments) == 0 {
+		return spillSegment{}, false
+	}
+	return l.segments[0], true
+}
+
+// remove deletes the oldest segment, which must be seg.
+func (l *spillLog) remove(seg spillSegment) error {
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.segments = l.segments[1:]
+	l.size -= seg.size
+	return nil
+}
+
+// readSpillSegment reads the records of the segment at path, stopping at the
+// first malformed or truncated entry.
+func readSpillSegment(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []string
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return records, nil
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(line, "\n"))
+		if err != nil || n < 0 {
+			return records, nil
+		}
+		record := make([]byte, n+1)
+		if _, err := io.ReadFull(r, record); err != nil {
+			return records, nil
+		}
+		if record[n] != '\n' || !json.Valid(record[:n]) {
+			return records, nil
+		}
+		records = append(records, string(record[:n]))
+	}
+}
+
+// spillBatch appends sendBatches to the spill log and acknowledges their
+// records and sendFlush. If the batch cannot be spilled, they are left
+// unsettled.
+func (c *DataCable) spillBatch(sendBatches []dataSendRecord, sendFlush *Flush) error {
+	if err := c.spill.append(payloads(sendBatches)); err != nil {
+		return err
+	}
+	for _, sendBatch := range sendBatches {
+		if sendBatch.err != nil {
+			close(sendBatch.err)
+		}
+	}
+	c.settled(sendBatches, &c.spilledRecords)
+	sendFlush.resolve(nil, nil)
+	return nil
+}
+
+// replaySpilled ingests the spilled batches in order until the cable stops.
+//
+// Once the cable stops, it keeps replaying until the log is empty or a replay
+// fails; the remaining batches are left for the next cable using the log.
+func (c *DataCable) replaySpilled(ctx context.Context, ingestType writeType) {
+	retryInterval := c.Spill.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = defaultSpillRetryInterval
+	}
+
+	for {
+		seg, ok := c.spill.oldest()
+		if !ok {
+			select {
+			case <-c.spill.notify:
+				continue
+			case <-c.stopped:
+				return
+			}
+		}
+
+		records, err := readSpillSegment(seg.path)
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		if err == nil && len(records) > 0 {
+			rows := strings.Join(records, "\n")
+			start := time.Now()
+			_, err = c.ingestRows(ctx, ingestType, rows)
+			c.recordFlush(FlushInfo{
+				Time:     start,
+				Duration: time.Since(start),
+				Records:  len(records),
+				Bytes:    len(rows),
+				Err:      err,
+			})
+			if err != nil && c.OnError != nil {
+				c.OnError(err, BatchInfo{Records: len(records), Bytes: len(rows)})
+			}
+		}
+		if err == nil {
+			err = c.spill.remove(seg)
+		}
+		if err != nil {
+			select {
+			case <-time.After(retryInterval):
+			case <-c.stopped:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,243 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyIngestServer accepts ingests only while healthy is set, and returns
+// the rows of the accepted ingests.
+func newFlakyIngestServer(t *testing.T, healthy *atomic.Bool) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var rows []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"message":"service unavailable"}`))
+			return
+		}
+		body, err := decodeCompressedRequestBody(r)
+		require.NoError(t, err)
+		var req ingestRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		mu.Lock()
+		rows = append(rows, req.Data.Rows)
+		mu.Unlock()
+		writeEmptyResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), rows...)
+	}
+}
+
+func TestReadSpillSegmentTruncated(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "00000000000000000000.spill")
+	data := "1\n1\n7\n\"hello\"\n10\n{\"v\":true}\n"
+	for _, tc := range []struct {
+		size     int
+		expected []string
+	}{
+		{size: len(data), expected: []string{"1", `"hello"`, `{"v":true}`}},
+		// killed in the middle of the last record
+		{size: len(data) - 4, expected: []string{"1", `"hello"`}},
+		// killed in the middle of the length of the last record
+		{size: len("1\n1\n7\n\"hello\"\n") + 1, expected: []string{"1", `"hello"`}},
+		// killed before the trailing newline of the first record
+		{size: len("1\n1"), expected: nil},
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(data[:tc.size]), 0o644))
+		records, err := readSpillSegment(path)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, records, "size %d", tc.size)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("3\n{x}\n1\n1\n"), 0o644))
+	records, err := readSpillSegment(path)
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
+
+func TestCableSpillReplaysInOrder(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+	server, ingested := newFlakyIngestServer(t, &healthy)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	dir := t.TempDir()
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 0
+	cable.Spill = &SpillOptions{Dir: dir, Ordered: true, RetryInterval: 10 * time.Millisecond}
+	require.NoError(t, cable.Start(context.Background()))
+
+	// The failed batch is spilled, and the later ones follow it while the
+	// spill log is not empty.
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, <-cable.Send(i))
+	}
+	require.Empty(t, ingested())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	healthy.Store(true)
+	require.Eventually(t, func() bool {
+		return len(ingested()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"1", "2", "3"}, ingested())
+
+	cable.Close()
+	require.NoError(t, cable.wait(context.Background()))
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestCableSpillReplaysOnRestart(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server, ingested := newFlakyIngestServer(t, &healthy)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	// A previous cable spilled two batches and was killed while writing the
+	// second one.
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000007.spill"), []byte("1\n1\n1\n2\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000008.spill"), []byte("1\n3\n2\n4"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated.txt"), nil, 0o644))
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.Spill = &SpillOptions{Dir: dir}
+	require.NoError(t, cable.Start(context.Background()))
+	cable.Close()
+	require.NoError(t, cable.wait(context.Background()))
+
+	require.Equal(t, []string{"1\n2", "3"}, ingested())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "unrelated.txt", entries[0].Name())
+}
+
+func TestCableSpillFull(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+	server, _ := newFlakyIngestServer(t, &healthy)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 0
+	cable.Spill = &SpillOptions{Dir: t.TempDir(), MaxBytes: 8}
+	require.NoError(t, cable.Start(context.Background()))
+	defer cable.Close()
+
+	require.NoError(t, <-cable.Send(1))
+	err := <-cable.Send("too large")
+	require.ErrorIs(t, err, ErrSpillFull)
+	require.ErrorContains(t, err, "service unavailable")
+}
+
+func TestCableSpillOnDrainGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	dir := t.TempDir()
+	final := make(chan FlushResult, 1)
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.BatchSize = 0
+	cable.DrainGracePeriod = 20 * time.Millisecond
+	cable.Spill = &SpillOptions{Dir: dir}
+	cable.OnFlush = func(result FlushResult) {
+		if result.Final {
+			final <- result
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, cable.Start(ctx))
+	ack := cable.Send(1)
+	cancel()
+
+	// The ingest canceled by the grace period is spilled, not dropped.
+	require.NoError(t, <-ack)
+	require.Equal(t, FlushResult{Final: true, Spilled: 1}, <-final)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	records, err := readSpillSegment(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, records)
+}
+
+func TestCableSpillReplaysWhileDraining(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server, ingested := newFlakyIngestServer(t, &healthy)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000000.spill"), []byte("1\n1\n"), 0o644))
+
+	// The replay runs within DrainGracePeriod, even though ctx is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cable := c.DataCable("SELECT $0 INSERT INTO t (v)")
+	cable.DrainGracePeriod = time.Minute
+	cable.Spill = &SpillOptions{Dir: dir}
+	require.NoError(t, cable.Start(ctx))
+	require.NoError(t, cable.wait(context.Background()))
+
+	require.Equal(t, []string{"1"}, ingested())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -17,7 +17,14 @@
 package scopedb
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"reflect"
 	"time"
 
 	"github.com/google/uuid"
@@ -36,6 +43,8 @@ type Statement struct {
 	c *Client
 
 	stmt string
+	// err is set if the statement could not be built, e.g., by Statementf.
+	err error
 
 	// ID of the statement.
 	//
@@ -48,70 +57,346 @@ type Statement struct {
 	// as timed out.
 	//
 	// Possible values like "1h".
+	//
+	// The default is Config.DefaultExecTimeout.
 	ExecTimeout string
+	// ExecTimeoutDuration is the maximum time for statement execution as a
+	// duration. Zero means unset, in which case ExecTimeout applies.
+	//
+	// It overrides Config.DefaultExecTimeout. Setting both ExecTimeout and
+	// ExecTimeoutDuration is allowed only if they denote the same duration.
+	ExecTimeoutDuration time.Duration
 	// ResultFormat is the format of the result set.
+	//
+	// The default is Config.DefaultResultFormat.
 	ResultFormat ResultFormat
+	// MaxResultRows is the maximum number of rows that Execute returns.
+	//
+	// When the result has more rows, Execute fails with ErrResultTooLarge, or
+	// returns the first MaxResultRows rows with ResultSet.Truncated set if
+	// TruncateResult is true. Only the first page of rows is fetched in either
+	// case when ScopeDB supports paged fetching.
+	//
+	// Zero means unlimited. The default is Config.MaxResultRows.
+	MaxResultRows uint64
+	// TruncateResult makes Execute truncate results that exceed MaxResultRows
+	// instead of failing.
+	TruncateResult bool
+	// Tags attribute the statement, e.g., to the originating service or feature,
+	// so that operators can filter system.statements by them.
+	//
+	// Tags are sent as a trailing comment of the statement text. They are
+	// merged over the ApplicationTag set from Config.ApplicationName. Keys
+	// must start with a letter or underscore and contain at most 64 letters,
+	// digits, underscores, dots, or dashes. Values may contain at most 256
+	// letters, digits, or any of "_.:/@+-". Invalid tags fail submission.
+	Tags map[string]string
+	// NodeGroup is the node group to execute the statement on, e.g., "default".
+	//
+	// It is passed through to ScopeDB as is. Empty means the server default.
+	NodeGroup string
+	// Priority is the scheduling priority of the statement. Empty means the
+	// server default.
+	//
+	// If ScopeDB rejects the priority, the statement is submitted without it.
+	// See Client.SupportsPriority.
+	Priority Priority
+	// WaitTimeout is the StatementHandle.WaitTimeout of the submitted statement.
+	//
+	// The default is Config.DefaultWaitTimeout.
+	WaitTimeout time.Duration
+	// MaxFetchFailures is the StatementHandle.MaxFetchFailures of the submitted statement.
+	MaxFetchFailures int
+	// FetchOptions is the StatementHandle.FetchOptions of the submitted statement.
+	FetchOptions FetchOptions
 }
 
 // Statement creates a new statement with the given ScopeQL statement.
 func (c *Client) Statement(stmt string) *Statement {
 	return &Statement{
-		c:            c,
-		stmt:         stmt,
-		ResultFormat: ResultFormatJSON,
+		c:             c,
+		stmt:          stmt,
+		err:           c.configErr,
+		ExecTimeout:   c.config.DefaultExecTimeout,
+		ResultFormat:  c.defaultResultFormat(),
+		MaxResultRows: c.config.MaxResultRows,
+		WaitTimeout:   c.config.DefaultWaitTimeout,
 	}
 }
 
+// defaultResultFormat returns Config.DefaultResultFormat or ResultFormatJSON if unset.
+func (c *Client) defaultResultFormat() ResultFormat {
+	if c.config.DefaultResultFormat != "" {
+		return c.config.DefaultResultFormat
+	}
+	return ResultFormatJSON
+}
+
 // Submit submits the statement to ScopeDB for execution.
 func (s *Statement) Submit(ctx context.Context) (*StatementHandle, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	execTimeout, err := s.execTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok && execTimeout == "" && s.c.config.PropagateContextDeadline {
+		execTimeout = formatTimeout(deriveTimeout(time.Until(deadline)))
+	}
+	stmt, err := s.taggedStatement()
+	if err != nil {
+		return nil, err
+	}
+
 	resp, err := s.c.submitStatement(ctx, &statementRequest{
 		StatementID: s.ID,
-		Statement:   s.stmt,
-		ExecTimeout: s.ExecTimeout,
+		Statement:   stmt,
+		ExecTimeout: execTimeout,
 		Format:      s.ResultFormat,
+		NodeGroup:   s.NodeGroup,
+		Priority:    s.Priority,
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	return &StatementHandle{
-		c:      s.c,
-		resp:   resp,
-		id:     resp.ID,
-		Format: s.ResultFormat,
+		c:           s.c,
+		resp:        resp,
+		id:          resp.ID,
+		requestID:   resp.requestID,
+		Format:      s.ResultFormat,
+		WaitTimeout: s.WaitTimeout,
+
+		MaxFetchFailures: s.MaxFetchFailures,
+		FetchOptions:     s.FetchOptions,
 	}, nil
 }
 
+// WithExecTimeout sets ExecTimeoutDuration and returns the statement for chaining.
+func (s *Statement) WithExecTimeout(d time.Duration) *Statement {
+	s.ExecTimeoutDuration = d
+	return s
+}
+
+// execTimeout resolves ExecTimeout and ExecTimeoutDuration into the timeout
+// string sent to ScopeDB.
+func (s *Statement) execTimeout() (string, error) {
+	d := s.ExecTimeoutDuration
+	if d < 0 {
+		return "", fmt.Errorf("exec timeout must not be negative, got %s", d)
+	}
+	if d == 0 {
+		return s.ExecTimeout, nil
+	}
+	if s.ExecTimeout != "" && s.ExecTimeout != s.c.config.DefaultExecTimeout {
+		legacy, err := parseTimeout(s.ExecTimeout)
+		if err != nil || legacy != d {
+			return "", fmt.Errorf("conflicting exec timeouts: ExecTimeout is %q but ExecTimeoutDuration is %s", s.ExecTimeout, d)
+		}
+	}
+	return formatTimeout(d), nil
+}
+
 // Execute submits the statement to ScopeDB for execution and waits for its completion.
+//
+// If Config.ResultCache is enabled, the result sets of read-only statements
+// are served from the cache within its TTL; see ResultCacheConfig.
 func (s *Statement) Execute(ctx context.Context) (*ResultSet, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	key, cacheable := s.cacheKey()
+	if cacheable {
+		if rs, ok := s.c.cache.get(key); ok {
+			return rs, nil
+		}
+	}
+
 	handle, err := s.Submit(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return handle.Fetch(ctx)
+	rs, err := s.fetchResult(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		s.c.cache.put(key, rs)
+	}
+	return rs, nil
 }
 
+// fetchResult waits for the submitted statement and returns its result set,
+// limited to MaxResultRows.
+func (s *Statement) fetchResult(ctx context.Context, handle *StatementHandle) (*ResultSet, error) {
+	if s.MaxResultRows == 0 {
+		return handle.Fetch(ctx)
+	}
+
+	rs, err := handle.FetchPage(ctx, 0, s.MaxResultRows)
+	if err != nil {
+		return nil, err
+	}
+	if rs.TotalRows <= s.MaxResultRows {
+		return rs, nil
+	}
+	if !s.TruncateResult {
+		return nil, fmt.Errorf("%w: %d rows exceed the limit of %d", ErrResultTooLarge, rs.TotalRows, s.MaxResultRows)
+	}
+	rs.Truncated = true
+	return rs, nil
+}
+
+// ExecResult summarizes the execution of a statement.
+type ExecResult struct {
+	// StatementID is the ID of the executed statement.
+	StatementID uuid.UUID
+	// RowsInserted is the number of rows inserted by a DML statement.
+	RowsInserted int64
+	// RowsUpdated is the number of rows updated by a DML statement.
+	RowsUpdated int64
+	// RowsDeleted is the number of rows deleted by a DML statement.
+	RowsDeleted int64
+	// Elapsed is the time from submission to completion reported by ScopeDB.
+	Elapsed time.Duration
+}
+
+// Exec submits the statement to ScopeDB for execution, waits for its
+// completion, and returns the execution summary.
+//
+// The row counts are read from the num_rows_inserted, num_rows_updated, and
+// num_rows_deleted columns of the first row that ScopeDB reports for DML
+// statements. They are zero for statements that return ordinary result sets.
+func (s *Statement) Exec(ctx context.Context) (*ExecResult, error) {
+	handle, err := s.Submit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := handle.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExecResult{StatementID: handle.id}
+	if progress := handle.Progress(); progress != nil {
+		result.Elapsed = time.Duration(progress.NanosFromSubmitted)
+	}
+
+	counts := []struct {
+		name string
+		dest *int64
+	}{
+		{"num_rows_inserted", &result.RowsInserted},
+		{"num_rows_updated", &result.RowsUpdated},
+		{"num_rows_deleted", &result.RowsDeleted},
+	}
+	var rows [][]Value
+	for _, count := range counts {
+		i, ok := rs.ColumnIndex(count.name)
+		if !ok {
+			continue
+		}
+		if rows == nil {
+			if rows, err = rs.ToValues(); err != nil {
+				return nil, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+		}
+		if err := convertAssign(reflect.ValueOf(count.dest).Elem(), rows[0][i], rs.Schema[i]); err != nil {
+			return nil, fmt.Errorf("column %q: %w", count.name, err)
+		}
+	}
+	return result, nil
+}
+
+// defaultMaxFetchFailures is the default StatementHandle.MaxFetchFailures.
+const defaultMaxFetchFailures = 3
+
 // StatementHandle is a handle to a statement that has been submitted to ScopeDB.
 type StatementHandle struct {
 	c    *Client
 	resp *statementResponse
+	// page is the page that resp was fetched for; nil if resp covers the whole result.
+	page *resultPage
+	// rows are the rows of resp split once, so that pages of a whole result
+	// are sliced locally without decoding it again.
+	rows []json.RawMessage
 
 	id uuid.UUID
+	// requestID is the ID of the HTTP request that submitted the statement.
+	requestID string
 
 	// Format is the expected format of the ResultSet.
 	Format ResultFormat
+	// WaitTimeout, if positive, makes each fetch a long poll: ScopeDB holds the
+	// request until the statement completes or WaitTimeout elapses, so that
+	// Fetch issues few requests for long-running statements. Servers that
+	// ignore it reply immediately, and Fetch keeps polling as usual.
+	WaitTimeout time.Duration
+	// MaxFetchFailures is the number of consecutive transient failures, i.e.,
+	// connection errors and 429 or 5xx responses, that Fetch tolerates before
+	// giving up. Other errors fail Fetch immediately.
+	//
+	// The default is zero, which tolerates 3 failures. A negative value
+	// tolerates none.
+	MaxFetchFailures int
+	// FetchOptions controls how often Fetch polls ScopeDB while the statement
+	// is running. The zero value polls after 10ms, 20ms, 40ms, and so on up
+	// to once per second.
+	FetchOptions FetchOptions
 }
 
 // StatementHandle creates a new StatementHandle with the given ID.
 func (c *Client) StatementHandle(id uuid.UUID) *StatementHandle {
 	return &StatementHandle{
-		c:      c,
-		resp:   nil,
-		id:     id,
-		Format: ResultFormatJSON,
+		c:           c,
+		resp:        nil,
+		id:          id,
+		Format:      c.defaultResultFormat(),
+		WaitTimeout: c.config.DefaultWaitTimeout,
 	}
 }
 
+// ID returns the ID of the statement.
+func (h *StatementHandle) ID() uuid.UUID {
+	return h.id
+}
+
+// Message returns the last seen message of the statement, which explains why
+// the statement failed or was cancelled.
+//
+// An empty string is returned if there is no message or nothing has been fetched yet.
+func (h *StatementHandle) Message() string {
+	if h.resp == nil || h.resp.Message == nil {
+		return ""
+	}
+	return *h.resp.Message
+}
+
+// CreatedAt returns the time the statement was created on ScopeDB.
+//
+// The zero time is returned if nothing has been fetched yet.
+func (h *StatementHandle) CreatedAt() time.Time {
+	if h.resp == nil {
+		return time.Time{}
+	}
+	return h.resp.Created
+}
+
+// RequestID returns the ID of the HTTP request that submitted the statement,
+// to correlate the statement with server logs.
+//
+// It is empty for handles created by Client.StatementHandle. If the submit
+// request fails, its ID is available from the error with RequestIDOf.
+func (h *StatementHandle) RequestID() string {
+	return h.requestID
+}
+
 // Status returns the last seen status of the statement.
 func (h *StatementHandle) Status() *StatementStatus {
 	if h.resp == nil {
@@ -129,11 +414,17 @@ func (h *StatementHandle) Progress() *StatementProgress {
 }
 
 // ResultSet returns the result set of the statement if available.
+//
+// A statement that finished without a result set, e.g., a DDL statement, has
+// an empty result set with no columns and no rows.
 func (h *StatementHandle) ResultSet() *ResultSet {
 	if h.resp == nil {
 		return nil
 	}
 	if h.resp.ResultSet == nil {
+		if h.resp.Status == StatementStatusFinished && (h.resp.Message == nil || *h.resp.Message == "") {
+			return &ResultSet{Format: h.Format, rows: json.RawMessage("[]")}
+		}
 		return nil
 	}
 	return h.resp.ResultSet.toResultSet()
@@ -143,58 +434,251 @@ func (h *StatementHandle) ResultSet() *ResultSet {
 //
 // If the last seen status is terminated, no fetch is performed.
 func (h *StatementHandle) FetchOnce(ctx context.Context) error {
-	if h.resp != nil && h.resp.Status.Terminated() {
+	return h.fetchOnce(ctx, nil)
+}
+
+func (h *StatementHandle) fetchOnce(ctx context.Context, page *resultPage) error {
+	if h.cached(page) {
 		return nil
 	}
 
-	resp, err := h.c.fetchStatementResult(ctx, h.id, h.Format)
+	resp, err := h.c.fetchStatementResult(ctx, h.id, h.Format, page, h.WaitTimeout)
 	if err != nil {
 		return err
 	}
 
 	h.resp = resp
+	h.page = page
+	h.rows = nil
+	if page != nil && resp.Status.Terminated() && resp.ResultSet != nil && resp.ResultSet.Metadata != nil {
+		// A server that does not support paging returns the whole result;
+		// keep it so that later pages are served from it.
+		var rows []json.RawMessage
+		if err := json.Unmarshal(resp.ResultSet.Rows, &rows); err == nil && uint64(len(rows)) == resp.ResultSet.Metadata.NumRows {
+			h.page = nil
+			h.rows = rows
+		}
+	}
 	if resp.Message != nil {
 		return &Error{Message: *resp.Message}
 	}
 	return nil
 }
 
+// cached returns true if the last seen response is terminated and covers the page.
+//
+// A response fetched without a page covers every page.
+func (h *StatementHandle) cached(page *resultPage) bool {
+	if h.resp == nil || !h.resp.Status.Terminated() {
+		return false
+	}
+	return h.page == nil || (page != nil && *h.page == *page)
+}
+
 // Fetch fetches the result set of the statement until it is finished, failed or cancelled.
 //
 // When the statement is finished, the result set is returned. Otherwise, an error is returned.
 func (h *StatementHandle) Fetch(ctx context.Context) (*ResultSet, error) {
-	tick := 5 * time.Millisecond
-	maxTick := 1 * time.Second
+	return h.fetch(ctx, nil)
+}
+
+// FetchPage fetches limit rows of the result set starting at offset, waiting
+// until the statement is finished, failed or cancelled.
+//
+// The returned ResultSet contains only the rows of the page; its TotalRows is
+// the number of rows of the whole result and its Offset is the given offset.
+// Page boundaries are stable since the result of a finished statement never
+// changes.
+//
+// The offset and limit are sent to ScopeDB as query parameters. If the server
+// returns the whole result instead, the page is sliced on the client side, and
+// later pages are sliced from the same result without fetching again.
+func (h *StatementHandle) FetchPage(ctx context.Context, offset, limit uint64) (*ResultSet, error) {
+	return h.fetch(ctx, &resultPage{Offset: offset, Limit: limit})
+}
 
-	ticker := time.NewTicker(tick)
-	defer ticker.Stop()
+// Pages returns an iterator that walks the whole result set page by page,
+// fetching pageSize rows at a time with FetchPage.
+//
+// The iteration stops after the first error.
+func (h *StatementHandle) Pages(ctx context.Context, pageSize uint64) iter.Seq2[*ResultSet, error] {
+	return func(yield func(*ResultSet, error) bool) {
+		if pageSize == 0 {
+			yield(nil, errors.New("page size must be positive"))
+			return
+		}
 
-	for {
-		if h.resp != nil {
-			if h.resp.ResultSet != nil {
-				return h.resp.ResultSet.toResultSet(), nil
+		var offset uint64
+		for {
+			rs, err := h.FetchPage(ctx, offset, pageSize)
+			if err != nil {
+				yield(nil, err)
+				return
 			}
-			if h.resp.Message != nil {
-				return nil, &Error{Message: *h.resp.Message}
+			n, err := rs.numRows()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if n == 0 && offset > 0 {
+				return
+			}
+			if !yield(rs, nil) {
+				return
+			}
+			offset += uint64(n)
+			if n == 0 || offset >= rs.TotalRows {
+				return
 			}
 		}
+	}
+}
+
+func (h *StatementHandle) fetch(ctx context.Context, page *resultPage) (*ResultSet, error) {
+	opts := h.FetchOptions.withDefaults()
+	interval := opts.InitialInterval
+	// failures is the number of consecutive transient fetch failures.
+	failures := 0
+
+	timer := time.NewTimer(opts.jittered(interval))
+	defer timer.Stop()
 
-		if tick < maxTick {
-			tick = min(tick*2, maxTick)
-			ticker.Reset(tick)
+	for {
+		if h.cached(page) || (h.resp != nil && h.page == page) {
+			if rs := h.ResultSet(); rs != nil {
+				switch {
+				case page == nil:
+					return rs, nil
+				case h.page == nil:
+					return h.slicePage(rs, page)
+				default:
+					rs.Offset = page.Offset
+					rs.limit = page.Limit
+					return rs, nil
+				}
+			}
+			if h.resp.Message != nil && *h.resp.Message != "" {
+				return nil, &Error{Message: *h.resp.Message}
+			}
+			if h.resp.Status.Terminated() {
+				return nil, &Error{Message: fmt.Sprintf("statement %s is %s", h.id, h.resp.Status)}
+			}
 		}
 
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-ticker.C:
-			if err := h.FetchOnce(ctx); err != nil {
+		case <-timer.C:
+			err := h.fetchOnce(ctx, page)
+			switch {
+			case err == nil:
+				failures = 0
+			case !isTransientError(err):
 				return nil, err
+			default:
+				failures++
+				if failures > h.maxFetchFailures() {
+					return nil, &FetchError{StatementID: h.id, Failures: failures, Err: err}
+				}
 			}
+			interval = opts.next(interval)
+			timer.Reset(opts.jittered(interval))
 		}
 	}
 }
 
+// slicePage returns the rows of the page in rs, which holds the whole result.
+func (h *StatementHandle) slicePage(rs *ResultSet, page *resultPage) (*ResultSet, error) {
+	if h.rows == nil {
+		if err := json.Unmarshal(rs.rows, &h.rows); err != nil {
+			return nil, err
+		}
+	}
+
+	start := min(page.Offset, uint64(len(h.rows)))
+	end := min(start+page.Limit, uint64(len(h.rows)))
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range h.rows[start:end] {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(row)
+	}
+	buf.WriteByte(']')
+
+	rs.Offset = page.Offset
+	rs.rows = buf.Bytes()
+	rs.limit = page.Limit
+	return rs, nil
+}
+
+// maxFetchFailures returns MaxFetchFailures or its default.
+func (h *StatementHandle) maxFetchFailures() int {
+	switch {
+	case h.MaxFetchFailures < 0:
+		return 0
+	case h.MaxFetchFailures == 0:
+		return defaultMaxFetchFailures
+	default:
+		return h.MaxFetchFailures
+	}
+}
+
+// FetchOptions controls the interval between the polls of
+// StatementHandle.Fetch. Each interval is the previous one times Multiplier,
+// capped at MaxInterval.
+type FetchOptions struct {
+	// InitialInterval is the wait before the first poll. The default is 10ms.
+	InitialInterval time.Duration
+	// MaxInterval is the longest wait between polls. The default is 1s.
+	MaxInterval time.Duration
+	// Multiplier is the factor by which the interval grows after each poll.
+	// The default is 2. Values below 1 are treated as 1, i.e., a fixed interval.
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction of the interval in
+	// either direction, so that many clients do not poll in lockstep. It is
+	// clamped to [0, 1]. The default is zero, which disables jitter.
+	Jitter float64
+}
+
+// withDefaults returns the options with zero values replaced by defaults.
+func (o FetchOptions) withDefaults() FetchOptions {
+	if o.InitialInterval <= 0 {
+		o.InitialInterval = 10 * time.Millisecond
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = time.Second
+	}
+	o.InitialInterval = min(o.InitialInterval, o.MaxInterval)
+	switch {
+	case o.Multiplier == 0:
+		o.Multiplier = 2
+	case o.Multiplier < 1:
+		o.Multiplier = 1
+	}
+	o.Jitter = max(0, min(o.Jitter, 1))
+	return o
+}
+
+// next returns the interval that follows interval.
+func (o FetchOptions) next(interval time.Duration) time.Duration {
+	next := float64(interval) * o.Multiplier
+	if next >= float64(o.MaxInterval) {
+		return o.MaxInterval
+	}
+	return time.Duration(next)
+}
+
+// jittered returns interval randomized by Jitter.
+func (o FetchOptions) jittered(interval time.Duration) time.Duration {
+	if o.Jitter == 0 {
+		return interval
+	}
+	delta := o.Jitter * (2*rand.Float64() - 1)
+	return time.Duration(float64(interval) * (1 + delta))
+}
+
 // Cancel cancels the statement if it is running or pending.
 func (h *StatementHandle) Cancel(ctx context.Context) (*StatementStatus, error) {
 	if h.resp != nil && h.resp.Status.Terminated() {
@@ -206,6 +690,9 @@ func (h *StatementHandle) Cancel(ctx context.Context) (*StatementStatus, error)
 		return nil, err
 	}
 
+	if h.resp == nil {
+		h.resp = &statementResponse{ID: h.id, Created: resp.Created}
+	}
 	h.resp.Status = resp.Status
 	h.resp.Message = &resp.Message
 	return &resp.Status, nil
@@ -0,0 +1,759 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// newStatementTestServer serves a finished statement whose result has numRows
+// int rows. The server ignores paging parameters but records them.
+func newStatementTestServer(t *testing.T, numRows int) (*httptest.Server, *[]http.Request) {
+	t.Helper()
+
+	rows := make([][]*string, numRows)
+	for i := range rows {
+		rows[i] = []*string{ptr(strconv.Itoa(i))}
+	}
+	return newResultTestServer(t, []resultSetField{{Name: "i", DataType: "int"}}, rows)
+}
+
+// newResultTestServer serves a finished statement with the given result for
+// every request, and records the requests.
+func newResultTestServer(t *testing.T, fields []resultSetField, rows [][]*string) (*httptest.Server, *[]http.Request) {
+	t.Helper()
+
+	return newRoutingTestServer(t, func(string) ([]resultSetField, [][]*string) {
+		return fields, rows
+	})
+}
+
+// newRoutingTestServer serves finished statements whose results are returned
+// by route for the submitted statement text, and records the requests.
+//
+// Fetch requests are served with the result of the last submitted statement.
+func newRoutingTestServer(t *testing.T, route func(stmt string) ([]resultSetField, [][]*string)) (*httptest.Server, *[]http.Request) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []http.Request
+	var lastStmt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, *r)
+
+		if r.Method == http.MethodPost {
+			body, err := decodeCompressedRequestBody(r)
+			require.NoError(t, err)
+			var req statementRequest
+			require.NoError(t, json.Unmarshal(body, &req))
+			lastStmt = req.Statement
+		}
+
+		fields, rows := route(lastStmt)
+		rowsData, err := json.Marshal(rows)
+		require.NoError(t, err)
+
+		resp := map[string]any{
+			"statement_id": uuid.NewString(),
+			"status":       StatementStatusFinished,
+			"created_at":   "2026-01-01T00:00:00Z",
+			"progress":     map[string]any{},
+			"result_set": map[string]any{
+				"metadata": map[string]any{
+					"fields":   fields,
+					"num_rows": len(rows),
+				},
+				"format": "json",
+				"rows":   json.RawMessage(rowsData),
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// newFailingTestServer fails every submitted statement with the given message
+// and records the submitted statements.
+func newFailingTestServer(t *testing.T, message string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	return newSelectiveFailingTestServer(t, message, func(string) bool { return true })
+}
+
+// newSelectiveFailingTestServer fails the submitted statements that fail
+// reports with the given message, finishes the others with an empty result,
+// and records the submitted statements.
+func newSelectiveFailingTestServer(t *testing.T, message string, fail func(stmt string) bool) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var stmts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, err := decodeCompressedRequestBody(r)
+		require.NoError(t, err)
+		var req statementRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		stmts = append(stmts, req.Statement)
+
+		resp := map[string]any{
+			"statement_id": uuid.NewString(),
+			"status":       StatementStatusFinished,
+			"created_at":   "2026-01-01T00:00:00Z",
+			"progress":     map[string]any{},
+		}
+		if fail(req.Statement) {
+			resp["status"] = StatementStatusFailed
+			resp["message"] = message
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &stmts
+}
+
+func TestStatementHandlePagesSlicesLocally(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newStatementTestServer(t, 5)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	h := c.StatementHandle(uuid.New())
+	var pages [][][]Value
+	for rs, err := range h.Pages(context.Background(), 2) {
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), rs.TotalRows)
+		require.Equal(t, uint64(len(pages)*2), rs.Offset)
+		values, err := rs.ToValues()
+		require.NoError(t, err)
+		pages = append(pages, values)
+	}
+	require.Equal(t, [][][]Value{
+		{{int64(0)}, {int64(1)}},
+		{{int64(2)}, {int64(3)}},
+		{{int64(4)}},
+	}, pages)
+
+	require.Len(t, *requests, 1, "later pages should be sliced from the whole result")
+	require.Equal(t, "0", (*requests)[0].URL.Query().Get("offset"))
+	require.Equal(t, "2", (*requests)[0].URL.Query().Get("limit"))
+}
+
+func TestStatementHandlePagesFetchesServerPages(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		mu.Unlock()
+
+		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		require.NoError(t, err)
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		require.NoError(t, err)
+		var rows [][]string
+		for i := offset; i < min(offset+limit, 5); i++ {
+			rows = append(rows, []string{strconv.Itoa(i)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"statement_id": uuid.NewString(),
+			"status":       StatementStatusFinished,
+			"created_at":   "2026-01-01T00:00:00Z",
+			"progress":     map[string]any{},
+			"result_set": map[string]any{
+				"metadata": map[string]any{
+					"fields":   []resultSetField{{Name: "i", DataType: "int"}},
+					"num_rows": 5,
+				},
+				"format": "json",
+				"rows":   rows,
+			},
+		})
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	var values [][]Value
+	for rs, err := range c.StatementHandle(uuid.New()).Pages(context.Background(), 2) {
+		require.NoError(t, err)
+		page, err := rs.ToValues()
+		require.NoError(t, err)
+		values = append(values, page...)
+	}
+	require.Equal(t, [][]Value{{int64(0)}, {int64(1)}, {int64(2)}, {int64(3)}, {int64(4)}}, values)
+	require.Equal(t, []string{"0", "2", "4"}, offsets)
+}
+
+func TestStatementHandleFetchPageUsesFinishedResult(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newStatementTestServer(t, 3)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	h, err := c.Statement("FROM t").Submit(context.Background())
+	require.NoError(t, err)
+
+	rs, err := h.FetchPage(context.Background(), 1, 10)
+	require.NoError(t, err)
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, [][]Value{{int64(1)}, {int64(2)}}, values)
+	require.Len(t, *requests, 1, "the finished submit response should be sliced without fetching")
+}
+
+func TestStatementHandleStream(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newStatementTestServer(t, 7)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	rows, errs := c.StatementHandle(uuid.New()).Stream(context.Background(), StreamOptions{PageSize: 3})
+	var values []Value
+	for row := range rows {
+		values = append(values, row[0])
+	}
+	require.NoError(t, <-errs)
+	require.Equal(t, []Value{int64(0), int64(1), int64(2), int64(3), int64(4), int64(5), int64(6)}, values)
+}
+
+func TestStatementHandleStreamCancel(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newStatementTestServer(t, 7)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, errs := c.StatementHandle(uuid.New()).Stream(ctx, StreamOptions{PageSize: 2})
+	<-rows
+	cancel()
+
+	for range rows {
+		// drain rows that raced with the cancellation
+	}
+	require.ErrorIs(t, <-errs, context.Canceled)
+	_, more := <-errs
+	require.False(t, more)
+}
+
+func TestStatementExecuteMaxResultRows(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newStatementTestServer(t, 5)
+	c := NewClient(&Config{Endpoint: server.URL, MaxResultRows: 3})
+	defer c.Close()
+
+	_, err := c.Statement("FROM t").Execute(context.Background())
+	require.ErrorIs(t, err, ErrResultTooLarge)
+	require.ErrorContains(t, err, "5 rows exceed the limit of 3")
+
+	s := c.Statement("FROM t")
+	s.TruncateResult = true
+	rs, err := s.Execute(context.Background())
+	require.NoError(t, err)
+	require.True(t, rs.Truncated)
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, [][]Value{{int64(0)}, {int64(1)}, {int64(2)}}, values)
+
+	s = c.Statement("FROM t")
+	s.MaxResultRows = 5
+	rs, err = s.Execute(context.Background())
+	require.NoError(t, err)
+	require.False(t, rs.Truncated)
+	values, err = rs.ToValues()
+	require.NoError(t, err)
+	require.Len(t, values, 5)
+}
+
+func TestStatementQueryRow(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newStatementTestServer(t, 2)
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	var i int
+	require.NoError(t, c.Statement("FROM t").QueryRow(context.Background()).Scan(&i))
+	require.Equal(t, 0, i)
+
+	var a, b int
+	err := c.Statement("FROM t").QueryRow(context.Background()).Scan(&a, &b)
+	require.EqualError(t, err, "expected 1 destination arguments in Scan, got 2")
+
+	v, err := c.QueryValue(context.Background(), "FROM t")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), v)
+
+	empty, _ := newStatementTestServer(t, 0)
+	c = NewClient(&Config{Endpoint: empty.URL})
+	defer c.Close()
+	require.ErrorIs(t, c.Statement("FROM t").QueryRow(context.Background()).Scan(&i), ErrNoRows)
+	_, err = c.QueryValue(context.Background(), "FROM t")
+	require.ErrorIs(t, err, ErrNoRows)
+}
+
+func TestStatementExec(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"statement_id": "0195d7a4-3c2a-7b0e-9a52-6a0f6f0c0b1d",
+			"status": "finished",
+			"created_at": "2026-01-01T00:00:00Z",
+			"progress": {"nanos_from_submitted": 1500000000},
+			"result_set": {
+				"metadata": {
+					"fields": [
+						{"name": "num_rows_updated", "data_type": "int"},
+						{"name": "num_rows_deleted", "data_type": "int"}
+					],
+					"num_rows": 1
+				},
+				"format": "json",
+				"rows": [["3", "4"]]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	result, err := c.Statement("DELETE FROM t").Exec(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &ExecResult{
+		StatementID: uuid.MustParse("0195d7a4-3c2a-7b0e-9a52-6a0f6f0c0b1d"),
+		RowsUpdated: 3,
+		RowsDeleted: 4,
+		Elapsed:     1500 * time.Millisecond,
+	}, result)
+
+	query, _ := newStatementTestServer(t, 2)
+	c = NewClient(&Config{Endpoint: query.URL})
+	defer c.Close()
+	result, err = c.Statement("FROM t").Exec(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, result.RowsInserted+result.RowsUpdated+result.RowsDeleted)
+}
+
+func TestStatementHandleAccessors(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newFailingTestServer(t, "division by zero")
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	id := uuid.New()
+	h := c.StatementHandle(id)
+	require.Equal(t, id, h.ID())
+	require.Empty(t, h.Message())
+	require.True(t, h.CreatedAt().IsZero())
+	require.Nil(t, h.Status())
+
+	h, err := c.Statement("VALUES (1 / 0)").Submit(context.Background())
+	require.NoError(t, err)
+	_, err = h.Fetch(context.Background())
+	require.EqualError(t, err, "division by zero")
+	require.Equal(t, "division by zero", h.Message())
+	require.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), h.CreatedAt())
+	require.Equal(t, StatementStatusFailed, *h.Status())
+}
+
+func TestStatementHandleFetchTerminatedWithoutMessage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"statement_id": uuid.NewString(),
+			"status":       StatementStatusFailed,
+			"created_at":   "2026-01-01T00:00:00Z",
+			"progress":     map[string]any{},
+		})
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	h := c.StatementHandle(uuid.New())
+	_, err := h.Fetch(ctx)
+	require.EqualError(t, err, fmt.Sprintf("statement %s is failed", h.ID()))
+	require.Empty(t, h.Message())
+}
+
+func TestStatementHandleCancelBeforeFetch(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, strings.HasSuffix(r.URL.Path, "/cancel"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"statement_id": uuid.NewString(),
+			"status":       StatementStatusCancelled,
+			"created_at":   "2026-01-01T00:00:00Z",
+		})
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	h := c.StatementHandle(uuid.New())
+	status, err := h.Cancel(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, StatementStatusCancelled, *status)
+	require.Empty(t, h.Message())
+
+	_, err = h.Fetch(context.Background())
+	require.EqualError(t, err, fmt.Sprintf("statement %s is cancelled", h.ID()))
+}
+
+func TestStatementWaitTimeout(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var waitTimeouts []string
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"statement_id": uuid.NewString(),
+				"status":       StatementStatusRunning,
+				"created_at":   "2026-01-01T00:00:00Z",
+				"progress":     map[string]any{},
+			})
+			return
+		}
+
+		mu.Lock()
+		waitTimeouts = append(waitTimeouts, r.URL.Query().Get("wait_timeout"))
+		fetches++
+		done := fetches > 1
+		mu.Unlock()
+		if !done {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"statement_id": uuid.NewString(),
+				"status":       StatementStatusRunning,
+				"created_at":   "2026-01-01T00:00:00Z",
+				"progress":     map[string]any{},
+			})
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	s := c.Statement("VALUES (1)")
+	s.WaitTimeout = 30 * time.Second
+	_, err := s.Execute(context.Background())
+	require.NoError(t, err)
+
+	h := c.StatementHandle(uuid.New())
+	require.NoError(t, h.FetchOnce(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"30s", "30s", ""}, waitTimeouts)
+}
+
+func TestStatementHandleFetchTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		status      int
+		failing     int
+		maxFailures int
+		expected    int // expected consecutive failures reported; zero for success
+		fetches     int
+	}{
+		{name: "recovers", status: http.StatusServiceUnavailable, failing: 3, expected: 0, fetches: 4},
+		{name: "exhausted", status: http.StatusBadGateway, failing: 10, expected: 4, fetches: 4},
+		{name: "configured", status: http.StatusTooManyRequests, failing: 10, maxFailures: 1, expected: 2, fetches: 2},
+		{name: "disabled", status: http.StatusInternalServerError, failing: 10, maxFailures: -1, expected: 1, fetches: 1},
+		{name: "not retryable", status: http.StatusNotFound, failing: 10, expected: -1, fetches: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var fetches atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(fetches.Add(1)) <= tc.failing {
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+					return
+				}
+				writeEmptyResponse(w, r)
+			}))
+			defer server.Close()
+			c := NewClient(&Config{Endpoint: server.URL})
+			defer c.Close()
+
+			h := c.StatementHandle(uuid.New())
+			h.MaxFetchFailures = tc.maxFailures
+			_, err := h.Fetch(context.Background())
+			require.Equal(t, tc.fetches, int(fetches.Load()))
+
+			var fetchErr *FetchError
+			switch {
+			case tc.expected == 0:
+				require.NoError(t, err)
+			case tc.expected < 0:
+				require.Error(t, err)
+				require.False(t, errors.As(err, &fetchErr))
+			default:
+				require.ErrorAs(t, err, &fetchErr)
+				require.Equal(t, tc.expected, fetchErr.Failures)
+				var serverErr *Error
+				require.ErrorAs(t, err, &serverErr)
+				require.Equal(t, "unavailable", serverErr.Message)
+			}
+		})
+	}
+}
+
+func TestFetchOptionsSchedule(t *testing.T) {
+	t.Parallel()
+
+	intervals := func(opts FetchOptions, n int) []time.Duration {
+		opts = opts.withDefaults()
+		result := []time.Duration{opts.InitialInterval}
+		for len(result) < n {
+			result = append(result, opts.next(result[len(result)-1]))
+		}
+		return result
+	}
+
+	ms := time.Millisecond
+	require.Equal(t, []time.Duration{
+		10 * ms, 20 * ms, 40 * ms, 80 * ms, 160 * ms, 320 * ms, 640 * ms, time.Second, time.Second,
+	}, intervals(FetchOptions{}, 9))
+	require.Equal(t, []time.Duration{
+		10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second,
+	}, intervals(FetchOptions{InitialInterval: 10 * time.Second, MaxInterval: 30 * time.Second}, 4))
+	require.Equal(t, []time.Duration{ms, ms, ms}, intervals(FetchOptions{InitialInterval: ms, Multiplier: 0.5}, 3))
+	require.Equal(t, []time.Duration{5 * ms, 5 * ms}, intervals(FetchOptions{InitialInterval: time.Second, MaxInterval: 5 * ms}, 2))
+
+	opts := FetchOptions{Jitter: 0.5}.withDefaults()
+	for range 100 {
+		d := opts.jittered(time.Second)
+		require.GreaterOrEqual(t, d, 500*ms)
+		require.LessOrEqual(t, d, 1500*ms)
+	}
+	require.Equal(t, time.Second, FetchOptions{}.withDefaults().jittered(time.Second))
+	require.Equal(t, 1.0, FetchOptions{Jitter: 3}.withDefaults().Jitter)
+}
+
+func TestStatementFetchOptions(t *testing.T) {
+	t.Parallel()
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && fetches.Add(1) < 5 {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"statement_id": uuid.NewString(),
+				"status":       StatementStatusRunning,
+				"created_at":   "2026-01-01T00:00:00Z",
+				"progress":     map[string]any{},
+			})
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	defer server.Close()
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	s := c.Statement("VALUES (1)")
+	s.FetchOptions = FetchOptions{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Jitter: 0.1}
+	h, err := s.Submit(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, s.FetchOptions, h.FetchOptions)
+
+	h = c.StatementHandle(h.ID())
+	h.FetchOptions = s.FetchOptions
+	start := time.Now()
+	_, err = h.Fetch(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 5, fetches.Load())
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestStatementHandleFetchGone(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		status   int
+		body     string
+		expected error
+	}{
+		{name: "not found", status: http.StatusNotFound, body: `{"message":"no such statement"}`, expected: ErrStatementNotFound},
+		{name: "not found message", status: http.StatusBadRequest, body: `{"message":"Statement not found"}`, expected: ErrStatementNotFound},
+		{name: "expired", status: http.StatusGone, body: `{"message":"gone"}`, expected: ErrResultExpired},
+		{name: "expired message", status: http.StatusBadRequest, body: `{"message":"result of statement has expired"}`, expected: ErrResultExpired},
+		{name: "other", status: http.StatusBadRequest, body: `{"message":"invalid format"}`},
+		{name: "transient", status: http.StatusServiceUnavailable, body: `{"message":"lease expired"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var fetches atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetches.Add(1)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+			c := NewClient(&Config{Endpoint: server.URL})
+			defer c.Close()
+
+			id := uuid.New()
+			h := c.StatementHandle(id)
+			h.MaxFetchFailures = -1
+			_, err := h.Fetch(context.Background())
+			require.Error(t, err)
+			require.NotEmpty(t, RequestIDOf(err))
+
+			var goneErr *StatementGoneError
+			if tc.expected == nil {
+				require.False(t, errors.As(err, &goneErr))
+				return
+			}
+			require.ErrorIs(t, err, tc.expected)
+			require.ErrorAs(t, err, &goneErr)
+			require.Equal(t, id, goneErr.StatementID)
+			require.EqualValues(t, 1, fetches.Load())
+			require.ErrorIs(t, h.FetchOnce(context.Background()), tc.expected)
+		})
+	}
+}
+
+func TestStatementExecuteDDL(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		stmt      string
+		resultSet any
+	}{
+		{stmt: "CREATE TABLE t (i int)"},
+		{stmt: "DROP TABLE t", resultSet: map[string]any{"format": "json"}},
+		{stmt: "OPTIMIZE TABLE t", resultSet: map[string]any{"metadata": nil, "format": "json", "rows": nil}},
+	} {
+		t.Run(tc.stmt, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := map[string]any{
+					"statement_id": uuid.NewString(),
+					"status":       StatementStatusFinished,
+					"created_at":   "2026-01-01T00:00:00Z",
+					"progress":     map[string]any{},
+				}
+				if tc.resultSet != nil {
+					resp["result_set"] = tc.resultSet
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(resp)
+			}))
+			defer server.Close()
+			c := NewClient(&Config{Endpoint: server.URL})
+			defer c.Close()
+
+			ctx := context.Background()
+			rs, err := c.Statement(tc.stmt).Execute(ctx)
+			require.NoError(t, err)
+			require.Zero(t, rs.TotalRows)
+			require.Empty(t, rs.Schema)
+			values, err := rs.ToValues()
+			require.NoError(t, err)
+			require.NotNil(t, values)
+			require.Empty(t, values)
+			require.NotEmpty(t, rs.String())
+
+			s := c.Statement(tc.stmt)
+			s.MaxResultRows = 10
+			rs, err = s.Execute(ctx)
+			require.NoError(t, err)
+			require.Zero(t, rs.TotalRows)
+
+			h, err := c.Statement(tc.stmt).Submit(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, h.ResultSet())
+		})
+	}
+}
+
+func TestStatementHandleCancelAcceptsAny2xx(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		code int
+		body string
+	}{
+		{http.StatusOK, `{"status":"cancelled","message":"cancelled by user"}`},
+		{http.StatusAccepted, `{"status":"cancelled","message":"cancelled by user"}`},
+		{http.StatusNoContent, ``},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.code)
+			_, _ = w.Write([]byte(tc.body))
+		}))
+		c := NewClient(&Config{Endpoint: server.URL})
+
+		status, err := c.StatementHandle(uuid.New()).Cancel(context.Background())
+		require.NoError(t, err, tc.code)
+		require.Equal(t, StatementStatusCancelled, *status, tc.code)
+
+		c.Close()
+		server.Close()
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+)
+
+const (
+	defaultStreamPageSize = 100_000
+	defaultStreamPrefetch = 1
+)
+
+// StreamOptions configures StatementHandle.Stream.
+type StreamOptions struct {
+	// PageSize is the number of rows fetched per page. The default is 100,000.
+	PageSize uint64
+	// Prefetch is the number of decoded pages buffered ahead of the consumer.
+	// The default is 1.
+	Prefetch int
+}
+
+// Stream fetches the result set page by page in the background and sends the
+// converted rows to the returned row channel.
+//
+// Fetching overlaps with processing, but at most Prefetch pages are buffered
+// ahead of the consumer, so an unread row channel applies backpressure.
+//
+// Both channels are closed when the stream ends. If an error occurs, or ctx is
+// done, the error is sent once on the error channel and the row channel is
+// closed without further rows.
+//
+// The handle must not be used by other goroutines until the row channel is closed.
+func (h *StatementHandle) Stream(ctx context.Context, opts StreamOptions) (<-chan []Value, <-chan error) {
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = defaultStreamPageSize
+	}
+	prefetch := opts.Prefetch
+	if prefetch <= 0 {
+		prefetch = defaultStreamPrefetch
+	}
+
+	rowCh := make(chan []Value)
+	errCh := make(chan error, 1)
+
+	type page struct {
+		values [][]Value
+		err    error
+	}
+	pageCh := make(chan page, prefetch)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer close(pageCh)
+		for rs, err := range h.Pages(ctx, pageSize) {
+			var p page
+			if err != nil {
+				p.err = err
+			} else {
+				p.values, p.err = rs.ToValues()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case pageCh <- p:
+			}
+			if p.err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(errCh)
+		defer close(rowCh)
+		defer cancel()
+
+		for {
+			var p page
+			var more bool
+			select {
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			case p, more = <-pageCh:
+			}
+
+			if !more {
+				if err := ctx.Err(); err != nil {
+					errCh <- err
+				}
+				return
+			}
+			if p.err != nil {
+				errCh <- p.err
+				return
+			}
+			for _, row := range p.values {
+				select {
+				case <-ctx.Done():
+					errCh <- ctx.Err()
+					return
+				case rowCh <- row:
+				}
+			}
+		}
+	}()
+
+	return rowCh, errCh
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// StructsOption configures how ResultSet.ToStructs maps columns to struct fields.
+type StructsOption func(*structsOptions)
+
+type structsOptions struct {
+	strict bool
+}
+
+// Strict makes ToStructs fail when a column does not match any struct field,
+// or when a nullable column matches a field that cannot hold NULL, i.e., a
+// field that is not a pointer, interface, map, slice, Null, or sql.Scanner.
+func Strict() StructsOption {
+	return func(o *structsOptions) {
+		o.strict = true
+	}
+}
+
+// ToStructs reads the result set and stores the rows into dest, which must be
+// a pointer to a slice of structs or struct pointers.
+//
+// Columns are mapped to exported fields by the name in the json tag if there
+// is one, and otherwise by the field name, preferring an exact match over a
+// case-insensitive one. Fields of embedded structs without a json tag are
+// promoted as encoding/json does, with shallower fields taking precedence.
+// Unmatched columns and fields are left alone unless Strict is given. Values are converted as ToValues does;
+// array, object, and any columns are unmarshaled with encoding/json when the
+// target field is not a string.
+//
+// This method is only valid if the result set is of the JSON format.
+func (rs *ResultSet) ToStructs(dest any, opts ...StructsOption) error {
+	var o structsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a non-nil pointer to a slice, got %T", dest)
+	}
+	sliceValue := dv.Elem()
+	elemType := sliceValue.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("dest must be a pointer to a slice of structs, got %T", dest)
+	}
+
+	fieldIndexes, err := structFieldIndexes(rs.Schema, structType, o.strict)
+	if err != nil {
+		return err
+	}
+
+	rows, err := rs.ToValues()
+	if err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(sliceValue.Type(), 0, len(rows))
+	for i, row := range rows {
+		sv := reflect.New(structType).Elem()
+		for j, fieldIndex := range fieldIndexes {
+			if fieldIndex == nil {
+				continue
+			}
+			field := fieldByIndexAlloc(sv, fieldIndex)
+			if err := convertAssign(field, row[j], rs.Schema[j]); err != nil {
+				return fmt.Errorf("row %d, column %q, field %s.%s: %w",
+					i, rs.Schema[j].Name, structType.Name(), structType.FieldByIndex(fieldIndex).Name, err)
+			}
+		}
+		if elemType.Kind() == reflect.Pointer {
+			sv = sv.Addr()
+		}
+		result = reflect.Append(result, sv)
+	}
+	sliceValue.Set(result)
+	return nil
+}
+
+// structFieldIndexes returns the struct field index for each field in schema,
+// or nil if the field does not match any struct field.
+func structFieldIndexes(schema Schema, t reflect.Type, strict bool) ([][]int, error) {
+	candidates := structFieldCandidates(t)
+
+	var unmatched []string
+	indexes := make([][]int, len(schema))
+	for i, fs := range schema {
+		for _, c := range candidates {
+			if c.name == fs.Name {
+				indexes[i] = c.index
+				break
+			}
+		}
+		if indexes[i] != nil {
+			continue
+		}
+		for _, c := range candidates {
+			if strings.EqualFold(c.name, fs.Name) {
+				indexes[i] = c.index
+				break
+			}
+		}
+		if indexes[i] == nil {
+			unmatched = append(unmatched, fs.Name)
+		}
+	}
+
+	if strict && len(unmatched) > 0 {
+		return nil, fmt.Errorf("columns do not match any field of %s: %s", t, strings.Join(unmatched, ", "))
+	}
+	if strict {
+		for i, fs := range schema {
+			if !fs.Nullable || indexes[i] == nil {
+				continue
+			}
+			f := t.FieldByIndex(indexes[i])
+			if !canHoldNull(f.Type) {
+				return nil, fmt.Errorf("nullable column %q cannot be stored in field %s of type %s", fs.Name, f.Name, f.Type)
+			}
+		}
+	}
+	return indexes, nil
+}
+
+type structFieldCandidate struct {
+	// name is the name in the json tag, or the field name if there is no tag.
+	name  string
+	index []int
+}
+
+// structFieldCandidates returns the fields of t that columns may be mapped to,
+// in breadth-first order so that shallower fields come first.
+//
+// Embedded structs, or pointers to them, without a json tag are flattened.
+func structFieldCandidates(t reflect.Type) []structFieldCandidate {
+	type level struct {
+		t     reflect.Type
+		index []int
+	}
+
+	var candidates []structFieldCandidate
+	visited := map[reflect.Type]bool{t: true}
+	queue := []level{{t: t}}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for i := range cur.t.NumField() {
+			f := cur.t.Field(i)
+			tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if tag == "-" {
+				continue
+			}
+			index := append(append([]int(nil), cur.index...), i)
+
+			if f.Anonymous && tag == "" {
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					// Promoted fields behind an unexported pointer cannot be allocated.
+					if !f.IsExported() {
+						continue
+					}
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					if !visited[ft] {
+						visited[ft] = true
+						queue = append(queue, level{t: ft, index: index})
+					}
+					continue
+				}
+			}
+			if !f.IsExported() {
+				continue
+			}
+
+			name := tag
+			if name == "" {
+				name = f.Name
+			}
+			candidates = append(candidates, structFieldCandidate{name: name, index: index})
+		}
+	}
+	return candidates
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex, but allocates nil
+// embedded struct pointers along the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+var (
+	cellScannerType = reflect.TypeFor[cellScanner]()
+	sqlScannerType  = reflect.TypeFor[sql.Scanner]()
+)
+
+// canHoldNull reports whether a value of type t can tell NULL apart.
+func canHoldNull(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	default:
+		pt := reflect.PointerTo(t)
+		return pt.Implements(cellScannerType) || pt.Implements(sqlScannerType)
+	}
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultSetToStructs(t *testing.T) {
+	t.Parallel()
+
+	type payload struct {
+		Arbitrary any `json:"arbitrary"`
+	}
+	type event struct {
+		TS      time.Time `json:"ts"`
+		Name    *string
+		Count   int32 `json:"cnt"`
+		Payload payload
+		Raw     string `json:"payload_raw"`
+		Ignored string `json:"-"`
+	}
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema: Schema{
+			newFieldSchema("ts", "timestamp"),
+			newFieldSchema("NAME", "string"),
+			newFieldSchema("cnt", "int"),
+			newFieldSchema("payload", "object"),
+			newFieldSchema("payload_raw", "object"),
+			newFieldSchema("extra", "string"),
+		},
+		Format: ResultFormatJSON,
+		rows: json.RawMessage(`[
+			["1970-01-01T00:00:00Z","scopedb","42","{\"arbitrary\":27}","{\"arbitrary\":27}","x"],
+			["1970-01-01T00:00:01Z",null,"7","{}","{}",null]
+		]`),
+	}
+
+	var events []event
+	require.NoError(t, rs.ToStructs(&events))
+	require.Equal(t, []event{
+		{
+			TS:      time.Unix(0, 0).UTC(),
+			Name:    ptr("scopedb"),
+			Count:   42,
+			Payload: payload{Arbitrary: json.Number("27")},
+			Raw:     `{"arbitrary":27}`,
+		},
+		{
+			TS:    time.Unix(1, 0).UTC(),
+			Count: 7,
+			Raw:   `{}`,
+		},
+	}, events)
+
+	var pointers []*event
+	require.NoError(t, rs.ToStructs(&pointers))
+	require.Len(t, pointers, 2)
+	require.Equal(t, events[0], *pointers[0])
+
+	err := rs.ToStructs(&events, Strict())
+	require.ErrorContains(t, err, "columns do not match any field of scopedb.event: extra")
+}
+
+func TestResultSetToStructsHonorsJSONTag(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		Name  string `json:"full_name"`
+		Email string `json:"email,omitempty"`
+	}
+
+	rs := &ResultSet{
+		TotalRows: 1,
+		Schema: Schema{
+			newFieldSchema("name", "string"),
+			newFieldSchema("EMAIL", "string"),
+		},
+		Format: ResultFormatJSON,
+		rows:   json.RawMessage(`[["scopedb","hi@scopedb.io"]]`),
+	}
+
+	var users []user
+	require.NoError(t, rs.ToStructs(&users))
+	require.Equal(t, []user{{Email: "hi@scopedb.io"}}, users)
+
+	err := rs.ToStructs(&users, Strict())
+	require.ErrorContains(t, err, "columns do not match any field of scopedb.user: name")
+}
+
+func TestResultSetToStructsEmbedded(t *testing.T) {
+	t.Parallel()
+
+	type Base struct {
+		ID   int64
+		Name string `json:"name"`
+	}
+	type Audit struct {
+		Updated time.Time `json:"updated"`
+	}
+	type Tagged struct {
+		Value string
+	}
+	type row struct {
+		Base
+		*Audit
+		Tagged `json:"tagged"`
+		Name   string `json:"name"`
+	}
+
+	rs := &ResultSet{
+		TotalRows: 1,
+		Schema: Schema{
+			newFieldSchema("id", "int"),
+			newFieldSchema("name", "string"),
+			newFieldSchema("updated", "timestamp"),
+			newFieldSchema("tagged", "object"),
+		},
+		Format: ResultFormatJSON,
+		rows:   json.RawMessage(`[["1","outer","1970-01-01T00:00:00Z","{\"Value\":\"v\"}"]]`),
+	}
+
+	var rows []row
+	require.NoError(t, rs.ToStructs(&rows, Strict()))
+	require.Equal(t, []row{{
+		Base:   Base{ID: 1},
+		Audit:  &Audit{Updated: time.Unix(0, 0).UTC()},
+		Tagged: Tagged{Value: "v"},
+		Name:   "outer",
+	}}, rows)
+}
+
+func TestResultSetToStructsReportsConversionErrors(t *testing.T) {
+	t.Parallel()
+
+	type row struct {
+		V int8 `json:"v"`
+	}
+
+	rs := &ResultSet{
+		TotalRows: 2,
+		Schema:    Schema{newFieldSchema("v", "int")},
+		Format:    ResultFormatJSON,
+		rows:      json.RawMessage(`[["1"],["1024"]]`),
+	}
+
+	var rows []row
+	err := rs.ToStructs(&rows)
+	require.EqualError(t, err, `row 1, column "v", field row.V: value 1024 overflows int8`)
+
+	require.ErrorContains(t, rs.ToStructs(rows), "dest must be a non-nil pointer to a slice")
+	var ints []int
+	require.ErrorContains(t, rs.ToStructs(&ints), "dest must be a pointer to a slice of structs")
+}
+
+func TestResultSetIntegerPrecision(t *testing.T) {
+	t.Parallel()
+
+	rs := &ResultSet{
+		TotalRows: 1,
+		Schema: Schema{
+			newFieldSchema("u", "uint"),
+			newFieldSchema("i", "int"),
+			newFieldSchema("v", "object"),
+		},
+		Format: ResultFormatJSON,
+		rows:   json.RawMessage(`[["18446744073709551615","-9223372036854775808","{\"id\":9007199254740993}"]]`),
+	}
+
+	values, err := rs.ToValues()
+	require.NoError(t, err)
+	require.Equal(t, []Value{uint64(math.MaxUint64), int64(math.MinInt64), `{"id":9007199254740993}`}, values[0])
+
+	type row struct {
+		U uint64         `json:"u"`
+		I int64          `json:"i"`
+		V map[string]any `json:"v"`
+	}
+	var rows []row
+	require.NoError(t, rs.ToStructs(&rows))
+	require.Equal(t, []row{{
+		U: math.MaxUint64,
+		I: math.MinInt64,
+		V: map[string]any{"id": json.Number("9007199254740993")},
+	}}, rows)
+
+	type typed struct {
+		ID uint64 `json:"id"`
+	}
+	type typedRow struct {
+		V typed `json:"v"`
+	}
+	var typedRows []typedRow
+	require.NoError(t, rs.ToStructs(&typedRows))
+	require.Equal(t, uint64(9007199254740993), typedRows[0].V.ID)
+
+	rs.rows = json.RawMessage(`[["1","1","{} {}"]]`)
+	require.Error(t, rs.ToStructs(&rows))
+}
+
+func TestResultSetToStructsNullable(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"version":1,"total_rows":1,"format":"json","fields":[` +
+		`{"name":"n","data_type":"int","nullable":true},{"name":"m","data_type":"int"}],"rows":[[null,"1"]]}`)
+	rs, err := NewResultSetFromJSON(data)
+	require.NoError(t, err)
+	require.True(t, rs.Schema[0].Nullable)
+	require.False(t, rs.Schema[1].Nullable)
+	roundTrip, err := json.Marshal(rs)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(roundTrip))
+
+	type plain struct {
+		N int64 `json:"n"`
+		M int64 `json:"m"`
+	}
+	var plains []plain
+	require.NoError(t, rs.ToStructs(&plains))
+	require.EqualError(t, rs.ToStructs(&plains, Strict()), `nullable column "n" cannot be stored in field N of type int64`)
+
+	type pointer struct {
+		N *int64 `json:"n"`
+		M int64  `json:"m"`
+	}
+	var pointers []pointer
+	require.NoError(t, rs.ToStructs(&pointers, Strict()))
+	require.Equal(t, []pointer{{M: 1}}, pointers)
+
+	type wrapped struct {
+		N Null[int64]   `json:"n"`
+		M sql.NullInt64 `json:"m"`
+	}
+	var wrappeds []wrapped
+	require.NoError(t, rs.ToStructs(&wrappeds, Strict()))
+	require.Equal(t, []wrapped{{M: sql.NullInt64{Int64: 1, Valid: true}}}, wrappeds)
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaOf derives a Schema from the exported fields of the struct type T.
+//
+// The column name and type of a field are taken from the scopedb struct tag,
+// e.g., `scopedb:"ts,timestamp"`. If the name is absent, the name in the json
+// tag is used, and then the field name. If the type is absent, it is inferred
+// from the field type:
+//
+//   - string: string
+//   - bool: boolean
+//   - signed integers: int
+//   - unsigned integers: uint
+//   - floats: float
+//   - time.Time and Timestamp: timestamp
+//   - time.Duration: interval
+//   - Binary and []byte: binary
+//   - *big.Rat: decimal
+//   - structs, maps, slices, arrays, and interfaces: any
+//
+// Pointers are inferred by their element type. Fields tagged with "-" are
+// skipped. Note that encoding/json marshals []byte as base64; use Binary for
+// fields sent via DataCable.
+func SchemaOf[T any]() (Schema, error) {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct type, got %s", t)
+	}
+
+	var schema Schema
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, typ, _ := strings.Cut(f.Tag.Get("scopedb"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if jsonName == "-" {
+				continue
+			}
+			name = jsonName
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		dataType := DataType(typ)
+		if dataType == "" {
+			var err error
+			if dataType, err = inferDataType(f.Type); err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		} else if err := validateDataType(dataType); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		schema = append(schema, newFieldSchema(name, string(dataType)))
+	}
+	return schema, nil
+}
+
+func inferDataType(t reflect.Type) (DataType, error) {
+	switch t {
+	case reflect.TypeFor[time.Time](), reflect.TypeFor[Timestamp]():
+		return TimestampDataType, nil
+	case reflect.TypeFor[time.Duration]():
+		return IntervalDataType, nil
+	case reflect.TypeFor[Binary](), reflect.TypeFor[[]byte]():
+		return BinaryDataType, nil
+	case reflect.TypeFor[*big.Rat](), reflect.TypeFor[big.Rat]():
+		return DecimalDataType, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return inferDataType(t.Elem())
+	case reflect.String:
+		return StringDataType, nil
+	case reflect.Bool:
+		return BooleanDataType, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntDataType, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return UIntDataType, nil
+	case reflect.Float32, reflect.Float64:
+		return FloatDataType, nil
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+		return AnyDataType, nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// Columns returns the columns of the schema, e.g., for Table.Create.
+func (s Schema) Columns() Columns {
+	columns := make(Columns, len(s))
+	for i, fs := range s {
+		columns[i] = Column{Name: fs.Name, Type: fs.dataType()}
+	}
+	return columns
+}
+
+// CableTransform returns a DataCable transform that inserts records with the
+// given schema into the table, e.g.:
+//
+//	SELECT $0["ts"]::timestamp, $0["name"]::string, $0["v"]
+//	INSERT INTO `events` (`ts`, `name`, `v`)
+//
+// Each field is read from the record key of the same name and cast to the
+// field type; array, object, and any fields are inserted without a cast.
+func (t *Table) CableTransform(schema Schema) (string, error) {
+	return t.cableTransform(schema, &cableTableOptions{})
+}
+
+func (t *Table) cableTransform(schema Schema, opts *cableTableOptions) (string, error) {
+	if len(schema) == 0 {
+		return "", fmt.Errorf("table %s must have at least one column", t.Identifier())
+	}
+
+	exprs := make([]string, len(schema))
+	names := make([]string, len(schema))
+	for i, fs := range schema {
+		names[i] = QuoteIdent(fs.Name)
+		if expr, ok := opts.exprs[fs.Name]; ok {
+			exprs[i] = expr
+			continue
+		}
+
+		typ := fs.dataType()
+		if err := validateDataType(typ); err != nil {
+			return "", fmt.Errorf("column %q: %w", fs.Name, err)
+		}
+
+		key := fs.Name
+		if renamed, ok := opts.keys[fs.Name]; ok {
+			key = renamed
+		}
+		exprs[i] = "$0[" + quote(key, '"') + "]"
+		switch fs.Type {
+		case ArrayDataType, ObjectDataType, AnyDataType:
+		default:
+			exprs[i] += "::" + string(typ)
+		}
+	}
+
+	return fmt.Sprintf("SELECT %s\nINSERT INTO %s (%s)",
+		strings.Join(exprs, ", "), t.Identifier(), strings.Join(names, ", ")), nil
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaOf(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		TS          time.Time         `json:"ts"`
+		Name        *string           `json:"name,omitempty"`
+		Count       uint32            `scopedb:"cnt"`
+		Score       float64           `scopedb:",decimal(10,2)"`
+		Fingerprint Binary            `json:"fp"`
+		Labels      map[string]string `json:"labels"`
+		Nested      struct{ A int }
+		Skipped     string `scopedb:"-"`
+		Ignored     string `json:"-"`
+		private     int
+	}
+
+	schema, err := SchemaOf[event]()
+	require.NoError(t, err)
+	require.Equal(t, Columns{
+		{"ts", TimestampDataType},
+		{"name", StringDataType},
+		{"cnt", UIntDataType},
+		{"Score", "decimal(10,2)"},
+		{"fp", BinaryDataType},
+		{"labels", AnyDataType},
+		{"Nested", AnyDataType},
+	}, schema.Columns())
+
+	transform, err := NewClient(&Config{}).Table("events").CableTransform(schema)
+	require.NoError(t, err)
+	require.Equal(t, `SELECT $0["ts"]::timestamp, $0["name"]::string, $0["cnt"]::uint, $0["Score"]::decimal(10,2), $0["fp"]::binary, $0["labels"], $0["Nested"]
+INSERT INTO `+"`events` (`ts`, `name`, `cnt`, `Score`, `fp`, `labels`, `Nested`)", transform)
+
+	_, err = SchemaOf[int]()
+	require.EqualError(t, err, "expected a struct type, got int")
+
+	type bad struct {
+		C chan int
+	}
+	_, err = SchemaOf[bad]()
+	require.EqualError(t, err, "field C: unsupported type chan int")
+
+	type badTag struct {
+		V int `scopedb:"v,variant"`
+	}
+	_, err = SchemaOf[badTag]()
+	require.EqualError(t, err, `field V: unknown data type: "variant"`)
+}
@@ -19,7 +19,14 @@ package scopedb
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Table represents a table like object (table, view, etc.) in ScopeDB.
@@ -37,49 +44,215 @@ type Table struct {
 	Schema string
 	// Table is the name of the table.
 	Table string
+	// Kind is the kind of the object, e.g., TableKindView.
+	//
+	// This is informational and set by Client.View and Client.ListTables;
+	// it is empty for tables created by Client.Table and Client.ParseTable.
+	Kind TableKind
 }
 
+// TableKind is the kind of a table like object.
+type TableKind string
+
+const (
+	// TableKindTable is a base table.
+	TableKindTable TableKind = "table"
+	// TableKindView is a view.
+	TableKindView TableKind = "view"
+)
+
 // Table creates a new Table object with the given name.
+//
+// The database and schema default to Config.Database and Config.Schema.
 func (c *Client) Table(tableName string) *Table {
-	return &Table{
+	t := &Table{
 		c:     c,
 		Table: tableName,
 	}
+	c.applyTableDefaults(t)
+	return t
+}
+
+// ParseTable creates a new Table object from a possibly qualified table name,
+// e.g., "events", "public.events", or "scopedb.public.events".
+//
+// The parts left out are filled from Config.Database and Config.Schema.
+//
+// Parts may be quoted with backticks to contain dots or other special
+// characters, e.g., "public.`my.events`". Names with more than three parts or
+// unbalanced quotes are rejected.
+func (c *Client) ParseTable(name string) (*Table, error) {
+	parts, err := splitQualifiedName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Table{c: c}
+	switch len(parts) {
+	case 1:
+		t.Table = parts[0]
+	case 2:
+		t.Schema, t.Table = parts[0], parts[1]
+	case 3:
+		t.Database, t.Schema, t.Table = parts[0], parts[1], parts[2]
+	default:
+		return nil, fmt.Errorf("invalid table name %q: expected at most 3 parts, got %d", name, len(parts))
+	}
+	c.applyTableDefaults(t)
+	return t, nil
+}
+
+// applyTableDefaults fills the database and schema that t leaves out from the
+// client config. See Config.Schema for the precedence.
+func (c *Client) applyTableDefaults(t *Table) {
+	if t.Schema == "" {
+		t.Schema = c.config.Schema
+		if t.Schema == "" && c.config.Database != "" {
+			t.Schema = "public"
+		}
+	}
+	if t.Database == "" && t.Schema != "" {
+		t.Database = c.config.Database
+	}
+}
+
+// splitQualifiedName splits a dot-separated name into unquoted parts.
+func splitQualifiedName(name string) ([]string, error) {
+	var parts []string
+	var b strings.Builder
+	quoted, inQuote := false, false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case inQuote && c == '\\':
+			i++
+			if i >= len(name) {
+				return nil, fmt.Errorf("invalid table name %q: unterminated escape", name)
+			}
+			switch name[i] {
+			case 't':
+				b.WriteByte('\t')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 'x':
+				if i+2 >= len(name) {
+					return nil, fmt.Errorf("invalid table name %q: malformed escape", name)
+				}
+				v, err := strconv.ParseUint(name[i+1:i+3], 16, 8)
+				if err != nil {
+					return nil, fmt.Errorf("invalid table name %q: malformed escape", name)
+				}
+				b.WriteByte(byte(v))
+				i += 2
+			default:
+				b.WriteByte(name[i])
+			}
+		case c == '`':
+			if !inQuote && (quoted || b.Len() > 0) {
+				return nil, fmt.Errorf("invalid table name %q: unexpected quote at %d", name, i)
+			}
+			inQuote = !inQuote
+			quoted = true
+		case inQuote:
+			b.WriteByte(c)
+		case c == '.':
+			if b.Len() == 0 && !quoted {
+				return nil, fmt.Errorf("invalid table name %q: empty part", name)
+			}
+			parts = append(parts, b.String())
+			b.Reset()
+			quoted = false
+		default:
+			if quoted {
+				return nil, fmt.Errorf("invalid table name %q: unexpected character after quote at %d", name, i)
+			}
+			b.WriteByte(c)
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("invalid table name %q: unbalanced quote", name)
+	}
+	if b.Len() == 0 && !quoted {
+		return nil, fmt.Errorf("invalid table name %q: empty part", name)
+	}
+	return append(parts, b.String()), nil
 }
 
 // Drop drops the table from ScopeDB.
 //
 // This method issues a DROP TABLE statement to ScopeDB and blocks until done.
-func (t *Table) Drop(ctx context.Context) error {
-	s := t.c.Statement(fmt.Sprintf(`DROP TABLE %s`, t.Identifier()))
+// At most one DropOptions may be given.
+func (t *Table) Drop(ctx context.Context, opts ...DropOptions) error {
+	ifExists := ""
+	for _, opt := range opts {
+		if opt.IfExists {
+			ifExists = "IF EXISTS "
+		}
+	}
+	s := t.c.Statement(fmt.Sprintf(`DROP TABLE %s%s`, ifExists, t.Identifier()))
 	_, err := s.Execute(ctx)
 	return err
 }
 
-// TableSchema returns the schema of the table.
+// OptimizeOptions configures Table.Optimize.
+type OptimizeOptions struct {
+	// ExecTimeout is the maximum time for the optimization, e.g., "1h".
+	//
+	// See Statement.ExecTimeout.
+	ExecTimeout string
+}
+
+// OptimizeResult reports a finished table optimization.
+type OptimizeResult struct {
+	// StatementID is the ID of the OPTIMIZE TABLE statement.
+	StatementID uuid.UUID
+	// Duration is the time from submission to completion reported by ScopeDB.
+	Duration time.Duration
+	// Progress is the final progress of the statement, including the scanned
+	// partitions, rows, and bytes.
+	Progress StatementProgress
+}
+
+// Optimize optimizes the table.
 //
-// This method issues a meta query to ScopeDB and blocks until the result is fetched.
-func (t *Table) TableSchema(ctx context.Context) (Schema, error) {
-	var dbName, schemaName, tableName string
-	if t.Database != "" {
-		dbName = quoteIdent(t.Database, '\'')
-	} else {
-		dbName = quoteIdent("scopedb", '\'')
+// This method issues an OPTIMIZE TABLE statement to ScopeDB and blocks until done.
+func (t *Table) Optimize(ctx context.Context, opts OptimizeOptions) (*OptimizeResult, error) {
+	h, err := t.OptimizeAsync(ctx, opts)
+	if err != nil {
+		return nil, err
 	}
-	if t.Schema != "" {
-		schemaName = quoteIdent(t.Schema, '\'')
-	} else {
-		schemaName = quoteIdent("public", '\'')
+	if _, err := h.Fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &OptimizeResult{StatementID: h.id}
+	if progress := h.Progress(); progress != nil {
+		result.Duration = time.Duration(progress.NanosFromSubmitted)
+		result.Progress = *progress
 	}
-	tableName = quoteIdent(t.Table, '\'')
+	return result, nil
+}
+
+// OptimizeAsync submits an OPTIMIZE TABLE statement and returns its handle
+// without waiting, so that long-running optimizations can be tracked with
+// StatementHandle.Progress.
+func (t *Table) OptimizeAsync(ctx context.Context, opts OptimizeOptions) (*StatementHandle, error) {
+	s := t.c.Statement(fmt.Sprintf(`OPTIMIZE TABLE %s`, t.Identifier()))
+	s.ExecTimeout = opts.ExecTimeout
+	return s.Submit(ctx)
+}
 
+// TableSchema returns the schema of the table.
+//
+// This method issues a meta query to ScopeDB and blocks until the result is fetched.
+func (t *Table) TableSchema(ctx context.Context) (Schema, error) {
+	// Select every column so that is_nullable is read if ScopeDB reports it.
 	r, err := t.c.Statement(fmt.Sprintf(`
 		FROM scopedb.system.columns
-		WHERE table_name = %s
-		  AND schema_name = %s
-		  AND database_name = %s
-		SELECT column_name, data_type
-	`, tableName, schemaName, dbName)).Execute(ctx)
+		WHERE %s
+	`, t.systemFilter())).Execute(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -88,43 +261,204 @@ func (t *Table) TableSchema(ctx context.Context) (Schema, error) {
 	if records, err = r.ToValues(); err != nil {
 		return nil, err
 	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	nameIndex, err := r.Schema.FieldIndex("column_name")
+	if err != nil {
+		return nil, err
+	}
+	typeIndex, err := r.Schema.FieldIndex("data_type")
+	if err != nil {
+		return nil, err
+	}
+	nullableIndex, hasNullable := r.ColumnIndex("is_nullable")
+
 	var schema Schema
 	for _, record := range records {
-		if len(record) != 2 {
-			return nil, fmt.Errorf("expected 2 columns, got %d", len(record))
-		}
-		name, ok := record[0].(string)
+		name, ok := record[nameIndex].(string)
 		if !ok {
-			return nil, fmt.Errorf("expected string, got %T", record[0])
+			return nil, fmt.Errorf("expected string, got %T", record[nameIndex])
 		}
-		dataType, ok := record[1].(string)
+		dataType, ok := record[typeIndex].(string)
 		if !ok {
-			return nil, fmt.Errorf("expected string, got %T", record[1])
+			return nil, fmt.Errorf("expected string, got %T", record[typeIndex])
+		}
+		fs := newFieldSchema(name, dataType)
+		if hasNullable {
+			fs.Nullable = isTruthy(record[nullableIndex])
 		}
-		schema = append(schema, &FieldSchema{
-			Name: name,
-			Type: DataType(dataType),
-		})
+		schema = append(schema, fs)
 	}
 	return schema, nil
 }
 
+// isTruthy reports whether v is true or a string like "YES" or "true", as
+// system tables report flags.
+func isTruthy(v Value) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "yes") || strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
+
+// systemFilter returns the predicate that matches the table in system tables.
+func (t *Table) systemFilter() string {
+	var dbName, schemaName, tableName string
+	switch {
+	case t.Database != "":
+		dbName = QuoteString(t.Database)
+	case t.c.config.Database != "":
+		dbName = QuoteString(t.c.config.Database)
+	default:
+		dbName = QuoteString("scopedb")
+	}
+	switch {
+	case t.Schema != "":
+		schemaName = QuoteString(t.Schema)
+	case t.c.config.Schema != "":
+		schemaName = QuoteString(t.c.config.Schema)
+	default:
+		schemaName = QuoteString("public")
+	}
+	tableName = QuoteString(t.Table)
+
+	return fmt.Sprintf(`table_name = %s
+		  AND schema_name = %s
+		  AND database_name = %s`, tableName, schemaName, dbName)
+}
+
+// TableStats describes the size of a table.
+type TableStats struct {
+	// RowCount is the number of rows in the table.
+	RowCount int64
+	// Partitions is the number of partitions of the table.
+	Partitions int64
+	// CompressedBytes is the compressed storage size of the table in bytes.
+	CompressedBytes int64
+	// UncompressedBytes is the uncompressed storage size of the table in bytes.
+	UncompressedBytes int64
+	// LastModified is the time the table was last modified.
+	LastModified time.Time
+	// Incomplete is true if ScopeDB does not report some of the statistics,
+	// e.g., on older servers. The missing statistics are left zero.
+	Incomplete bool
+}
+
+// Stats returns the statistics of the table.
+//
+// This method issues a meta query on scopedb.system.tables to ScopeDB and
+// blocks until the result is fetched.
+func (t *Table) Stats(ctx context.Context) (*TableStats, error) {
+	rs, err := t.c.Statement(fmt.Sprintf(`
+		FROM scopedb.system.tables
+		WHERE %s
+	`, t.systemFilter())).Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := rs.Rows()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, fmt.Errorf("table %s not found", t.Identifier())
+	}
+
+	stats := &TableStats{}
+	for _, stat := range []struct {
+		dest    any
+		columns []string
+	}{
+		{&stats.RowCount, []string{"num_rows", "row_count"}},
+		{&stats.Partitions, []string{"num_partitions", "partition_count"}},
+		{&stats.CompressedBytes, []string{"compressed_bytes", "total_compressed_bytes"}},
+		{&stats.UncompressedBytes, []string{"uncompressed_bytes", "total_uncompressed_bytes"}},
+		{&stats.LastModified, []string{"updated_at", "last_modified"}},
+	} {
+		found := false
+		for _, column := range stat.columns {
+			i, ok := rs.ColumnIndex(column)
+			if !ok {
+				continue
+			}
+			if err := convertAssign(reflect.ValueOf(stat.dest).Elem(), rows.Values()[i], rs.Schema[i]); err != nil {
+				return nil, fmt.Errorf("column %q: %w", column, err)
+			}
+			found = true
+			break
+		}
+		stats.Incomplete = stats.Incomplete || !found
+	}
+	return stats, nil
+}
+
+// Comment returns the comment of the table, or an empty string if it has none.
+//
+// This method issues a meta query on scopedb.system.tables to ScopeDB and
+// blocks until the result is fetched.
+func (t *Table) Comment(ctx context.Context) (string, error) {
+	var comment *string
+	err := t.c.Statement(fmt.Sprintf(`
+		FROM scopedb.system.tables
+		WHERE %s
+		SELECT comment
+	`, t.systemFilter())).QueryRow(ctx).Scan(&comment)
+	if errors.Is(err, ErrNoRows) {
+		return "", fmt.Errorf("table %s not found", t.Identifier())
+	}
+	if err != nil || comment == nil {
+		return "", err
+	}
+	return *comment, nil
+}
+
+// SetComment sets the comment of the table. An empty comment clears it.
+//
+// This method issues an ALTER TABLE SET COMMENT statement to ScopeDB and
+// blocks until done.
+func (t *Table) SetComment(ctx context.Context, comment string) error {
+	stmt := fmt.Sprintf("ALTER TABLE %s SET COMMENT = %s", t.Identifier(), QuoteString(comment))
+	_, err := t.c.Statement(stmt).Execute(ctx)
+	return err
+}
+
 // Identifier returns the quoted table identifier.
 func (t *Table) Identifier() string {
 	var b bytes.Buffer
 	if t.Database != "" {
-		b.WriteString(quoteIdent(t.Database, '`'))
+		b.WriteString(QuoteIdent(t.Database))
 		b.WriteByte('.')
 	}
 	if t.Schema != "" {
-		b.WriteString(quoteIdent(t.Schema, '`'))
+		b.WriteString(QuoteIdent(t.Schema))
 		b.WriteByte('.')
 	}
-	b.WriteString(quoteIdent(t.Table, '`'))
+	b.WriteString(QuoteIdent(t.Table))
 	return b.String()
 }
 
-func quoteIdent(s string, r rune) string {
+// QuoteIdent quotes s as a ScopeQL identifier with backticks, e.g., `my table`.
+//
+// Backticks, backslashes, and control characters in s are escaped.
+func QuoteIdent(s string) string {
+	return quote(s, '`')
+}
+
+// QuoteString quotes s as a ScopeQL string literal with single quotes, e.g., 'it\'s'.
+//
+// Single quotes, backslashes, and control characters in s are escaped.
+func QuoteString(s string) string {
+	return quote(s, '\'')
+}
+
+func quote(s string, r rune) string {
 	var b bytes.Buffer
 	b.WriteRune(r)
 	for _, c := range s {
@@ -0,0 +1,238 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "`events`", QuoteIdent("events"))
+	require.Equal(t, "`a\\`b.c`", QuoteIdent("a`b.c"))
+	require.Equal(t, `'it\'s'`, QuoteString("it's"))
+	require.Equal(t, `'a\\b\n\t\r\x00"'`, QuoteString("a\\b\n\t\r\x00\""))
+}
+
+func TestClientParseTable(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(&Config{})
+	for _, tc := range []struct {
+		name     string
+		database string
+		schema   string
+		table    string
+	}{
+		{"events", "", "", "events"},
+		{"public.events", "", "public", "events"},
+		{"scopedb.public.events", "scopedb", "public", "events"},
+		{"`my.db`.`my schema`.`a\\`b`", "my.db", "my schema", "a`b"},
+		{"public.`new\\nline`", "", "public", "new\nline"},
+	} {
+		tbl, err := c.ParseTable(tc.name)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.database, tbl.Database, tc.name)
+		require.Equal(t, tc.schema, tbl.Schema, tc.name)
+		require.Equal(t, tc.table, tbl.Table, tc.name)
+
+		reparsed, err := c.ParseTable(tbl.Identifier())
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tbl, reparsed, tc.name)
+	}
+
+	for _, name := range []string{"", "a.b.c.d", "`events", "a..b", "a.", "`a`b", "a`b`"} {
+		_, err := c.ParseTable(name)
+		require.Error(t, err, name)
+	}
+}
+
+func TestClientTableDefaults(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		database, schema string
+		name             string
+		want             string
+	}{
+		{"", "", "events", "`events`"},
+		{"", "s", "events", "`s`.`events`"},
+		{"db", "", "events", "`db`.`public`.`events`"},
+		{"db", "s", "events", "`db`.`s`.`events`"},
+		{"db", "s", "x.events", "`db`.`x`.`events`"},
+		{"", "s", "x.events", "`x`.`events`"},
+		{"db", "s", "d.x.events", "`d`.`x`.`events`"},
+	} {
+		c := NewClient(&Config{Database: tc.database, Schema: tc.schema})
+		tbl, err := c.ParseTable(tc.name)
+		require.NoError(t, err, tc)
+		require.Equal(t, tc.want, tbl.Identifier(), tc)
+		if !strings.Contains(tc.name, ".") {
+			require.Equal(t, tc.want, c.Table(tc.name).Identifier(), tc)
+		}
+	}
+
+	c := NewClient(&Config{Database: "db", Schema: "s"})
+	tbl := c.Table("events")
+	tbl.Schema = "override"
+	require.Equal(t, "`db`.`override`.`events`", tbl.Identifier())
+	tbl.Database, tbl.Schema = "", ""
+	require.Contains(t, tbl.systemFilter(), "schema_name = 's'")
+	require.Contains(t, tbl.systemFilter(), "database_name = 'db'")
+
+	tbl = NewClient(&Config{}).Table("events")
+	require.Contains(t, tbl.systemFilter(), "schema_name = 'public'")
+	require.Contains(t, tbl.systemFilter(), "database_name = 'scopedb'")
+}
+
+func TestTableStatsToleratesMissingColumns(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newResultTestServer(t, []resultSetField{
+		{Name: "table_name", DataType: "string"},
+		{Name: "num_rows", DataType: "int"},
+		{Name: "updated_at", DataType: "timestamp"},
+	}, [][]*string{{ptr("events"), ptr("42"), ptr("2026-01-01T00:00:00Z")}})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	stats, err := c.Table("events").Stats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &TableStats{
+		RowCount:     42,
+		LastModified: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Incomplete:   true,
+	}, stats)
+	require.Len(t, *requests, 1)
+
+	empty, _ := newResultTestServer(t, []resultSetField{{Name: "num_rows", DataType: "int"}}, nil)
+	c = NewClient(&Config{Endpoint: empty.URL})
+	defer c.Close()
+	_, err = c.Table("events").Stats(context.Background())
+	require.EqualError(t, err, "table `events` not found")
+}
+
+func TestTableComment(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newResultTestServer(t, []resultSetField{{Name: "comment", DataType: "string"}},
+		[][]*string{{ptr("it's\nevents")}})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	comment, err := c.Table("events").Comment(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "it's\nevents", comment)
+
+	null, _ := newResultTestServer(t, []resultSetField{{Name: "comment", DataType: "string"}}, [][]*string{{nil}})
+	c = NewClient(&Config{Endpoint: null.URL})
+	defer c.Close()
+	comment, err = c.Table("events").Comment(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, comment)
+
+	empty, _ := newResultTestServer(t, []resultSetField{{Name: "comment", DataType: "string"}}, nil)
+	c = NewClient(&Config{Endpoint: empty.URL})
+	defer c.Close()
+	_, err = c.Table("events").Comment(context.Background())
+	require.EqualError(t, err, "table `events` not found")
+}
+
+func FuzzQuoteRoundTrip(f *testing.F) {
+	for _, seed := range []string{"", "events", "a`b", "it's", "back\\slash", "new\nline", "\x00\x1f", "数据库"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			t.Skip()
+		}
+		for _, q := range []rune{'`', '\''} {
+			quoted := quote(s, q)
+			require.Equal(t, s, unquote(t, quoted, q), "quoted: %s", quoted)
+		}
+	})
+}
+
+// unquote reverses quote following the escape rules of the ScopeQL lexer.
+func unquote(t *testing.T, quoted string, q rune) string {
+	t.Helper()
+
+	require.True(t, strings.HasPrefix(quoted, string(q)) && strings.HasSuffix(quoted, string(q)))
+	body := []rune(quoted[1 : len(quoted)-1])
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		require.NotEqual(t, q, c, "unescaped quote in %s", quoted)
+		require.False(t, c < 0x20, "unescaped control character in %s", quoted)
+		if c != '\\' {
+			b.WriteRune(c)
+			continue
+		}
+		i++
+		require.Less(t, i, len(body), "dangling escape in %s", quoted)
+		switch body[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'x':
+			require.LessOrEqual(t, i+2, len(body)-1)
+			v, err := strconv.ParseUint(string(body[i+1:i+3]), 16, 8)
+			require.NoError(t, err)
+			b.WriteByte(byte(v))
+			i += 2
+		default:
+			b.WriteRune(body[i])
+		}
+	}
+	return b.String()
+}
+
+func TestTableSchemaNullable(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newResultTestServer(t, []resultSetField{
+		{Name: "table_name", DataType: "string"},
+		{Name: "column_name", DataType: "string"},
+		{Name: "data_type", DataType: "string"},
+		{Name: "is_nullable", DataType: "string"},
+	}, [][]*string{
+		{ptr("events"), ptr("ts"), ptr("timestamp"), ptr("NO")},
+		{ptr("events"), ptr("v"), ptr("any"), ptr("YES")},
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	schema, err := c.Table("events").TableSchema(context.Background())
+	require.NoError(t, err)
+	require.Len(t, schema, 2)
+	require.Equal(t, "ts", schema[0].Name)
+	require.False(t, schema[0].Nullable)
+	require.Equal(t, "v", schema[1].Name)
+	require.True(t, schema[1].Nullable)
+}
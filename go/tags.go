@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ApplicationTag is the tag key set from Config.ApplicationName.
+const ApplicationTag = "application"
+
+var (
+	tagKeyPattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]{0,63}$`)
+	tagValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.:/@+-]{0,256}$`)
+)
+
+// validateTag checks that the tag key and value can be embedded in a comment.
+//
+// Keys must start with a letter or underscore and contain at most 64 letters,
+// digits, underscores, dots, or dashes. Values may contain at most 256
+// letters, digits, or any of "_.:/@+-".
+func validateTag(key, value string) error {
+	if !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid tag key %q: must match %s", key, tagKeyPattern)
+	}
+	if !tagValuePattern.MatchString(value) {
+		return fmt.Errorf("invalid tag value %q for key %q: must match %s", value, key, tagValuePattern)
+	}
+	return nil
+}
+
+// taggedStatement appends the tags of the statement, including the default
+// application tag, to the statement text as a trailing comment, e.g.,
+// "/* scopedb_tags: application=api, feature=search */".
+//
+// The comment is put on its own line after the statement so that it never
+// changes the meaning of the statement or the positions of errors in it.
+func (s *Statement) taggedStatement() (string, error) {
+	tags := make(map[string]string, len(s.Tags)+1)
+	if app := s.c.config.ApplicationName; app != "" {
+		tags[ApplicationTag] = app
+	}
+	maps.Copy(tags, s.Tags)
+	if len(tags) == 0 {
+		return s.stmt, nil
+	}
+
+	pairs := make([]string, 0, len(tags))
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if err := validateTag(key, tags[key]); err != nil {
+			return "", err
+		}
+		pairs = append(pairs, key+"="+tags[key])
+	}
+	return s.stmt + "\n/* scopedb_tags: " + strings.Join(pairs, ", ") + " */", nil
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementTags(t *testing.T) {
+	t.Parallel()
+
+	var submitted string
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		submitted = stmt
+		return nil, nil
+	})
+	c := NewClient(&Config{Endpoint: server.URL, ApplicationName: "billing-api"})
+	defer c.Close()
+
+	ctx := context.Background()
+	_, err := c.Statement("VALUES (1) -- trailing comment").Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "VALUES (1) -- trailing comment\n/* scopedb_tags: application=billing-api */", submitted)
+
+	s := c.Statement("VALUES (1)")
+	s.Tags = map[string]string{"feature": "search", "application": "override", "run.id": "v1.2:3"}
+	_, err = s.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "VALUES (1)\n/* scopedb_tags: application=override, feature=search, run.id=v1.2:3 */", submitted)
+
+	s.Tags = map[string]string{"feature": "*/ DROP TABLE t"}
+	_, err = s.Execute(ctx)
+	require.ErrorContains(t, err, `invalid tag value "*/ DROP TABLE t" for key "feature"`)
+	s.Tags = map[string]string{"1x": "v"}
+	_, err = s.Execute(ctx)
+	require.ErrorContains(t, err, `invalid tag key "1x"`)
+
+	c = NewClient(&Config{Endpoint: server.URL, ApplicationName: "bad name"})
+	defer c.Close()
+	_, err = c.Statement("VALUES (1)").Execute(ctx)
+	require.ErrorContains(t, err, "invalid application name")
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Ident is an identifier, e.g., a column name, for Render.
+type Ident string
+
+// Render renders a statement template whose named placeholders are replaced
+// with the quoted identifiers or literals of args.
+//
+// A placeholder is {name} or {name:kind}, where name is a key of args, e.g.:
+//
+//	stmt, err := scopedb.Render("FROM {table} WHERE name = {name:s} AND ts > {since:t}", map[string]any{
+//		"table": c.Table("events"),
+//		"name":  name,
+//		"since": since,
+//	})
+//
+// An untyped placeholder renders a *Table as its identifier, an Ident or a
+// []Ident as quoted identifiers, and any other value as a literal like the
+// arguments of Client.Statementf. A typed placeholder also checks the kind of
+// its value:
+//
+//   - i: an identifier, from a string, Ident, or *Table.
+//   - s: a string literal.
+//   - n: a number literal, from an integer, a float, or a *big.Rat.
+//   - b: a boolean literal.
+//   - t: a timestamp literal, from a time.Time or Timestamp.
+//   - d: an interval literal, from a time.Duration.
+//   - x: a binary literal, from a []byte or Binary.
+//
+// Placeholders are ignored inside string literals, quoted identifiers, and
+// comments; elsewhere, {{ and }} render a literal brace. A nil value renders
+// NULL for all kinds but i. Placeholders that are missing from args, and args
+// that no placeholder uses, are errors.
+//
+// Render may also build the transforms of Client.DataCable.
+func Render(template string, args map[string]any) (string, error) {
+	var b strings.Builder
+	used := make(map[string]bool, len(args))
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(template) && template[j] != c; j++ {
+				if template[j] == '\\' {
+					j++
+				}
+			}
+			j = min(j, len(template)-1)
+			b.WriteString(template[i : j+1])
+			i = j
+		case c == '-' && strings.HasPrefix(template[i:], "--"):
+			j := strings.IndexByte(template[i:], '\n')
+			if j < 0 {
+				j = len(template) - i - 1
+			}
+			b.WriteString(template[i : i+j+1])
+			i += j
+		case c == '/' && strings.HasPrefix(template[i:], "/*"):
+			j := strings.Index(template[i+2:], "*/")
+			if j < 0 {
+				j = len(template) - i - 1
+			} else {
+				j += 3
+			}
+			b.WriteString(template[i : i+j+1])
+			i += j
+		case c == '{' && strings.HasPrefix(template[i:], "{{"),
+			c == '}' && strings.HasPrefix(template[i:], "}}"):
+			b.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated placeholder at offset %d", i)
+			}
+			placeholder := template[i+1 : i+end]
+			name, kind, _ := strings.Cut(placeholder, ":")
+			if !isPlaceholderName(name) {
+				return "", fmt.Errorf("invalid placeholder {%s} at offset %d; use {{ for a literal brace", placeholder, i)
+			}
+			v, ok := args[name]
+			if !ok {
+				return "", fmt.Errorf("missing argument for placeholder {%s}", placeholder)
+			}
+			s, err := renderPlaceholder(kind, v)
+			if err != nil {
+				return "", fmt.Errorf("placeholder {%s}: %w", placeholder, err)
+			}
+			b.WriteString(s)
+			used[name] = true
+			i += end
+		case c == '}':
+			return "", fmt.Errorf("unmatched } at offset %d; use }} for a literal brace", i)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	var unused []string
+	for name := range args {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		slices.Sort(unused)
+		return "", fmt.Errorf("unused arguments: %s", strings.Join(unused, ", "))
+	}
+	return b.String(), nil
+}
+
+func isPlaceholderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// renderPlaceholder renders v for a placeholder of kind.
+func renderPlaceholder(kind string, v any) (string, error) {
+	switch kind {
+	case "":
+		switch v := v.(type) {
+		case *Table:
+			return v.Identifier(), nil
+		case Ident:
+			return QuoteIdent(string(v)), nil
+		case []Ident:
+			if len(v) == 0 {
+				return "", fmt.Errorf("unsupported empty list of type %T", v)
+			}
+			idents := make([]string, len(v))
+			for i, ident := range v {
+				idents[i] = QuoteIdent(string(ident))
+			}
+			return strings.Join(idents, ", "), nil
+		}
+		return formatLiteral(v)
+	case "i":
+		switch v := v.(type) {
+		case *Table:
+			return v.Identifier(), nil
+		case Ident:
+			return QuoteIdent(string(v)), nil
+		case string:
+			return QuoteIdent(v), nil
+		}
+		return "", fmt.Errorf("expected an identifier, got %T", v)
+	}
+
+	if v == nil {
+		return "NULL", nil
+	}
+	var ok bool
+	switch kind {
+	case "s":
+		ok = kindOf(v) == reflect.String
+	case "n":
+		switch kindOf(v) {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			ok = true
+		default:
+			_, ok = v.(*big.Rat)
+		}
+		switch v.(type) {
+		case time.Duration, *time.Duration:
+			ok = false
+		}
+	case "b":
+		ok = kindOf(v) == reflect.Bool
+	case "t":
+		switch v.(type) {
+		case time.Time, Timestamp, *time.Time, *Timestamp:
+			ok = true
+		}
+	case "d":
+		switch v.(type) {
+		case time.Duration, *time.Duration:
+			ok = true
+		}
+	case "x":
+		switch v.(type) {
+		case []byte, Binary:
+			ok = true
+		}
+	default:
+		return "", fmt.Errorf("unknown placeholder kind %q", kind)
+	}
+	if !ok {
+		return "", fmt.Errorf("unexpected argument type %T for kind %q", v, kind)
+	}
+	return formatLiteral(v)
+}
+
+// kindOf returns the kind of v, or of the element of a pointer v.
+func kindOf(v any) reflect.Kind {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return reflect.Invalid
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
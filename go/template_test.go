@@ -0,0 +1,74 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tbl := NewClient(&Config{}).Table("events")
+	tbl.Schema = "logs"
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stmt, err := Render(
+		"FROM {table} WHERE name = {name:s} AND ts > {since:t} AND note != '{name}' -- {name}\n"+
+			"AND n < {limit:n} AND {flag:b} AND age < {age:d} AND ids IN {ids} SELECT {cols}, {col:i}, {{1}}",
+		map[string]any{
+			"table": tbl,
+			"name":  "it's",
+			"since": since,
+			"limit": 10,
+			"flag":  true,
+			"age":   time.Hour,
+			"ids":   []int{1, 2},
+			"cols":  []Ident{"a b", "c"},
+			"col":   "d`e",
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "FROM `logs`.`events` WHERE name = 'it\\'s' AND ts > '2024-01-01T00:00:00Z'::timestamp "+
+		"AND note != '{name}' -- {name}\n"+
+		"AND n < 10 AND true AND age < '1h0m0s'::interval AND ids IN (1, 2) SELECT `a b`, `c`, `d\\`e`, {1}", stmt)
+
+	stmt, err = Render("VALUES ({v:s}, {w})", map[string]any{"v": nil, "w": (*int)(nil)})
+	require.NoError(t, err)
+	require.Equal(t, "VALUES (NULL, NULL)", stmt)
+
+	for _, tc := range []struct {
+		template string
+		args     map[string]any
+		err      string
+	}{
+		{template: "FROM {t}", args: map[string]any{}, err: "missing argument for placeholder {t}"},
+		{template: "FROM t", args: map[string]any{"b": 1, "a": 2}, err: "unused arguments: a, b"},
+		{template: "VALUES ({v:s})", args: map[string]any{"v": 1}, err: `placeholder {v:s}: unexpected argument type int for kind "s"`},
+		{template: "VALUES ({v:n})", args: map[string]any{"v": time.Second}, err: `placeholder {v:n}: unexpected argument type time.Duration for kind "n"`},
+		{template: "FROM {t:i}", args: map[string]any{"t": 1}, err: "placeholder {t:i}: expected an identifier, got int"},
+		{template: "VALUES ({v:q})", args: map[string]any{"v": 1}, err: `placeholder {v:q}: unknown placeholder kind "q"`},
+		{template: "VALUES ({ v })", args: nil, err: "invalid placeholder { v } at offset 8; use {{ for a literal brace"},
+		{template: "VALUES ({v", args: nil, err: "unterminated placeholder at offset 8"},
+		{template: "VALUES (v})", args: nil, err: "unmatched } at offset 9; use }} for a literal brace"},
+	} {
+		_, err := Render(tc.template, tc.args)
+		require.EqualError(t, err, tc.err, tc.template)
+	}
+}
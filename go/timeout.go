@@ -0,0 +1,104 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isoDurationPattern matches ISO 8601 durations with day and time parts, e.g., "PT1S".
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseTimeout parses a timeout like "1h30m" or "PT1S".
+func parseTimeout(s string) (time.Duration, error) {
+	if m := isoDurationPattern.FindStringSubmatch(s); m != nil && s != "P" && s != "PT" {
+		var d time.Duration
+		for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+			if m[i+1] == "" {
+				continue
+			}
+			n, err := strconv.ParseFloat(m[i+1], 64)
+			if err != nil {
+				return 0, err
+			}
+			d += time.Duration(n * float64(unit))
+		}
+		return d, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration like \"1h30m\" or \"PT1S\"", s)
+	}
+	return d, nil
+}
+
+// validateTimeout checks that s is a positive duration like "1h30m" or "PT1S".
+func validateTimeout(s string) error {
+	d, err := parseTimeout(s)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("%q is not positive", s)
+	}
+	return nil
+}
+
+const (
+	minDerivedTimeout    = time.Second
+	maxDerivedTimeout    = 24 * time.Hour
+	maxDerivedTimeoutGap = time.Second
+)
+
+// deriveTimeout returns the exec timeout derived from the remaining time
+// until a context deadline. See Config.PropagateContextDeadline.
+func deriveTimeout(remaining time.Duration) time.Duration {
+	d := remaining - min(maxDerivedTimeoutGap, remaining/10)
+	return min(max(d, minDerivedTimeout), maxDerivedTimeout).Truncate(time.Millisecond)
+}
+
+// formatTimeout formats d with integer units, e.g., "1h30m" or "1s500ms",
+// which ScopeDB accepts in place of a timeout string.
+func formatTimeout(d time.Duration) string {
+	var b strings.Builder
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+		{time.Millisecond, "ms"},
+		{time.Microsecond, "us"},
+		{time.Nanosecond, "ns"},
+	} {
+		if n := d / unit.d; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10))
+			b.WriteString(unit.name)
+			d -= n * unit.d
+		}
+	}
+	if b.Len() == 0 {
+		return "0s"
+	}
+	return b.String()
+}
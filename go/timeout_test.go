@@ -0,0 +1,121 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for s, d := range map[string]time.Duration{
+		"1h30m":      90 * time.Minute,
+		"1s500ms":    1500 * time.Millisecond,
+		"2h1ns":      2*time.Hour + time.Nanosecond,
+		"1m1s1us":    time.Minute + time.Second + time.Microsecond,
+		"100ms":      100 * time.Millisecond,
+		"24h0m1s":    24*time.Hour + time.Second,
+		"PT1.5S":     1500 * time.Millisecond,
+		"P1DT2H3M4S": 26*time.Hour + 3*time.Minute + 4*time.Second,
+	} {
+		parsed, err := parseTimeout(s)
+		require.NoError(t, err, s)
+		require.Equal(t, d, parsed, s)
+		reparsed, err := parseTimeout(formatTimeout(d))
+		require.NoError(t, err, s)
+		require.Equal(t, d, reparsed, s)
+	}
+	require.Equal(t, "24h1s", formatTimeout(24*time.Hour+time.Second))
+	require.Equal(t, "0s", formatTimeout(0))
+}
+
+func TestDeriveTimeout(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 29*time.Second, deriveTimeout(30*time.Second))
+	require.Equal(t, 4500*time.Millisecond, deriveTimeout(5*time.Second))
+	require.Equal(t, time.Second, deriveTimeout(500*time.Millisecond))
+	require.Equal(t, time.Second, deriveTimeout(-time.Second))
+	require.Equal(t, 24*time.Hour, deriveTimeout(48*time.Hour))
+}
+
+func TestPropagateContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	server, requests := newRecordingTestServer(t)
+	c := NewClient(&Config{Endpoint: server.URL, PropagateContextDeadline: true})
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := c.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+	explicit := c.Statement("VALUES (1)")
+	explicit.ExecTimeout = "5s"
+	_, err = explicit.Execute(ctx)
+	require.NoError(t, err)
+	_, err = c.Statement("VALUES (1)").Execute(context.Background())
+	require.NoError(t, err)
+
+	recorded := requests()
+	require.Len(t, recorded, 3)
+	derived, err := parseTimeout(recorded[0].Body["exec_timeout"].(string))
+	require.NoError(t, err)
+	require.InDelta(t, float64(59*time.Second), float64(derived), float64(time.Second))
+	require.Equal(t, "5s", recorded[1].Body["exec_timeout"])
+	require.NotContains(t, recorded[2].Body, "exec_timeout")
+
+	c = NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+	_, err = c.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+	require.NotContains(t, requests()[3].Body, "exec_timeout")
+}
+
+func TestStatementExecTimeout(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(&Config{})
+	timeout, err := c.Statement("").execTimeout()
+	require.NoError(t, err)
+	require.Empty(t, timeout)
+
+	s := c.Statement("")
+	s.ExecTimeout = "1h"
+	timeout, err = s.execTimeout()
+	require.NoError(t, err)
+	require.Equal(t, "1h", timeout)
+
+	timeout, err = s.WithExecTimeout(time.Hour).execTimeout()
+	require.NoError(t, err)
+	require.Equal(t, "1h", timeout)
+
+	_, err = s.WithExecTimeout(time.Minute).execTimeout()
+	require.EqualError(t, err, `conflicting exec timeouts: ExecTimeout is "1h" but ExecTimeoutDuration is 1m0s`)
+	_, err = c.Statement("").WithExecTimeout(-time.Second).execTimeout()
+	require.EqualError(t, err, "exec timeout must not be negative, got -1s")
+
+	c = NewClient(&Config{DefaultExecTimeout: "PT30S"})
+	timeout, err = c.Statement("").WithExecTimeout(90 * time.Second).execTimeout()
+	require.NoError(t, err)
+	require.Equal(t, "1m30s", timeout)
+}
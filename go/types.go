@@ -0,0 +1,151 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"strings"
+)
+
+// TypeInfo is the parsed representation of a data type string reported by ScopeDB.
+//
+// For example, "array(array(int))" is parsed as an array whose element type is
+// an array of int, and "timestamp(9)" is parsed as a timestamp with parameter "9".
+type TypeInfo struct {
+	// Kind is the base data type, e.g., ArrayDataType for "array(int)".
+	//
+	// If the type string cannot be parsed, Kind is the raw type string.
+	Kind DataType
+	// Params are the type parameters, e.g., ["38", "10"] for "decimal(38,10)".
+	//
+	// Params are not set for array and object types; see Elem and Fields instead.
+	Params []string
+	// Elem is the element type of an array type.
+	Elem *TypeInfo
+	// Fields are the nested fields of an object type, if the server reports them.
+	Fields []*FieldSchema
+	// Raw is the original type string.
+	Raw string
+}
+
+// String returns the original type string.
+func (t *TypeInfo) String() string {
+	return t.Raw
+}
+
+// ParseTypeInfo parses a data type string reported by ScopeDB.
+//
+// Unknown or malformed type strings never fail; they degrade to a TypeInfo
+// whose Kind is the raw type string.
+func ParseTypeInfo(s string) *TypeInfo {
+	raw := strings.TrimSpace(s)
+	fallback := &TypeInfo{Kind: DataType(raw), Raw: raw}
+
+	name, args, ok := splitTypeArgs(raw)
+	if !ok {
+		return fallback
+	}
+
+	info := &TypeInfo{Kind: DataType(name), Raw: raw}
+	if args == nil {
+		return info
+	}
+
+	switch info.Kind {
+	case ArrayDataType:
+		if len(args) != 1 {
+			return fallback
+		}
+		info.Elem = ParseTypeInfo(args[0])
+	case DecimalDataType:
+		if len(args) > 2 {
+			return fallback
+		}
+		for _, arg := range args {
+			info.Params = append(info.Params, strings.TrimSpace(arg))
+		}
+	case ObjectDataType:
+		for _, arg := range args {
+			fieldName, fieldType, ok := strings.Cut(strings.TrimSpace(arg), " ")
+			if !ok {
+				return fallback
+			}
+			info.Fields = append(info.Fields, newFieldSchema(fieldName, fieldType))
+		}
+	default:
+		for _, arg := range args {
+			info.Params = append(info.Params, strings.TrimSpace(arg))
+		}
+	}
+	return info
+}
+
+// splitTypeArgs splits "name(arg1, arg2(x, y))" into the name and top-level arguments.
+//
+// The returned args is nil if the type string has no parentheses.
+func splitTypeArgs(s string) (string, []string, bool) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 {
+		return s, nil, isTypeName(s)
+	}
+
+	name := strings.TrimSpace(s[:open])
+	if !isTypeName(name) || !strings.HasSuffix(s, ")") {
+		return "", nil, false
+	}
+
+	body := s[open+1 : len(s)-1]
+	args := []string{}
+	depth, start := 0, 0
+	for i, c := range body {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return "", nil, false
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, body[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return "", nil, false
+	}
+	args = append(args, body[start:])
+	for _, arg := range args {
+		if strings.TrimSpace(arg) == "" {
+			return "", nil, false
+		}
+	}
+	return name, args, true
+}
+
+func isTypeName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
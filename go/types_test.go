@@ -0,0 +1,62 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTypeInfo(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, &TypeInfo{Kind: IntDataType, Raw: "int"}, ParseTypeInfo("int"))
+	require.Equal(t, &TypeInfo{Kind: TimestampDataType, Params: []string{"9"}, Raw: "timestamp(9)"}, ParseTypeInfo("timestamp(9)"))
+	require.Equal(t, &TypeInfo{Kind: DecimalDataType, Params: []string{"38", "10"}, Raw: "decimal(38, 10)"}, ParseTypeInfo("decimal(38, 10)"))
+
+	nested := ParseTypeInfo("array(array(int))")
+	require.Equal(t, ArrayDataType, nested.Kind)
+	require.Equal(t, ArrayDataType, nested.Elem.Kind)
+	require.Equal(t, &TypeInfo{Kind: IntDataType, Raw: "int"}, nested.Elem.Elem)
+
+	obj := ParseTypeInfo("object(a int, b array(string))")
+	require.Equal(t, ObjectDataType, obj.Kind)
+	require.Len(t, obj.Fields, 2)
+	require.Equal(t, "a", obj.Fields[0].Name)
+	require.Equal(t, IntDataType, obj.Fields[0].Type)
+	require.Equal(t, "b", obj.Fields[1].Name)
+	require.Equal(t, ArrayDataType, obj.Fields[1].Type)
+	require.Equal(t, StringDataType, obj.Fields[1].TypeInfo.Elem.Kind)
+}
+
+func TestParseTypeInfoDegradesToRawString(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{
+		"array(int",
+		"array(int))",
+		"array(int, string)",
+		"decimal(38,)",
+		"decimal(38, 10, 2)",
+		"object(a)",
+		"map<string, int>",
+		"",
+	} {
+		require.Equal(t, &TypeInfo{Kind: DataType(s), Raw: s}, ParseTypeInfo(s), s)
+	}
+}
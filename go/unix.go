@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// unixSocketHost is the host of requests sent over a Unix domain socket.
+const unixSocketHost = "unix"
+
+// parseEndpoint parses Config.Endpoint. For a Unix domain socket endpoint, it
+// returns the socket path and an HTTP URL with unixSocketHost as the host.
+//
+// A socket endpoint is either "unix:///path/to/scopedb.sock", or
+// "http+unix://%2Fpath%2Fto%2Fscopedb.sock/prefix" with the socket path
+// escaped as the host, which also allows a path prefix.
+func parseEndpoint(endpoint string) (*url.URL, string, error) {
+	var socket string
+	if rest, ok := strings.CutPrefix(endpoint, "http+unix://"); ok {
+		// url.Parse rejects escaped slashes in the host, so the socket path is
+		// cut out before parsing.
+		host, path := rest, ""
+		if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+			host, path = rest[:i], rest[i:]
+		}
+		var err error
+		if socket, err = url.PathUnescape(host); err != nil {
+			return nil, "", fmt.Errorf("invalid socket path %q: %w", host, err)
+		}
+		if socket == "" {
+			return nil, "", fmt.Errorf("missing socket path in endpoint %q", endpoint)
+		}
+		endpoint = "http://" + unixSocketHost + path
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Scheme == "unix" {
+		socket = u.Path
+		if socket == "" {
+			return nil, "", fmt.Errorf("missing socket path in endpoint %q", endpoint)
+		}
+		u = &url.URL{Scheme: "http", Host: unixSocketHost, RawQuery: u.RawQuery}
+	}
+	return u, socket, nil
+}
+
+// dialUnixSocket makes transport connect to socket whatever the host of the
+// request.
+func dialUnixSocket(transport *http.Transport, socket string) {
+	transport.Proxy = nil
+	var dialer net.Dialer
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+}
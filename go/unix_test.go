@@ -0,0 +1,108 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		endpoint string
+		url      string
+		socket   string
+	}{
+		{endpoint: "http://localhost:6543", url: "http://localhost:6543"},
+		{endpoint: "unix:///var/run/scopedb.sock", url: "http://unix", socket: "/var/run/scopedb.sock"},
+		{endpoint: "http+unix://%2Fvar%2Frun%2Fscopedb.sock/scopedb/", url: "http://unix/scopedb/", socket: "/var/run/scopedb.sock"},
+	} {
+		u, socket, err := parseEndpoint(tc.endpoint)
+		require.NoError(t, err, tc.endpoint)
+		require.Equal(t, tc.url, u.String(), tc.endpoint)
+		require.Equal(t, tc.socket, socket, tc.endpoint)
+	}
+
+	_, _, err := parseEndpoint("unix://")
+	require.EqualError(t, err, `missing socket path in endpoint "unix://"`)
+	_, _, err = parseEndpoint("http+unix:///scopedb")
+	require.EqualError(t, err, `missing socket path in endpoint "http+unix:///scopedb"`)
+}
+
+func TestUnixSocketEndpoint(t *testing.T) {
+	t.Parallel()
+
+	// Socket paths are limited to about 100 bytes, which t.TempDir may exceed.
+	dir, err := os.MkdirTemp("", "scopedb")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "scopedb.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.Host+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/statements" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"statement_id": "c8fe71d6-3695-11f0-85b3-063c3400fda9",
+				"status":       StatementStatusRunning,
+				"created_at":   "2026-01-01T00:00:00Z",
+				"progress":     map[string]any{},
+			})
+			return
+		}
+		writeEmptyResponse(w, r)
+	}))
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	c := NewClient(&Config{Endpoint: "unix://" + socket})
+	defer c.Close()
+	ctx := context.Background()
+	_, err = c.Statement("VALUES (1)").Execute(ctx)
+	require.NoError(t, err)
+	_, err = c.ingest(ctx, &ingestRequest{Statement: "INSERT INTO t"})
+	require.NoError(t, err)
+
+	mu.Lock()
+	require.Equal(t, []string{
+		"POST unix/v1/statements",
+		"GET unix/v1/statements/c8fe71d6-3695-11f0-85b3-063c3400fda9",
+		"POST unix/v1/ingest",
+	}, requests)
+	mu.Unlock()
+
+	missing := filepath.Join(dir, "missing.sock")
+	_, err = NewClient(&Config{Endpoint: "unix://" + missing}).Statement("VALUES (1)").Execute(ctx)
+	require.ErrorContains(t, err, "dial unix "+missing)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is returned by Statement.Validate when the statement is invalid.
+type ValidationError struct {
+	// Message is the error message reported by ScopeDB.
+	Message string
+	// Line is the 1-based line of the error in the statement, or 0 if unknown.
+	Line int
+	// Column is the 1-based column of the error in the statement, or 0 if unknown.
+	Column int
+	// Err is the underlying server error.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("invalid statement at line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return "invalid statement: " + e.Message
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validatePrefix is put on its own line so that error lines can be mapped
+// back to the original statement.
+const validatePrefix = "EXPLAIN\n"
+
+var errorPositionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)line\s+(\d+),?\s+col(?:umn)?\s+(\d+)`),
+	regexp.MustCompile(`\bat\s+(\d+):(\d+)\b`),
+}
+
+// validateProbe is explained to tell a server that does not support EXPLAIN
+// apart from an invalid statement when the error has no position.
+const validateProbe = "VALUES (1)"
+
+// Validate checks that the statement parses and plans without executing it.
+//
+// The statement is planned with EXPLAIN, which ScopeDB never executes. If it is
+// invalid, a *ValidationError is returned with the position of the error when
+// ScopeDB reports one. If the server does not support EXPLAIN, an error
+// wrapping ErrUnsupported is returned; the statement is never executed as a
+// fallback.
+//
+// The server is taken not to support EXPLAIN if it says so, if it reports the
+// error at the EXPLAIN prefix, or, for an error without a position, if it
+// also fails to explain a trivial statement.
+func (s *Statement) Validate(ctx context.Context) error {
+	_, err := s.explain(ctx, validatePrefix)
+	var serverErr *Error
+	if err == nil || !errors.As(err, &serverErr) {
+		return err
+	}
+
+	msg := serverErr.Message
+	lower := strings.ToLower(msg)
+	if strings.Contains(lower, "explain") &&
+		(strings.Contains(lower, "unsupported") || strings.Contains(lower, "not supported")) {
+		return fmt.Errorf("%w: EXPLAIN: %s", ErrUnsupported, msg)
+	}
+
+	verr := &ValidationError{Message: msg, Err: err}
+	line, column, ok := errorPosition(msg)
+	switch {
+	case ok && line == 1:
+		// The prefix is on the first line, and the statement starts below it.
+		return fmt.Errorf("%w: EXPLAIN: %s", ErrUnsupported, msg)
+	case ok:
+		verr.Line, verr.Column = line-1, column
+	default:
+		_, probeErr := s.c.Statement(validatePrefix + validateProbe).Execute(ctx)
+		var probeServerErr *Error
+		if errors.As(probeErr, &probeServerErr) {
+			return fmt.Errorf("%w: EXPLAIN: %s", ErrUnsupported, msg)
+		}
+	}
+	return verr
+}
+
+// errorPosition returns the 1-based line and column reported in msg, if any.
+func errorPosition(msg string) (line, column int, ok bool) {
+	for _, pattern := range errorPositionPatterns {
+		m := pattern.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		line, _ = strconv.Atoi(m[1])
+		column, _ = strconv.Atoi(m[2])
+		return line, column, line > 0
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementValidate(t *testing.T) {
+	t.Parallel()
+
+	ok, _ := newResultTestServer(t, []resultSetField{{Name: "plan", DataType: "string"}}, [][]*string{{ptr("Values")}})
+	c := NewClient(&Config{Endpoint: ok.URL})
+	defer c.Close()
+	require.NoError(t, c.Statement("VALUES (1)").Validate(context.Background()))
+
+	invalid, stmts := newFailingTestServer(t, "syntax error at line 3, column 7: unexpected token")
+	c = NewClient(&Config{Endpoint: invalid.URL})
+	defer c.Close()
+	err := c.Statement("FROM t\nWHERE x\nSELEC y").Validate(context.Background())
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, 2, verr.Line)
+	require.Equal(t, 7, verr.Column)
+	require.EqualError(t, err, "invalid statement at line 2, column 7: syntax error at line 3, column 7: unexpected token")
+	var serverErr *Error
+	require.ErrorAs(t, err, &serverErr)
+	require.Equal(t, []string{"EXPLAIN\nFROM t\nWHERE x\nSELEC y"}, *stmts)
+
+	unsupported, _ := newFailingTestServer(t, "EXPLAIN is not supported")
+	c = NewClient(&Config{Endpoint: unsupported.URL})
+	defer c.Close()
+	err = c.Statement("VALUES (1)").Validate(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.NotErrorAs(t, err, &verr)
+
+	// A server that does not know EXPLAIN fails at the prefix.
+	unknown, stmts := newFailingTestServer(t, "syntax error at line 1, column 1: unexpected token 'EXPLAIN'")
+	c = NewClient(&Config{Endpoint: unknown.URL})
+	defer c.Close()
+	err = c.Statement("VALUES (1)").Validate(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.NotErrorAs(t, err, &verr)
+	require.Equal(t, []string{"EXPLAIN\nVALUES (1)"}, *stmts)
+}
+
+func TestStatementValidateWithoutPosition(t *testing.T) {
+	t.Parallel()
+
+	// Without a position, the failure of a trivial statement tells that the
+	// server does not support EXPLAIN.
+	unknown, stmts := newFailingTestServer(t, "unknown statement")
+	c := NewClient(&Config{Endpoint: unknown.URL})
+	defer c.Close()
+	err := c.Statement("FROM t").Validate(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.Equal(t, []string{"EXPLAIN\nFROM t", "EXPLAIN\nVALUES (1)"}, *stmts)
+
+	invalid, stmts := newSelectiveFailingTestServer(t, "table t not found", func(stmt string) bool {
+		return stmt != "EXPLAIN\nVALUES (1)"
+	})
+	c = NewClient(&Config{Endpoint: invalid.URL})
+	defer c.Close()
+	err = c.Statement("FROM t").Validate(context.Background())
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Zero(t, verr.Line)
+	require.ErrorIs(t, err, ErrTableNotFound)
+	require.Equal(t, []string{"EXPLAIN\nFROM t", "EXPLAIN\nVALUES (1)"}, *stmts)
+}
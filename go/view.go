@@ -0,0 +1,106 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// View creates a new Table object of TableKindView with the given name.
+//
+// The database and schema default to Config.Database and Config.Schema.
+func (c *Client) View(viewName string) *Table {
+	t := c.Table(viewName)
+	t.Kind = TableKindView
+	return t
+}
+
+// CreateViewOptions configures Table.CreateView.
+type CreateViewOptions struct {
+	// IfNotExists makes the creation a no-op if the view already exists.
+	IfNotExists bool
+	// OrReplace replaces the view if it already exists.
+	OrReplace bool
+}
+
+// CreateView creates a view with the given name and query.
+//
+// This method issues a CREATE VIEW statement to ScopeDB and blocks until done.
+// The query is embedded as is; quote identifiers in it with QuoteIdent or
+// Table.Identifier.
+func (t *Table) CreateView(ctx context.Context, query string, opts CreateViewOptions) error {
+	if strings.TrimSpace(query) == "" {
+		return errors.New("view query must not be empty")
+	}
+	if opts.IfNotExists && opts.OrReplace {
+		return errors.New("IfNotExists and OrReplace are mutually exclusive")
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if opts.OrReplace {
+		b.WriteString("OR REPLACE ")
+	}
+	b.WriteString("VIEW ")
+	if opts.IfNotExists {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(t.Identifier())
+	b.WriteString(" AS ")
+	b.WriteString(query)
+	if _, err := t.c.Statement(b.String()).Execute(ctx); err != nil {
+		return err
+	}
+	t.Kind = TableKindView
+	return nil
+}
+
+// DropView drops the view from ScopeDB.
+//
+// This method issues a DROP VIEW statement to ScopeDB and blocks until done.
+// At most one DropOptions may be given.
+func (t *Table) DropView(ctx context.Context, opts ...DropOptions) error {
+	ifExists := ""
+	for _, opt := range opts {
+		if opt.IfExists {
+			ifExists = "IF EXISTS "
+		}
+	}
+	s := t.c.Statement(fmt.Sprintf(`DROP VIEW %s%s`, ifExists, t.Identifier()))
+	_, err := s.Execute(ctx)
+	return err
+}
+
+// ViewDefinition returns the query that defines the view.
+//
+// This method issues a meta query on scopedb.system.views to ScopeDB and
+// blocks until the result is fetched.
+func (t *Table) ViewDefinition(ctx context.Context) (string, error) {
+	var definition string
+	err := t.c.Statement(fmt.Sprintf(`
+		FROM scopedb.system.views
+		WHERE %s
+		SELECT definition
+	`, t.systemFilter())).QueryRow(ctx).Scan(&definition)
+	if errors.Is(err, ErrNoRows) {
+		return "", fmt.Errorf("view %s not found", t.Identifier())
+	}
+	return definition, err
+}
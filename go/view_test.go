@@ -0,0 +1,86 @@
+/*
+ * Copyright 2024 ScopeDB, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scopedb
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableViews(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var stmts []string
+	server, _ := newRoutingTestServer(t, func(stmt string) ([]resultSetField, [][]*string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Contains(stmts, stmt) {
+			stmts = append(stmts, stmt)
+		}
+		switch {
+		case strings.Contains(stmt, "SELECT definition"):
+			return []resultSetField{{Name: "definition", DataType: "string"}}, [][]*string{{ptr("FROM `my events`")}}
+		case strings.Contains(stmt, "system.tables"):
+			return []resultSetField{{Name: "table_name", DataType: "string"}}, [][]*string{{ptr("b")}, {ptr("d")}}
+		case strings.Contains(stmt, "system.views"):
+			return []resultSetField{{Name: "table_name", DataType: "string"}}, [][]*string{{ptr("a")}, {ptr("c")}}
+		default:
+			return nil, nil
+		}
+	})
+	c := NewClient(&Config{Endpoint: server.URL})
+	defer c.Close()
+
+	ctx := context.Background()
+	view := c.View("recent")
+	require.Equal(t, TableKindView, view.Kind)
+	require.NoError(t, view.CreateView(ctx, "FROM `my events`", CreateViewOptions{IfNotExists: true}))
+	require.NoError(t, view.CreateView(ctx, "FROM `my events`", CreateViewOptions{OrReplace: true}))
+	require.NoError(t, view.DropView(ctx, DropOptions{IfExists: true}))
+	require.EqualError(t, view.CreateView(ctx, " ", CreateViewOptions{}), "view query must not be empty")
+
+	definition, err := view.ViewDefinition(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "FROM `my events`", definition)
+
+	tables, err := c.ListTables(ctx, "scopedb", "public")
+	require.NoError(t, err)
+	var kinds []string
+	for _, tbl := range tables {
+		kinds = append(kinds, tbl.Identifier()+" "+string(tbl.Kind))
+	}
+	require.Equal(t, []string{
+		"`scopedb`.`public`.`a` view",
+		"`scopedb`.`public`.`b` table",
+		"`scopedb`.`public`.`c` view",
+		"`scopedb`.`public`.`d` table",
+	}, kinds)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{
+		"CREATE VIEW IF NOT EXISTS `recent` AS FROM `my events`",
+		"CREATE OR REPLACE VIEW `recent` AS FROM `my events`",
+		"DROP VIEW IF EXISTS `recent`",
+	}, stmts[:3])
+}